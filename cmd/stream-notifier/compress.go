@@ -0,0 +1,71 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// compressMu は圧縮処理が重複して同じファイルを扱わないようにする。
+var compressMu sync.Mutex
+
+// compressOldLogs はlogDir内のtoday以外の日付のログファイル(*.log)をgzip圧縮し、元ファイルを削除する。
+func compressOldLogs(logDir, today string) {
+	compressMu.Lock()
+	defer compressMu.Unlock()
+
+	paths, err := filepath.Glob(filepath.Join(logDir, "*.log"))
+	if err != nil {
+		return
+	}
+	for _, path := range paths {
+		// ファイル名は app-YYYY-MM-DD.log / error-YYYY-MM-DD.log
+		if strings.HasSuffix(path, "-"+today+".log") {
+			continue
+		}
+		if err := gzipFile(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to compress log %s: %v\n", path, err)
+		}
+	}
+}
+
+// gzipFile はpathを path.gz に圧縮して元ファイルを削除する。
+func gzipFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+	zw.Name = filepath.Base(path)
+	_, copyErr := io.Copy(zw, src)
+	closeErr := zw.Close()
+	if err := dst.Close(); err != nil && closeErr == nil {
+		closeErr = err
+	}
+	if copyErr != nil || closeErr != nil {
+		_ = os.Remove(tmp)
+		if copyErr != nil {
+			return copyErr
+		}
+		return closeErr
+	}
+
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	_ = src.Close()
+	return os.Remove(path)
+}
@@ -4,6 +4,8 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log/slog"
@@ -14,12 +16,22 @@ import (
 	"sync"
 	"syscall"
 	"time"
+	// timezoneの名前をOSのタイムゾーンデータベースがない環境(Windows・最小構成のコンテナ)でも解決できるようにする
+	_ "time/tzdata"
 
+	"github.com/yuu1111/StreamNotifier/internal/audit"
+	"github.com/yuu1111/StreamNotifier/internal/broker"
 	"github.com/yuu1111/StreamNotifier/internal/cli"
-	"github.com/yuu1111/StreamNotifier/internal/config"
-	"github.com/yuu1111/StreamNotifier/internal/discord"
-	"github.com/yuu1111/StreamNotifier/internal/monitor"
-	"github.com/yuu1111/StreamNotifier/internal/twitch"
+	"github.com/yuu1111/StreamNotifier/internal/history"
+	"github.com/yuu1111/StreamNotifier/internal/httpclient"
+	"github.com/yuu1111/StreamNotifier/internal/lock"
+	"github.com/yuu1111/StreamNotifier/internal/notifier"
+	"github.com/yuu1111/StreamNotifier/internal/readsync"
+	"github.com/yuu1111/StreamNotifier/internal/server"
+	"github.com/yuu1111/StreamNotifier/internal/version"
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+	"github.com/yuu1111/StreamNotifier/pkg/discord"
+	"github.com/yuu1111/StreamNotifier/pkg/monitor"
 )
 
 // ANSI色コード
@@ -101,10 +113,16 @@ func (h *consoleHandler) WithGroup(name string) slog.Handler {
 
 // fileHandler はJSON形式のファイル出力ハンドラ。
 type fileHandler struct {
-	level  slog.Level
-	logDir string
-	mu     sync.Mutex
+	level   slog.Level
+	logDir  string
+	mu      sync.Mutex
 	ensured bool
+	// compress は日付の切り替わりで前日以前のログをgzip圧縮するか。
+	compress bool
+	// lastDate は直前に書き込んだログの日付。切り替わり検出に使う。
+	lastDate string
+	// loc はログファイル名の日付を決めるタイムゾーン。
+	loc *time.Location
 }
 
 // ensureDir はログディレクトリを確保する。
@@ -118,10 +136,9 @@ func (h *fileHandler) ensureDir() {
 	h.ensured = true
 }
 
-// getDateString はJST日付をYYYY-MM-DD形式で返す。
-func getDateString() string {
-	jst := time.FixedZone("JST", 9*60*60)
-	return time.Now().In(jst).Format("2006-01-02")
+// getDateString はlocでの今日の日付をYYYY-MM-DD形式で返す。
+func getDateString(loc *time.Location) string {
+	return time.Now().In(loc).Format("2006-01-02")
 }
 
 func (h *fileHandler) Enabled(_ context.Context, level slog.Level) bool {
@@ -154,7 +171,12 @@ func (h *fileHandler) Handle(_ context.Context, r slog.Record) error {
 	}
 	logLine := string(data) + "\n"
 
-	dateStr := getDateString()
+	dateStr := getDateString(h.loc)
+	if h.compress && h.lastDate != "" && h.lastDate != dateStr {
+		go compressOldLogs(h.logDir, dateStr)
+	}
+	h.lastDate = dateStr
+
 	appPath := filepath.Join(h.logDir, "app-"+dateStr+".log")
 	h.appendToFile(appPath, logLine)
 
@@ -246,89 +268,306 @@ func parseSlogLevel(level string) slog.Level {
 	}
 }
 
+// logLevelEnv は設定読み込み前のログレベルを上書きする環境変数名。
+const logLevelEnv = "STREAM_NOTIFIER_LOG_LEVEL"
+
+// initialLogLevel は設定読み込み前に使うログレベルを返す。
+// 環境変数で有効なレベルが指定されていればそれを優先し、config.Loadの失敗調査などに使えるようにする。
+func initialLogLevel() (level string, invalid bool) {
+	env := strings.ToLower(strings.TrimSpace(os.Getenv(logLevelEnv)))
+	switch env {
+	case "":
+		return config.LogInfo, false
+	case config.LogDebug, config.LogInfo, config.LogWarn, config.LogError:
+		return env, false
+	default:
+		return config.LogInfo, true
+	}
+}
+
 // setupLogger はslogのグローバルロガーをセットアップする。
-func setupLogger(level string) {
+// fileがfalseならログファイルには出力しない。compressがtrueなら前回までに残った前日以前のログも圧縮する。
+// ログファイル名の日付はlocのタイムゾーンで決める。
+func setupLogger(level string, file, compress bool, loc *time.Location) {
 	slogLevel := parseSlogLevel(level)
+	const logDir = "./logs"
 
-	handler := &multiHandler{
-		handlers: []slog.Handler{
-			&consoleHandler{level: slogLevel, w: os.Stdout},
-			&fileHandler{level: slogLevel, logDir: "./logs"},
-		},
+	handlers := []slog.Handler{&consoleHandler{level: slogLevel, w: os.Stdout}}
+	if file {
+		handlers = append(handlers, &fileHandler{level: slogLevel, logDir: logDir, compress: compress, loc: loc})
 	}
+	handler := &multiHandler{handlers: handlers}
 
 	slog.SetDefault(slog.New(handler))
+
+	if file && compress {
+		go compressOldLogs(logDir, getDateString(loc))
+	}
+}
+
+// newRetryQueue は設定からリトライキューを作成する。
+func newRetryQueue(cfg config.RetryQueueConfig) (*discord.RetryQueue, error) {
+	path := cfg.Path
+	if path == "" {
+		path = config.DefaultRetryQueuePath
+	}
+	maxAge := cfg.MaxAgeMinutes
+	if maxAge == 0 {
+		maxAge = config.DefaultRetryQueueMaxAgeMinutes
+	}
+	return discord.NewRetryQueue(path, time.Duration(maxAge)*time.Minute)
 }
 
-func startMonitor() error {
+// recordHistory は配信の開始・終了を配信履歴に記録する。
+func recordHistory(store *history.Store, changes []monitor.DetectedChange) {
+	now := time.Now()
+	for _, c := range changes {
+		switch c.Type {
+		case config.ChangeOnline:
+			startedAt, err := time.Parse(time.RFC3339, c.CurrentState.StartedAt)
+			if err != nil {
+				startedAt = now
+			}
+			store.RecordStart(c.Streamer, startedAt, c.CurrentState.Title, c.CurrentState.GameName)
+		case config.ChangeOffline:
+			store.RecordEnd(c.Streamer, now)
+		}
+	}
+}
+
+// startMonitor は監視を開始する。tagsが空でなければいずれかのタグを持つWebhookにのみ送信する。
+func startMonitor(tags []string) error {
 	// コンソールウィンドウのタイトルを設定
 	fmt.Print("\033]0;Stream Notifier\007")
 
-	slog.Info("Stream Notifier 起動中...")
+	slog.Info("Stream Notifier 起動中...", "version", version.String())
+	if p := config.Profile(); p != "" {
+		slog.Info("プロファイルを使用", "profile", p)
+	}
 
 	cfg, err := config.Load("./config.json")
 	if err != nil {
 		return err
 	}
+	// routesの解決は実行時のコピーにのみ行い、設定ファイルには書き戻さない
+	cfg = cfg.WithRoutes()
 
-	setupLogger(cfg.Log.Level)
+	setupLogger(cfg.Log.Level, cfg.Log.FileEnabled(), cfg.Log.Compress, cfg.Location())
+	httpclient.SetUserAgent(cfg.Network.UserAgent)
+	if err := httpclient.ConfigureTLS(cfg.Network.CAFile, cfg.Network.InsecureSkipVerify); err != nil {
+		return err
+	}
+	for _, w := range cfg.Warnings() {
+		slog.Warn(w)
+	}
 
-	auth := twitch.NewAuth(cfg.Twitch.ClientID, cfg.Twitch.ClientSecret)
-	api := twitch.NewAPI(auth, cfg.Twitch.ClientID)
+	api := monitor.NewTwitchAPI(cfg.Twitch)
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	poller := monitor.NewPoller(api, cfg, func(changes []monitor.DetectedChange, sc config.StreamerConfig) {
-		for _, change := range changes {
-			embed := discord.BuildEmbed(change)
-			streamerInfo := discord.StreamerInfo{
-				DisplayName:     change.CurrentState.DisplayName,
-				ProfileImageURL: change.CurrentState.ProfileImageURL,
+	if cfg.Singleton.Enabled {
+		l, err := acquireSingleton(ctx, cfg)
+		if err != nil {
+			// 待機中に終了シグナルを受けた場合は正常終了とする
+			if ctx.Err() != nil {
+				return nil
 			}
+			return err
+		}
+		defer func() { _ = l.Release() }()
+	}
 
-			for _, webhook := range sc.Webhooks {
-				if !config.IsNotificationEnabled(change.Type, webhook.Notifications) {
-					continue
-				}
-
-				webhookLabel := webhook.Name
-				if webhookLabel == "" {
-					webhookLabel = "Webhook"
-				}
-
-				logMsg := fmt.Sprintf("[%s] %s → %s",
-					change.CurrentState.DisplayName, change.Type, webhookLabel)
-				if change.NewValue != "" {
-					logMsg += fmt.Sprintf(" (%s)", change.NewValue)
-				}
-				slog.Info(logMsg)
-
-				if err := discord.SendWebhook(ctx, webhook.URL, embed, streamerInfo); err != nil {
-					slog.Error("Webhook送信失敗", "error", err)
-				}
-			}
+	discord.SetDeadWebhookThreshold(cfg.DeadWebhookFailures())
+	discord.SetSendConcurrency(cfg.SendConcurrency())
+	if cfg.DeadLetter.Enabled {
+		discord.SetDeadLetter(cfg.DeadLetter.DeadLetterPath(), cfg.DeadLetter.MaxDeadLetters())
+	}
+	var queue *discord.RetryQueue
+	if cfg.RetryQueue.Enabled {
+		queue, err = newRetryQueue(cfg.RetryQueue)
+		if err != nil {
+			return err
 		}
+		go queue.Run(ctx)
+	}
+
+	dispatcher := notifier.NewDispatcher(cfg, queue)
+	if len(tags) > 0 {
+		slog.Info("タグで送信先を限定", "tags", tags)
+		dispatcher.SetTags(tags)
+	}
+	if cfg.Log.Audit.Enabled {
+		auditLog, err := audit.Open(cfg.AuditLogPath())
+		if err != nil {
+			return err
+		}
+		defer func() { _ = auditLog.Close() }()
+		dispatcher.SetAudit(auditLog)
+	}
+	if cfg.ReadSync.Enabled {
+		tracker, err := readsync.New(cfg.ReadSync)
+		if err != nil {
+			return err
+		}
+		dispatcher.SetReadSync(tracker)
+		go tracker.Run(ctx)
+	}
+	var publisher *broker.AsyncPublisher
+	if cfg.Broker.Enabled {
+		p, err := broker.NewPublisher(cfg.Broker)
+		if err != nil {
+			return err
+		}
+		publisher = broker.NewAsyncPublisher(p)
+		go publisher.Run(ctx)
+	}
+
+	var historyStore *history.Store
+	if cfg.History.Enabled {
+		historyStore, err = history.Open(cfg.HistoryPath())
+		if err != nil {
+			return err
+		}
+	}
+
+	srv := server.New(cfg.Server)
+
+	// 送信中の通知はシグナル受信後もshutdownTimeoutまでは完了を待ち、過ぎたらキャンセルする。
+	// 送信はポーリングの中で同期的に行うため、poller.Runは送信の完了かキャンセルを待ってから戻る
+	sendCtx, cancelSends := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelSends()
+	go cancelSendsAfterShutdown(ctx, sendCtx, cancelSends, shutdownTimeout)
+	dispatch := func(changes []monitor.DetectedChange, sc config.StreamerConfig) {
+		dispatcher.Dispatch(sendCtx, changes, sc)
+	}
+
+	poller := monitor.NewPoller(api, cfg, func(changes []monitor.DetectedChange, sc config.StreamerConfig) {
+		// 保留していた配信開始通知は検出時に記録・配信済みのため送信のみ行う
+		if len(changes) == 1 && changes[0].Deferred {
+			dispatch(changes, sc)
+			return
+		}
+		if historyStore != nil {
+			recordHistory(historyStore, changes)
+		}
+		if publisher != nil {
+			publisher.Enqueue(changes)
+		}
+		for _, c := range changes {
+			srv.Broadcast(broker.NewEvent(c))
+		}
+		dispatch(changes, sc)
 	})
+	if cfg.Polling.Auto.Enabled {
+		poller.SetFrequencyProvider(historyStore.StreamsPerWeek)
+	}
+
+	// HTTPサーバーはポーリングと独立して動かし、失敗しても監視は継続する
+	srv.SetStatusProvider(func() any { return poller.Stats() })
+	if srv.Enabled() {
+		go func() {
+			if err := srv.Run(ctx); err != nil {
+				slog.Error("HTTPサーバーエラー", "error", err)
+			}
+		}()
+	}
+
+	// SIGUSR1で次の定期ポーリングを待たずに全配信者をポーリングする(Windowsでは無効)
+	go watchManualPoll(ctx, poller.TriggerPoll)
 
 	return poller.Run(ctx)
 }
 
+// acquireSingleton は多重起動防止のロックを取得する。singleton.waitが有効なら他のインスタンスの終了を待つ。
+func acquireSingleton(ctx context.Context, cfg *config.Config) (*lock.Lock, error) {
+	path := cfg.SingletonLockPath()
+	l, err := lock.TryAcquire(path)
+	if !errors.Is(err, lock.ErrLocked) {
+		return l, err
+	}
+	if !cfg.Singleton.Wait {
+		return nil, fmt.Errorf("同じ設定のStream Notifierが既に起動しています (%s): %w", path, err)
+	}
+	slog.Info("他のインスタンスの終了を待機中...", "lock", path)
+	return lock.Wait(ctx, path)
+}
+
+// shutdownTimeout は終了時に送信中の通知の完了を待つ上限。
+const shutdownTimeout = 5 * time.Second
+
+// cancelSendsAfterShutdown はctxのキャンセル(終了シグナル)からtimeoutが経っても送信が終わっていなければ
+// cancelで送信中の通知を中断する。sendCtxが先に終われば何もしない。
+func cancelSendsAfterShutdown(ctx, sendCtx context.Context, cancel context.CancelFunc, timeout time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-sendCtx.Done():
+		return
+	}
+	select {
+	case <-sendCtx.Done():
+	case <-time.After(timeout):
+		slog.Warn("送信中の通知が時間内に完了しなかったため中断します", "timeout", timeout.String())
+		cancel()
+	}
+}
+
+// extractProfile は全コマンド共通の"--profile <name>"または"--profile=<name>"を取り出し、残りの引数を返す。
+func extractProfile(args []string) (profile string, rest []string) {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--profile" && i+1 < len(args):
+			profile = args[i+1]
+			i++
+		case strings.HasPrefix(a, "--profile="):
+			profile = strings.TrimPrefix(a, "--profile=")
+		default:
+			rest = append(rest, a)
+		}
+	}
+	return profile, rest
+}
+
 func main() {
-	args := os.Args[1:]
+	profile, args := extractProfile(os.Args[1:])
+	config.SetProfile(profile)
 
 	// 引数なし or "run" → 監視開始
 	if len(args) == 0 || args[0] == "run" {
 		// 起動前にデフォルトロガーをセットアップ(設定読み込み前のログ用)
-		setupLogger(config.LogInfo)
+		// log.file.enabledはまだ分からないためコンソールにのみ出力し、ログファイルは設定読み込み後に追加する
+		level, invalid := initialLogLevel()
+		setupLogger(level, false, false, config.DefaultLocation)
+		if invalid {
+			slog.Warn("無効なログレベルのため無視します", "env", logLevelEnv, "value", os.Getenv(logLevelEnv))
+		}
 
-		if err := startMonitor(); err != nil {
-			slog.Error("致命的なエラー", "error", err)
+		var runArgs []string
+		if len(args) > 0 {
+			runArgs = args[1:]
+		}
+		fs := flag.NewFlagSet("run", flag.ExitOnError)
+		tag := fs.String("tag", "", "指定タグ(カンマ区切り)を持つWebhookにのみ送信")
+		_ = fs.Parse(runArgs)
+
+		if err := startMonitor(config.ParseTags(*tag)); err != nil {
+			var validationErr *config.ValidationError
+			switch {
+			case errors.Is(err, config.ErrConfigNotFound):
+				slog.Error(fmt.Sprintf("config.json が見つかりません。`%s init` で作成してください", filepath.Base(os.Args[0])))
+			case errors.Is(err, config.ErrSchemaTooNew):
+				slog.Error("設定ファイルを読み込めません", "error", err, "version", version.String())
+			case errors.As(err, &validationErr):
+				slog.Error("設定ファイルの内容が不正です", "field", validationErr.Field, "error", validationErr.Err)
+			default:
+				slog.Error("致命的なエラー", "error", err)
+			}
 			os.Exit(1)
 		}
 		return
 	}
 
-	// その他 → CLI
+	// その他 (version/--version 含む) → CLI
 	cli.Run(args)
 }
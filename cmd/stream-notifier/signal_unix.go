@@ -0,0 +1,26 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchManualPoll はSIGUSR1を受信するたびにtriggerを呼び出す。ctxがキャンセルされたら受信をやめて戻る。
+func watchManualPoll(ctx context.Context, trigger func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGUSR1)
+	defer signal.Stop(ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+			trigger()
+		}
+	}
+}
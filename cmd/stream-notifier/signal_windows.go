@@ -0,0 +1,8 @@
+//go:build windows
+
+package main
+
+import "context"
+
+// watchManualPoll はWindowsにSIGUSR1がないため何もしない。
+func watchManualPoll(context.Context, func()) {}
@@ -0,0 +1,102 @@
+// custom-sink は検出した変更を独自の通知先に渡す例。
+// Discordへの送信の代わりに、変更を1行のJSONとして標準出力に書き出す。
+//
+//	go run ./examples/custom-sink
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	streamnotifier "github.com/yuu1111/StreamNotifier"
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+	"github.com/yuu1111/StreamNotifier/pkg/monitor"
+)
+
+// Sink は変更を受け取る独自の通知先。
+type Sink interface {
+	Send(change monitor.DetectedChange, streamer config.StreamerConfig) error
+}
+
+// jsonLinesSink は変更を1行のJSONとして書き出すSink。
+type jsonLinesSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func newJSONLinesSink(w io.Writer) *jsonLinesSink {
+	return &jsonLinesSink{enc: json.NewEncoder(w)}
+}
+
+// event はSinkが書き出す1件の変更。
+type event struct {
+	Type     config.ChangeType `json:"type"`
+	Username string            `json:"username"`
+	Streamer string            `json:"streamer"`
+	StreamID string            `json:"streamId,omitempty"`
+	Title    string            `json:"title,omitempty"`
+	Game     string            `json:"game,omitempty"`
+	OldValue string            `json:"oldValue,omitempty"`
+	NewValue string            `json:"newValue,omitempty"`
+	Live     bool              `json:"live"`
+	At       time.Time         `json:"at"`
+}
+
+func (s *jsonLinesSink) Send(change monitor.DetectedChange, streamer config.StreamerConfig) error {
+	state := change.CurrentState
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(event{
+		Type:     change.Type,
+		Username: streamer.Username,
+		Streamer: change.Streamer,
+		StreamID: change.StreamID,
+		Title:    state.Title,
+		Game:     state.GameName,
+		OldValue: change.OldValue,
+		NewValue: change.NewValue,
+		Live:     state.IsLive,
+		At:       time.Now(),
+	})
+}
+
+func main() {
+	cfg, err := config.Load("./config.json")
+	if err != nil {
+		slog.Error("設定の読み込みに失敗", "error", err)
+		os.Exit(1)
+	}
+	m, err := streamnotifier.New(cfg)
+	if err != nil {
+		slog.Error("初期化に失敗", "error", err)
+		os.Exit(1)
+	}
+
+	var sink Sink = newJSONLinesSink(os.Stdout)
+	// ハンドラーを設定するとconfig.jsonのWebhookへは送信しない
+	m.OnChanges(func(changes []monitor.DetectedChange, sc config.StreamerConfig) {
+		for _, c := range changes {
+			// Deferredは保留していた通知の遅延送信。検出時に一度渡しているため二重に扱わない
+			if c.Deferred {
+				continue
+			}
+			if err := sink.Send(c, sc); err != nil {
+				slog.Error("通知先への送信に失敗", "streamer", c.Streamer, "type", c.Type, "error", err)
+			}
+		}
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if err := m.Run(ctx); err != nil && ctx.Err() == nil {
+		slog.Error("監視を終了しました", "error", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,111 @@
+// Package audit は通知ごとの送信判断と結果をJSON Linesで記録する監査ログを提供する。
+// アプリのログとは別の、集計・分析向けのイベントストリーム。
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+)
+
+// Outcome は1つのWebhookに対する通知の結果。
+type Outcome = string
+
+const (
+	OutcomeSent       Outcome = "sent"
+	OutcomeFailed     Outcome = "failed"
+	OutcomeSuppressed Outcome = "suppressed"
+)
+
+// Reason は通知を抑制した理由。
+type Reason = string
+
+const (
+	// ReasonDisabled はWebhookでその通知タイプが無効。
+	ReasonDisabled Reason = "disabled"
+	// ReasonTag は--tagで指定したタグをWebhookが持たない。
+	ReasonTag Reason = "tag"
+	// ReasonGameFilter はゲームフィルタに一致しない。
+	ReasonGameFilter Reason = "gameFilter"
+	// ReasonDuplicateURL は同じURLへ送信済み。
+	ReasonDuplicateURL Reason = "duplicateUrl"
+	// ReasonWebhookDisabled は404/401が続いたためWebhookへの送信を停止中。
+	ReasonWebhookDisabled Reason = "webhookDisabled"
+	// ReasonCircuitOpen はサーキットブレーカーで通知を停止中。
+	ReasonCircuitOpen Reason = "circuitOpen"
+)
+
+// Record は1つの変更を1つのWebhookへ通知した(または抑制した)記録。
+type Record struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Streamer  string            `json:"streamer"`
+	Type      config.ChangeType `json:"type"`
+	// Title は送信したEmbedのタイトル。
+	Title    string `json:"title,omitempty"`
+	OldValue string `json:"oldValue,omitempty"`
+	NewValue string `json:"newValue,omitempty"`
+	// Webhook はWebhookの名前。未設定なら"Webhook"。URLは秘密情報のため記録しない。
+	Webhook string `json:"webhook"`
+	// WebhookIndex は配信者のwebhooks内の位置。
+	WebhookIndex int     `json:"webhookIndex"`
+	Outcome      Outcome `json:"outcome"`
+	Reason       Reason  `json:"reason,omitempty"`
+	Error        string  `json:"error,omitempty"`
+}
+
+// Logger は監査ログの書き出し先。並行して呼び出してよい。
+type Logger struct {
+	mu sync.Mutex
+	w  io.Writer
+	// closer はファイルに書き出す場合のみ設定する。
+	closer io.Closer
+}
+
+// Open はpathへ追記する監査ログを開く。pathが"-"なら標準出力に書き出す。
+func Open(path string) (*Logger, error) {
+	if path == "-" {
+		return &Logger{w: os.Stdout}, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("監査ログディレクトリの作成に失敗: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("監査ログを開けません: %w", err)
+	}
+	return &Logger{w: f, closer: f}, nil
+}
+
+// Write は記録を1行のJSONとして書き出す。Timestampが未設定なら現在時刻にする。
+// 書き込みに失敗しても通知は継続するため、エラーはログに残すのみとする。
+func (l *Logger) Write(r Record) {
+	if r.Timestamp.IsZero() {
+		r.Timestamp = time.Now()
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		slog.Error("監査ログのシリアライズに失敗", "error", err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.w.Write(append(data, '\n')); err != nil {
+		slog.Error("監査ログの書き込みに失敗", "error", err)
+	}
+}
+
+// Close はファイルを閉じる。標準出力の場合は何もしない。
+func (l *Logger) Close() error {
+	if l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
@@ -0,0 +1,81 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+	"github.com/yuu1111/StreamNotifier/pkg/monitor"
+)
+
+// publishBufferSize は非同期発行の待ち行列の長さ。溢れた分は破棄する。
+const publishBufferSize = 256
+
+// Publisher はメッセージブローカーへの発行手段。
+type Publisher interface {
+	Publish(ctx context.Context, data []byte) error
+	Close() error
+}
+
+// NewPublisher は設定の種別に応じたPublisherを作成する。
+func NewPublisher(cfg config.BrokerConfig) (Publisher, error) {
+	switch cfg.Type {
+	case config.BrokerNATS:
+		return newNATSPublisher(cfg.URL, cfg.Topic), nil
+	case config.BrokerKafkaREST:
+		return newKafkaRESTPublisher(cfg.URL, cfg.Topic), nil
+	default:
+		return nil, fmt.Errorf("不明なブローカー種別: %s", cfg.Type)
+	}
+}
+
+// AsyncPublisher はイベントを待ち行列に積み、別ゴルーチンで発行する。
+// 発行の失敗や遅延が通知本体に影響しないようにする。
+type AsyncPublisher struct {
+	publisher Publisher
+	events    chan Event
+}
+
+// NewAsyncPublisher はAsyncPublisherを作成する。
+func NewAsyncPublisher(publisher Publisher) *AsyncPublisher {
+	return &AsyncPublisher{
+		publisher: publisher,
+		events:    make(chan Event, publishBufferSize),
+	}
+}
+
+// Enqueue は変更をイベントとして待ち行列に積む。満杯なら破棄する。
+func (a *AsyncPublisher) Enqueue(changes []monitor.DetectedChange) {
+	for _, change := range changes {
+		select {
+		case a.events <- NewEvent(change):
+		default:
+			slog.Warn("ブローカー発行の待ち行列が満杯のためイベントを破棄", "streamer", change.Streamer, "type", change.Type)
+		}
+	}
+}
+
+// Run はctxがキャンセルされるまで待ち行列のイベントを発行する。
+func (a *AsyncPublisher) Run(ctx context.Context) {
+	defer func() { _ = a.publisher.Close() }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-a.events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				slog.Error("イベントのJSON変換に失敗", "error", err)
+				continue
+			}
+			if err := a.publisher.Publish(ctx, data); err != nil {
+				slog.Error("ブローカーへの発行失敗", "streamer", event.Streamer, "type", event.Type, "error", err)
+				continue
+			}
+			slog.Debug("ブローカーへ発行", "streamer", event.Streamer, "type", event.Type)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Package broker は検出した変更をメッセージブローカーへ発行する。
+package broker
+
+import (
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+	"github.com/yuu1111/StreamNotifier/pkg/monitor"
+)
+
+// EventSchemaVersion は発行するイベントのスキーマバージョン。
+// フィールドの削除・意味の変更を行う場合にのみ上げる(追加は互換とみなす)。
+const EventSchemaVersion = 1
+
+// Event はブローカーに発行するプラットフォーム非依存の安定したイベント構造。
+type Event struct {
+	SchemaVersion    int               `json:"schemaVersion"`
+	Type             config.ChangeType `json:"type"`
+	Platform         config.Platform   `json:"platform"`
+	Streamer         string            `json:"streamer"`
+	DisplayName      string            `json:"displayName"`
+	IsLive           bool              `json:"isLive"`
+	Title            string            `json:"title"`
+	GameName         string            `json:"gameName"`
+	OldValue         string            `json:"oldValue,omitempty"`
+	NewValue         string            `json:"newValue,omitempty"`
+	OldTitle         string            `json:"oldTitle,omitempty"`
+	NewTitle         string            `json:"newTitle,omitempty"`
+	OldGame          string            `json:"oldGame,omitempty"`
+	NewGame          string            `json:"newGame,omitempty"`
+	StreamStartedAt  string            `json:"streamStartedAt,omitempty"`
+	StreamID         string            `json:"streamId,omitempty"`
+	ScheduledStartAt string            `json:"scheduledStartAt,omitempty"`
+	VodURL           string            `json:"vodUrl,omitempty"`
+	OccurredAt       string            `json:"occurredAt"`
+}
+
+// NewEvent はDetectedChangeからイベントを構築する。
+func NewEvent(change monitor.DetectedChange) Event {
+	state := change.CurrentState
+	startedAt := change.StreamStartedAt
+	if startedAt == "" && state.IsLive {
+		startedAt = state.StartedAt
+	}
+	// 配信IDは配信開始と配信終了のイベントを対応付けるのに使う
+	streamID := change.StreamID
+	if streamID == "" && state.IsLive {
+		streamID = state.StreamID
+	}
+	return Event{
+		SchemaVersion:    EventSchemaVersion,
+		Type:             change.Type,
+		Platform:         change.Platform,
+		Streamer:         change.Streamer,
+		DisplayName:      state.DisplayName,
+		IsLive:           state.IsLive,
+		Title:            state.Title,
+		GameName:         state.GameName,
+		OldValue:         change.OldValue,
+		NewValue:         change.NewValue,
+		OldTitle:         change.OldTitle,
+		NewTitle:         change.NewTitle,
+		OldGame:          change.OldGame,
+		NewGame:          change.NewGame,
+		StreamStartedAt:  startedAt,
+		StreamID:         streamID,
+		ScheduledStartAt: change.ScheduledStartAt,
+		VodURL:           change.VodURL,
+		OccurredAt:       time.Now().UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,64 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/internal/httpclient"
+)
+
+// kafkaRESTPublisher はConfluent REST Proxy(v2 API)経由でKafkaトピックへ発行する。
+// Kafkaのバイナリプロトコルは実装せず、HTTPのみで完結させる。
+type kafkaRESTPublisher struct {
+	endpoint string
+}
+
+// newKafkaRESTPublisher はkafkaRESTPublisherを作成する。proxyURLはREST ProxyのベースURL。
+func newKafkaRESTPublisher(proxyURL, topic string) *kafkaRESTPublisher {
+	return &kafkaRESTPublisher{
+		endpoint: strings.TrimSuffix(proxyURL, "/") + "/topics/" + url.PathEscape(topic),
+	}
+}
+
+// Publish はデータを1レコードとしてトピックに発行する。
+func (k *kafkaRESTPublisher) Publish(ctx context.Context, data []byte) error {
+	body, err := json.Marshal(map[string]any{
+		"records": []map[string]json.RawMessage{{"value": data}},
+	})
+	if err != nil {
+		return fmt.Errorf("レコードのJSON変換に失敗: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("リクエスト作成に失敗: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Kafka REST Proxyへの送信に失敗: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Kafka REST Proxyエラー: %d %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// Close は何もしない(HTTPのため保持する接続がない)。
+func (k *kafkaRESTPublisher) Close() error {
+	return nil
+}
@@ -0,0 +1,112 @@
+package broker
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// natsPublisher はNATSのテキストプロトコルでPUBする最小限のクライアント。
+// 接続は初回発行時に確立し、エラー時は次回発行時に再接続する。
+type natsPublisher struct {
+	addr    string
+	subject string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// newNATSPublisher はnatsPublisherを作成する。serverURLは"nats://host:4222"形式。
+func newNATSPublisher(serverURL, subject string) *natsPublisher {
+	addr := serverURL
+	if u, err := url.Parse(serverURL); err == nil && u.Host != "" {
+		addr = u.Host
+	}
+	return &natsPublisher{addr: addr, subject: subject}
+}
+
+// connect はNATSサーバーに接続してCONNECTを送り、サーバーからのPINGに応答するゴルーチンを起動する。
+func (n *natsPublisher) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: 10 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return fmt.Errorf("NATS接続に失敗: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	_ = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	info, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(info, "INFO") {
+		_ = conn.Close()
+		return fmt.Errorf("NATSサーバーの応答が不正です: %q", info)
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+
+	if _, err := fmt.Fprint(conn, "CONNECT {\"verbose\":false,\"pedantic\":false,\"name\":\"stream-notifier\"}\r\n"); err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("NATS CONNECT送信に失敗: %w", err)
+	}
+
+	n.conn = conn
+	go n.readLoop(conn, reader)
+	return nil
+}
+
+// readLoop はサーバーからのPINGにPONGで応答する。接続が切れたら終了する。
+func (n *natsPublisher) readLoop(conn net.Conn, reader *bufio.Reader) {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			n.mu.Lock()
+			if n.conn == conn {
+				n.conn = nil
+			}
+			n.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		if strings.HasPrefix(line, "PING") {
+			n.mu.Lock()
+			_, _ = fmt.Fprint(conn, "PONG\r\n")
+			n.mu.Unlock()
+		}
+	}
+}
+
+// Publish はsubjectにデータをPUBする。
+func (n *natsPublisher) Publish(ctx context.Context, data []byte) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn == nil {
+		if err := n.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	_ = n.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if _, err := fmt.Fprintf(n.conn, "PUB %s %d\r\n%s\r\n", n.subject, len(data), data); err != nil {
+		_ = n.conn.Close()
+		n.conn = nil
+		return fmt.Errorf("NATS PUBに失敗: %w", err)
+	}
+	return nil
+}
+
+// Close は接続を閉じる。
+func (n *natsPublisher) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn == nil {
+		return nil
+	}
+	err := n.conn.Close()
+	n.conn = nil
+	return err
+}
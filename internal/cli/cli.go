@@ -3,17 +3,37 @@ package cli
 
 import (
 	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
-
-	"github.com/yuu1111/StreamNotifier/internal/config"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/internal/history"
+	"github.com/yuu1111/StreamNotifier/internal/httpclient"
+	"github.com/yuu1111/StreamNotifier/internal/notifier"
+	"github.com/yuu1111/StreamNotifier/internal/version"
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+	"github.com/yuu1111/StreamNotifier/pkg/discord"
+	"github.com/yuu1111/StreamNotifier/pkg/monitor"
+	"github.com/yuu1111/StreamNotifier/pkg/twitch"
 )
 
 const configPath = "./config.json"
 
+const (
+	// defaultVerifyConcurrency はWebhook疎通確認のデフォルト並列数。Discordのレート制限を考慮して小さめにする。
+	defaultVerifyConcurrency = 4
+	// defaultVerifyTimeoutSeconds はWebhook疎通確認1件あたりのデフォルトタイムアウト秒数。
+	defaultVerifyTimeoutSeconds = 10
+)
+
 var scanner *bufio.Scanner
 
 // getScanner はstdin用のscannerを遅延初期化して返す。
@@ -48,273 +68,1464 @@ func getEnabledNotificationTypes(n config.NotificationSettings) string {
 	if n.Offline {
 		types = append(types, "offline")
 	}
-	if n.TitleChange {
-		types = append(types, "title")
+	if n.TitleChange {
+		types = append(types, "title")
+	}
+	if n.GameChange {
+		types = append(types, "game")
+	}
+	if len(types) == 4 {
+		types = []string{"全通知"}
+	}
+	if n.Schedule {
+		types = append(types, "schedule")
+	}
+	if n.ProfileUpdate {
+		types = append(types, "profile")
+	}
+	if n.VodPublished {
+		types = append(types, "vod")
+	}
+	return strings.Join(types, ", ")
+}
+
+// formatTags はタグを表示用文字列に変換する。タグがなければ空文字列を返す。
+func formatTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(tags, ", ") + "]"
+}
+
+// webhookLabel はWebhookの表示名を返す。名前がなければ先頭のURLを切り詰めて使う。
+func webhookLabel(w config.WebhookConfig) string {
+	if w.Name != "" {
+		return w.Name
+	}
+	targets := w.Targets()
+	if len(targets) == 0 {
+		return "(URLなし)"
+	}
+	return truncateURL(targets[0], 50)
+}
+
+// printWebhooks は登録済みWebhookを番号付きで表示する。
+func printWebhooks(webhooks []config.WebhookConfig) {
+	fmt.Println("登録済みWebhook:")
+	for i, w := range webhooks {
+		label := webhookLabel(w)
+		if w.IsGroup() {
+			label += fmt.Sprintf(" [グループ: URL %d件]", len(w.Targets()))
+		}
+		enabled := getEnabledNotificationTypes(w.Notifications)
+		fmt.Printf("  %d. %s (%s)%s\n", i+1, label, enabled, formatTags(w.Tags))
+	}
+}
+
+// hasWebhookURL は配信者のいずれかのWebhook(グループ含む)にURLが登録済みか判定する。
+func hasWebhookURL(webhooks []config.WebhookConfig, url string) bool {
+	for _, w := range webhooks {
+		if slices.Contains(w.Targets(), url) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectWebhook はWebhook一覧を表示して番号入力で選択させ、0-basedのインデックスを返す。
+func selectWebhook(webhooks []config.WebhookConfig, prompt string) int {
+	printWebhooks(webhooks)
+
+	input := promptInput(prompt)
+	index, err := strconv.Atoi(input)
+	if err != nil || index < 1 || index > len(webhooks) {
+		fmt.Fprintln(os.Stderr, "エラー: 無効な番号です")
+		os.Exit(1)
+	}
+	return index - 1
+}
+
+// updateConfig はロックを取得して読み込んだ最新の設定にfnを適用し保存する。失敗したらエラーを表示して終了する。
+// 対話入力中に他のCLIが保存した変更を上書きしないよう、入力を終えてから呼ぶ。
+func updateConfig(fn func(cfg *config.Config) error) {
+	if err := config.WithLock(configPath, fn); err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// lockedWebhook はロック内で読み込んだ設定から、ロック前に選択したWebhookを名前と送信先で探してインデックスを返す。
+// 選択後に他の操作で変更・削除されていればエラーを返す。
+func lockedWebhook(cfg *config.Config, username string, selected config.WebhookConfig) (*config.StreamerConfig, int, error) {
+	streamer := findStreamer(cfg.Streamers, username)
+	if streamer == nil {
+		return nil, -1, fmt.Errorf("%s は登録されていません", username)
+	}
+	i := slices.IndexFunc(streamer.Webhooks, func(w config.WebhookConfig) bool {
+		return w.Name == selected.Name && slices.Equal(w.Targets(), selected.Targets())
+	})
+	if i < 0 {
+		return nil, -1, fmt.Errorf("選択したWebhookが他の操作で変更されました。もう一度実行してください")
+	}
+	return streamer, i, nil
+}
+
+// flagValue は"--name value"または"--name=value"形式のオプション値を返す。
+func flagValue(args []string, name string) string {
+	prefix := "--" + name
+	for i, a := range args {
+		if a == prefix && i+1 < len(args) {
+			return args[i+1]
+		}
+		if v, ok := strings.CutPrefix(a, prefix+"="); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// parseIntFlag は整数オプションを取得する。未指定ならdefaultValを返し、不正な値ならエラー終了する。
+func parseIntFlag(args []string, name string, defaultVal int) int {
+	v := flagValue(args, name)
+	if v == "" {
+		return defaultVal
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		fmt.Fprintf(os.Stderr, "エラー: --%s には1以上の整数を指定してください\n", name)
+		os.Exit(1)
+	}
+	return n
+}
+
+// findStreamer はユーザー名で配信者を検索する。
+func findStreamer(streamers []config.StreamerConfig, username string) *config.StreamerConfig {
+	lower := strings.ToLower(username)
+	for i := range streamers {
+		if strings.ToLower(streamers[i].Username) == lower {
+			return &streamers[i]
+		}
+	}
+	return nil
+}
+
+// findStreamerIndex はユーザー名で配信者のインデックスを検索する。
+func findStreamerIndex(streamers []config.StreamerConfig, username string) int {
+	lower := strings.ToLower(username)
+	for i := range streamers {
+		if strings.ToLower(streamers[i].Username) == lower {
+			return i
+		}
+	}
+	return -1
+}
+
+// defaultInitIntervalSeconds はinitコマンドで提示するポーリング間隔の初期値。
+const defaultInitIntervalSeconds = 30
+
+// initConfig は対話形式で最小構成のconfig.jsonを作成する。既存の設定ファイルは上書きしない。
+func initConfig() {
+	if config.Profile() != "" {
+		fmt.Fprintln(os.Stderr, "エラー: initは--profileを指定せずに実行してください。プロファイルはconfig.jsonのprofilesに追加します")
+		os.Exit(1)
+	}
+	if _, err := os.Stat(configPath); err == nil {
+		fmt.Fprintf(os.Stderr, "エラー: %s は既に存在します。上書きする場合は削除してから実行してください\n", configPath)
+		os.Exit(1)
+	}
+
+	fmt.Println("設定ファイルを作成します (Twitchの認証情報は https://dev.twitch.tv/console で取得できます)")
+	clientID := promptInput("Twitch Client ID: ")
+	clientSecret := promptInput("Twitch Client Secret: ")
+
+	interval := defaultInitIntervalSeconds
+	if input := promptInput(fmt.Sprintf("ポーリング間隔(秒) [%d]: ", interval)); input != "" {
+		n, err := strconv.Atoi(input)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "エラー: ポーリング間隔は数値で入力してください")
+			os.Exit(1)
+		}
+		interval = n
+	}
+
+	username, err := config.NormalizeUsername(promptInput("最初に登録する配信者のユーザー名: "))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+	webhookURL := promptInput("Webhook URL: ")
+	if !validateWebhookURL(webhookURL) {
+		fmt.Fprintln(os.Stderr, "エラー: 無効なWebhook URLです")
+		os.Exit(1)
+	}
+
+	cfg := &config.Config{
+		Twitch:  config.TwitchConfig{ClientID: clientID, ClientSecret: clientSecret},
+		Polling: config.PollingConfig{IntervalSeconds: interval},
+		Streamers: []config.StreamerConfig{
+			{
+				Username: username,
+				Webhooks: []config.WebhookConfig{
+					{URL: webhookURL, Notifications: config.DefaultNotificationSettings},
+				},
+			},
+		},
+		Log: config.LogConfig{Level: config.LogInfo},
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+	if err := config.Save(configPath, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s を作成しました。%s で監視を開始できます\n", configPath, getExeName())
+}
+
+// addStreamer は配信者を追加する。
+func addStreamer(username string) {
+	username, err := config.NormalizeUsername(username)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+
+	if findStreamer(cfg.Streamers, username) != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %s は既に登録されています\n", username)
+		os.Exit(1)
+	}
+
+	webhookName := promptInput("Webhook名 (任意): ")
+	webhookURL := promptInput("Webhook URL: ")
+	if !validateWebhookURL(webhookURL) {
+		fmt.Fprintln(os.Stderr, "エラー: 無効なWebhook URLです")
+		os.Exit(1)
+	}
+
+	tags := config.ParseTags(promptInput("タグ (カンマ区切り, 任意): "))
+
+	newStreamer := config.StreamerConfig{
+		Username: username,
+		Webhooks: []config.WebhookConfig{
+			{
+				Name:          webhookName,
+				URL:           webhookURL,
+				Notifications: cfg.DefaultNotifications(),
+				Tags:          tags,
+			},
+		},
+	}
+
+	updateConfig(func(cfg *config.Config) error {
+		if findStreamer(cfg.Streamers, username) != nil {
+			return fmt.Errorf("%s は既に登録されています", username)
+		}
+		cfg.Streamers = append(cfg.Streamers, newStreamer)
+		return nil
+	})
+	fmt.Printf("%s を追加しました\n", username)
+}
+
+// removeStreamer は配信者を削除する。
+func removeStreamer(username string) {
+	updateConfig(func(cfg *config.Config) error {
+		index := findStreamerIndex(cfg.Streamers, username)
+		if index == -1 {
+			return fmt.Errorf("%s は登録されていません", username)
+		}
+		cfg.Streamers = append(cfg.Streamers[:index], cfg.Streamers[index+1:]...)
+		return nil
+	})
+	fmt.Printf("%s を削除しました\n", username)
+}
+
+// listStreamers は登録済み配信者一覧を表示する。
+func listStreamers() {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(cfg.Streamers) == 0 {
+		fmt.Println("登録されている配信者はいません")
+		return
+	}
+
+	stats := loadLifetimeStats(cfg)
+	fmt.Println("登録済み配信者:")
+	for _, s := range cfg.Streamers {
+		var tags []string
+		for _, w := range s.Webhooks {
+			for _, t := range w.Tags {
+				if !slices.Contains(tags, t) {
+					tags = append(tags, t)
+				}
+			}
+		}
+		fmt.Printf("  - %s (Webhook: %d件)%s%s\n", s.Username, len(s.Webhooks), formatTags(tags), formatLifetimeSummary(stats[strings.ToLower(s.Username)]))
+	}
+}
+
+// loadLifetimeStats は監視プロセスが保存した累計の配信記録を読み込み、小文字のユーザー名をキーにして返す。
+// 記録がない、または読み込めない場合は空のmapを返す。
+func loadLifetimeStats(cfg *config.Config) map[string]monitor.LifetimeStats {
+	stats := make(map[string]monitor.LifetimeStats)
+	list, err := monitor.LoadLifetimeStats(cfg.LifetimeStatsPath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintf(os.Stderr, "警告: %v\n", err)
+	}
+	for _, s := range list {
+		stats[strings.ToLower(s.Username)] = s
+	}
+	return stats
+}
+
+// formatLifetimeSummary はlistコマンドに表示する累計の配信記録の要約を返す。配信の記録がなければ空文字。
+func formatLifetimeSummary(s monitor.LifetimeStats) string {
+	if s.Streams == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" [配信%d回 / 累計%s / 最終配信 %s]", s.Streams, formatLiveHours(s.LiveDuration()), s.LastLiveAt.Local().Format(time.DateOnly))
+}
+
+// formatLiveHours は累計の配信時間を時間単位(小数第1位まで)で表示用に整形する。
+func formatLiveHours(d time.Duration) string {
+	return fmt.Sprintf("%.1f時間", d.Hours())
+}
+
+// resetLifetimeStats は累計の配信記録を消去する。usernameが空なら全配信者の記録を消去する。
+func resetLifetimeStats(username string) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+	removed, err := monitor.ResetLifetimeStats(cfg.LifetimeStatsPath(), username)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+	if removed == 0 {
+		fmt.Println("消去する記録はありません")
+		return
+	}
+	fmt.Printf("%d人分の累計の配信記録を消去しました (監視中の場合は停止してから実行してください。実行中のプロセスが記録を書き戻します)\n", removed)
+}
+
+// matrixRow は通知マトリクスの1行。各通知タイプが有効なWebhookの数を持つ。
+type matrixRow struct {
+	Username    string `json:"username"`
+	Webhooks    int    `json:"webhooks"`
+	Online      int    `json:"online"`
+	Offline     int    `json:"offline"`
+	TitleChange int    `json:"titleChange"`
+	GameChange  int    `json:"gameChange"`
+}
+
+// buildMatrix は配信者ごとに各通知タイプが有効なWebhookの数を集計する。
+func buildMatrix(streamers []config.StreamerConfig) []matrixRow {
+	rows := make([]matrixRow, 0, len(streamers))
+	for _, s := range streamers {
+		row := matrixRow{Username: s.Username, Webhooks: len(s.Webhooks)}
+		for _, w := range s.Webhooks {
+			n := w.Notifications
+			row.Online += boolCount(n.Online)
+			row.Offline += boolCount(n.Offline)
+			row.TitleChange += boolCount(n.TitleChange)
+			row.GameChange += boolCount(n.GameChange)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// boolCount はtrueなら1を返す。
+func boolCount(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// showMatrix は配信者×通知タイプごとに有効なWebhookの数を表で表示する。jsonOutputならJSONで出力する。
+// 有効なWebhookが1つもない通知タイプは「-」で表示し、設定漏れを見つけやすくする。
+func showMatrix(jsonOutput bool) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+	cfg = cfg.WithRoutes()
+
+	rows := buildMatrix(cfg.Streamers)
+	if jsonOutput {
+		data, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if len(rows) == 0 {
+		fmt.Println("登録されている配信者はいません")
+		return
+	}
+
+	width := len("streamer")
+	for _, r := range rows {
+		width = max(width, len(r.Username))
+	}
+	cell := func(n int) string {
+		if n == 0 {
+			return "-"
+		}
+		return strconv.Itoa(n)
+	}
+	fmt.Printf("%-*s  %8s  %7s  %7s  %5s  %4s\n", width, "streamer", "webhooks", "online", "offline", "title", "game")
+	for _, r := range rows {
+		fmt.Printf("%-*s  %8d  %7s  %7s  %5s  %4s\n", width, r.Username, r.Webhooks,
+			cell(r.Online), cell(r.Offline), cell(r.TitleChange), cell(r.GameChange))
+	}
+}
+
+// checkStreamer は1人の配信者について1回分のポーリングを行い、検出される変更を表示する。
+// 前回状態なし(初回ポーリング)と、prevPathで指定したJSONの前回状態とのそれぞれで比較する。
+// sendがtrueなら検出した変更を設定どおりにWebhookへ送信する。通知されない原因の調査用。
+func checkStreamer(username, prevPath string, send bool) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+	cfg = cfg.WithRoutes()
+	httpclient.SetUserAgent(cfg.Network.UserAgent)
+	if err := httpclient.ConfigureTLS(cfg.Network.CAFile, cfg.Network.InsecureSkipVerify); err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+
+	username, err = config.NormalizeUsername(username)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+	streamer := findStreamer(cfg.Streamers, username)
+	if streamer == nil {
+		if send {
+			fmt.Fprintf(os.Stderr, "エラー: %s は登録されていないため送信できません\n", username)
+			os.Exit(1)
+		}
+		fmt.Printf("注意: %s は登録されていません。検出結果のみ表示します\n", username)
+	}
+
+	var prev *monitor.StreamerState
+	if prevPath != "" {
+		data, err := os.ReadFile(prevPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "エラー: 前回状態の読み込みに失敗: %v\n", err)
+			os.Exit(1)
+		}
+		prev = &monitor.StreamerState{}
+		if err := json.Unmarshal(data, prev); err != nil {
+			fmt.Fprintf(os.Stderr, "エラー: 前回状態の解析に失敗: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	api := monitor.NewTwitchAPI(cfg.Twitch)
+
+	users, err := api.GetUsers(ctx, []string{username})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: ユーザー情報の取得に失敗: %v\n", err)
+		os.Exit(1)
+	}
+	user, ok := users[username]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "エラー: Twitchでユーザーが見つかりません: %s\n", username)
+		os.Exit(1)
+	}
+	streams, err := api.GetStreams(ctx, []string{username})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: 配信情報の取得に失敗: %v\n", err)
+		os.Exit(1)
+	}
+	var stream *twitch.Stream
+	var channel *twitch.Channel
+	if s, ok := streams[username]; ok {
+		stream = &s
+	} else {
+		// ポーリングと同様に、オフライン時のタイトル・ゲームは/channelsから取得する
+		channels, err := api.GetChannels(ctx, []string{user.ID})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "警告: チャンネル情報の取得に失敗: %v\n", err)
+		} else if ch, ok := channels[username]; ok {
+			channel = &ch
+		}
+	}
+
+	state := monitor.BuildStreamerState(user, stream, channel)
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("現在の状態:\n%s\n", data)
+
+	detectors := monitor.DetectorsFor(cfg)
+	// 前回状態がない初回ポーリングでは、Pollerは配信中なら配信開始として通知する
+	changes := monitor.DetectChanges(nil, state, detectors...)
+	if state.IsLive {
+		changes = append(changes, monitor.DetectedChange{Type: config.ChangeOnline, Streamer: state.Username, CurrentState: state})
+	}
+	fmt.Println("\n前回状態なし(初回ポーリング)との比較:")
+	printChanges(changes)
+
+	if prev != nil {
+		changes = monitor.DetectChanges(prev, state, detectors...)
+		fmt.Printf("\n前回状態 (%s) との比較:\n", prevPath)
+		printChanges(changes)
+	}
+
+	if !send || len(changes) == 0 {
+		return
+	}
+	for i := range changes {
+		changes[i].Platform = config.PlatformTwitch
+	}
+	fmt.Println("\n検出した変更を送信します")
+	notifier.NewDispatcher(cfg, nil).Dispatch(ctx, changes, *streamer)
+}
+
+// printChanges は検出された変更を1行ずつ表示する。
+func printChanges(changes []monitor.DetectedChange) {
+	if len(changes) == 0 {
+		fmt.Println("  変更なし")
+		return
+	}
+	for _, c := range changes {
+		line := "  " + c.Type
+		if c.OldValue != "" || c.NewValue != "" {
+			line += fmt.Sprintf(": %q → %q", c.OldValue, c.NewValue)
+		}
+		fmt.Println(line)
+	}
+}
+
+// showStreamerInfo は配信者の設定と、監視プロセスが記録したポーリングのヘルス状態を表示する。
+func showStreamerInfo(username string) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+
+	streamer := findStreamer(cfg.Streamers, username)
+	if streamer == nil {
+		fmt.Fprintf(os.Stderr, "エラー: %s は登録されていません\n", username)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s:\n", streamer.Username)
+	fmt.Printf("  Webhook: %d件\n", len(streamer.Webhooks))
+	for i, w := range streamer.Webhooks {
+		fmt.Printf("    %d. %s%s\n", i+1, webhookLabel(w), formatTags(w.Tags))
+	}
+	if s, ok := loadLifetimeStats(cfg)[strings.ToLower(streamer.Username)]; ok && s.Streams > 0 {
+		fmt.Printf("  累計: 配信%d回 / %s\n", s.Streams, formatLiveHours(s.LiveDuration()))
+		fmt.Printf("  最終配信: %s\n", formatTime(s.LastLiveAt))
+	}
+
+	list, err := monitor.LoadHealth(cfg.HealthPath())
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Println("  ヘルス状態: 記録なし (監視を開始すると記録されます)")
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+	i := slices.IndexFunc(list, func(h monitor.StreamerHealth) bool {
+		return strings.EqualFold(h.Username, username)
+	})
+	if i < 0 {
+		fmt.Println("  ヘルス状態: 記録なし (まだポーリングされていません)")
+		return
+	}
+
+	h := list[i]
+	status := "正常"
+	if !h.Healthy() {
+		status = fmt.Sprintf("エラー (%s以降%d回連続)", h.FailingSince.Local().Format(time.DateTime), h.ConsecutiveErrors)
+	}
+	fmt.Printf("  ヘルス状態: %s\n", status)
+	fmt.Printf("  最終成功ポーリング: %s\n", formatTime(h.LastSuccessAt))
+	if h.LastError != "" {
+		fmt.Printf("  最終エラー: %s (%s)\n", h.LastError, formatTime(h.LastErrorAt))
+	}
+}
+
+// formatTime は時刻を経過時間付きで表示用に整形する。ゼロ値は「なし」とする。
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "なし"
+	}
+	return fmt.Sprintf("%s, %s前", t.Local().Format(time.DateTime), time.Since(t).Round(time.Second))
+}
+
+// addWebhook は配信者にWebhookを追加する。
+func addWebhook(username string) {
+	username, err := config.NormalizeUsername(username)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+
+	streamer := findStreamer(cfg.Streamers, username)
+	if streamer == nil {
+		fmt.Fprintf(os.Stderr, "エラー: %s は登録されていません\n", username)
+		os.Exit(1)
+	}
+
+	webhookName := promptInput("Webhook名 (任意): ")
+	webhookURL := promptInput("Webhook URL: ")
+	if !validateWebhookURL(webhookURL) {
+		fmt.Fprintln(os.Stderr, "エラー: 無効なWebhook URLです")
+		os.Exit(1)
+	}
+
+	if hasWebhookURL(streamer.Webhooks, webhookURL) {
+		fmt.Fprintln(os.Stderr, "エラー: このWebhookは既に登録されています")
+		os.Exit(1)
+	}
+
+	tags := config.ParseTags(promptInput("タグ (カンマ区切り, 任意): "))
+
+	var total int
+	updateConfig(func(cfg *config.Config) error {
+		streamer := findStreamer(cfg.Streamers, username)
+		if streamer == nil {
+			return fmt.Errorf("%s は登録されていません", username)
+		}
+		if hasWebhookURL(streamer.Webhooks, webhookURL) {
+			return fmt.Errorf("このWebhookは既に登録されています")
+		}
+		streamer.Webhooks = append(streamer.Webhooks, config.WebhookConfig{
+			Name:          webhookName,
+			URL:           webhookURL,
+			Notifications: cfg.DefaultNotifications(),
+			Tags:          tags,
+		})
+		total = len(streamer.Webhooks)
+		return nil
+	})
+	fmt.Printf("%s にWebhookを追加しました (合計: %d件)\n", username, total)
+}
+
+// removeWebhook は配信者からWebhookを削除する。
+func removeWebhook(username string) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+
+	streamer := findStreamer(cfg.Streamers, username)
+	if streamer == nil {
+		fmt.Fprintf(os.Stderr, "エラー: %s は登録されていません\n", username)
+		os.Exit(1)
+	}
+
+	if len(streamer.Webhooks) == 0 {
+		fmt.Fprintln(os.Stderr, "エラー: Webhookが登録されていません")
+		os.Exit(1)
+	}
+
+	index := selectWebhook(streamer.Webhooks, "削除する番号: ")
+	selected := streamer.Webhooks[index]
+
+	var remaining int
+	updateConfig(func(cfg *config.Config) error {
+		streamer, i, err := lockedWebhook(cfg, username, selected)
+		if err != nil {
+			return err
+		}
+		streamer.Webhooks = append(streamer.Webhooks[:i], streamer.Webhooks[i+1:]...)
+		remaining = len(streamer.Webhooks)
+		return nil
+	})
+	fmt.Printf("Webhookを削除しました (残り: %d件)\n", remaining)
+}
+
+// addGroupURL はWebhookに送信先URLを追加してWebhookグループにする。
+func addGroupURL(username string) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+
+	streamer := findStreamer(cfg.Streamers, username)
+	if streamer == nil {
+		fmt.Fprintf(os.Stderr, "エラー: %s は登録されていません\n", username)
+		os.Exit(1)
+	}
+
+	if len(streamer.Webhooks) == 0 {
+		fmt.Fprintln(os.Stderr, "エラー: Webhookが登録されていません")
+		os.Exit(1)
+	}
+
+	index := selectWebhook(streamer.Webhooks, "URLを追加する番号: ")
+	w := &streamer.Webhooks[index]
+
+	webhookURL := promptInput("追加するWebhook URL: ")
+	if w.Type != config.WebhookGeneric && !validateWebhookURL(webhookURL) {
+		fmt.Fprintln(os.Stderr, "エラー: 無効なWebhook URLです")
+		os.Exit(1)
+	}
+	if hasWebhookURL(streamer.Webhooks, webhookURL) {
+		fmt.Fprintln(os.Stderr, "エラー: このWebhookは既に登録されています")
+		os.Exit(1)
+	}
+
+	selected := *w
+	var total int
+	updateConfig(func(cfg *config.Config) error {
+		streamer, i, err := lockedWebhook(cfg, username, selected)
+		if err != nil {
+			return err
+		}
+		if hasWebhookURL(streamer.Webhooks, webhookURL) {
+			return fmt.Errorf("このWebhookは既に登録されています")
+		}
+		w := &streamer.Webhooks[i]
+		w.URLs = append(w.URLs, webhookURL)
+		total = len(w.Targets())
+		return nil
+	})
+	fmt.Printf("Webhook %d にURLを追加しました (送信先: %d件)\n", index+1, total)
+}
+
+// removeGroupURL はWebhookグループから送信先URLを削除する。
+func removeGroupURL(username string) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+
+	streamer := findStreamer(cfg.Streamers, username)
+	if streamer == nil {
+		fmt.Fprintf(os.Stderr, "エラー: %s は登録されていません\n", username)
+		os.Exit(1)
+	}
+
+	if len(streamer.Webhooks) == 0 {
+		fmt.Fprintln(os.Stderr, "エラー: Webhookが登録されていません")
+		os.Exit(1)
+	}
+
+	index := selectWebhook(streamer.Webhooks, "URLを削除する番号: ")
+	w := &streamer.Webhooks[index]
+
+	targets := w.Targets()
+	if len(targets) < 2 {
+		fmt.Fprintln(os.Stderr, "エラー: 送信先が1件のみのため削除できません (Webhookごと削除してください)")
+		os.Exit(1)
+	}
+
+	fmt.Println("送信先URL:")
+	for i, u := range targets {
+		fmt.Printf("  %d. %s\n", i+1, truncateURL(u, 80))
+	}
+	input := promptInput("削除するURLの番号: ")
+	urlIndex, err := strconv.Atoi(input)
+	if err != nil || urlIndex < 1 || urlIndex > len(targets) {
+		fmt.Fprintln(os.Stderr, "エラー: 無効な番号です")
+		os.Exit(1)
+	}
+
+	selected := *w
+	removeURL := targets[urlIndex-1]
+	var remaining []string
+	updateConfig(func(cfg *config.Config) error {
+		streamer, i, err := lockedWebhook(cfg, username, selected)
+		if err != nil {
+			return err
+		}
+		w := &streamer.Webhooks[i]
+		remaining = slices.DeleteFunc(w.Targets(), func(u string) bool { return u == removeURL })
+		w.URL = remaining[0]
+		w.URLs = remaining[1:]
+		if len(w.URLs) == 0 {
+			w.URLs = nil
+		}
+		return nil
+	})
+	fmt.Printf("Webhook %d からURLを削除しました (送信先: %d件)\n", index+1, len(remaining))
+}
+
+// configureWebhook は配信者のWebhook通知設定を変更する。
+func configureWebhook(username string) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+
+	streamer := findStreamer(cfg.Streamers, username)
+	if streamer == nil {
+		fmt.Fprintf(os.Stderr, "エラー: %s は登録されていません\n", username)
+		os.Exit(1)
+	}
+
+	if len(streamer.Webhooks) == 0 {
+		fmt.Fprintln(os.Stderr, "エラー: Webhookが登録されていません")
+		os.Exit(1)
+	}
+
+	index := selectWebhook(streamer.Webhooks, "\n設定する番号: ")
+
+	w := &streamer.Webhooks[index]
+	fmt.Println("\n通知設定 (y/n):")
+
+	onlineInput := promptInput(fmt.Sprintf("  online [%s]: ", boolToYN(w.Notifications.Online)))
+	offlineInput := promptInput(fmt.Sprintf("  offline [%s]: ", boolToYN(w.Notifications.Offline)))
+	titleInput := promptInput(fmt.Sprintf("  titleChange [%s]: ", boolToYN(w.Notifications.TitleChange)))
+	gameInput := promptInput(fmt.Sprintf("  gameChange [%s]: ", boolToYN(w.Notifications.GameChange)))
+	scheduleInput := promptInput(fmt.Sprintf("  schedule (配信予定リマインダー) [%s]: ", boolToYN(w.Notifications.Schedule)))
+	profileInput := promptInput(fmt.Sprintf("  profileUpdate (表示名・アイコン変更) [%s]: ", boolToYN(w.Notifications.ProfileUpdate)))
+	vodInput := promptInput(fmt.Sprintf("  vodPublished (アーカイブ公開) [%s]: ", boolToYN(w.Notifications.VodPublished)))
+
+	notifications := config.NotificationSettings{
+		Online:        parseYesNo(onlineInput, w.Notifications.Online),
+		Offline:       parseYesNo(offlineInput, w.Notifications.Offline),
+		TitleChange:   parseYesNo(titleInput, w.Notifications.TitleChange),
+		GameChange:    parseYesNo(gameInput, w.Notifications.GameChange),
+		Schedule:      parseYesNo(scheduleInput, w.Notifications.Schedule),
+		ProfileUpdate: parseYesNo(profileInput, w.Notifications.ProfileUpdate),
+		VodPublished:  parseYesNo(vodInput, w.Notifications.VodPublished),
+	}
+
+	selected := *w
+	updateConfig(func(cfg *config.Config) error {
+		streamer, i, err := lockedWebhook(cfg, username, selected)
+		if err != nil {
+			return err
+		}
+		streamer.Webhooks[i].Notifications = notifications
+		return nil
+	})
+	fmt.Printf("\nWebhook %d の設定を更新しました\n", index+1)
+}
+
+// testWebhook は配信者のWebhookにテスト通知を送信する。tagsが空でなければタグで絞り込む。
+func testWebhook(username string, tags []string) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+	httpclient.SetUserAgent(cfg.Network.UserAgent)
+	if err := httpclient.ConfigureTLS(cfg.Network.CAFile, cfg.Network.InsecureSkipVerify); err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
 	}
-	if n.GameChange {
-		types = append(types, "game")
+
+	streamer := findStreamer(cfg.Streamers, username)
+	if streamer == nil {
+		fmt.Fprintf(os.Stderr, "エラー: %s は登録されていません\n", username)
+		os.Exit(1)
 	}
-	if len(types) == 4 {
-		return "全通知"
+
+	change := monitor.DetectedChange{
+		Type:     config.ChangeOnline,
+		Streamer: streamer.Username,
+		Platform: config.PlatformTwitch,
+		CurrentState: monitor.StreamerState{
+			Username:    streamer.Username,
+			DisplayName: streamer.Username,
+			IsLive:      true,
+			Title:       "テスト通知 (Stream Notifier)",
+			// 冪等キーに含まれるため、続けてテストしても重複として破棄されないよう実行ごとに変える
+			StartedAt: time.Now().UTC().Format(time.RFC3339),
+		},
 	}
-	return strings.Join(types, ", ")
-}
+	embedOpts := discord.NewEmbedOptions(cfg)
 
-// findStreamer はユーザー名で配信者を検索する。
-func findStreamer(streamers []config.StreamerConfig, username string) *config.StreamerConfig {
-	lower := strings.ToLower(username)
-	for i := range streamers {
-		if strings.ToLower(streamers[i].Username) == lower {
-			return &streamers[i]
+	sent, failed := 0, 0
+	for i, w := range streamer.Webhooks {
+		if !w.HasAnyTag(tags) {
+			continue
 		}
-	}
-	return nil
-}
+		label := webhookLabel(w)
 
-// findStreamerIndex はユーザー名で配信者のインデックスを検索する。
-func findStreamerIndex(streamers []config.StreamerConfig, username string) int {
-	lower := strings.ToLower(username)
-	for i := range streamers {
-		if strings.ToLower(streamers[i].Username) == lower {
-			return i
+		opts := embedOpts
+		opts.Language = w.Language
+		if color, ok := streamer.EmbedColor(w); ok {
+			opts.Color = &color
+		}
+		n, err := notifier.New(w, opts, nil)
+		if err == nil {
+			err = n.Notify(context.Background(), change)
 		}
+		if err != nil {
+			fmt.Printf("  %d. %s: 失敗 (%v)\n", i+1, label, err)
+			failed++
+			continue
+		}
+		fmt.Printf("  %d. %s: 成功\n", i+1, label)
+		sent++
 	}
-	return -1
-}
 
-// defaultNotifications は全通知有効のデフォルト設定を返す。
-func defaultNotifications() config.NotificationSettings {
-	return config.NotificationSettings{
-		Online:      true,
-		Offline:     true,
-		TitleChange: true,
-		GameChange:  true,
+	if sent+failed == 0 {
+		fmt.Println("対象のWebhookがありません")
+		return
+	}
+	fmt.Printf("テスト通知を送信しました (成功: %d件, 失敗: %d件)\n", sent, failed)
+	if failed > 0 {
+		os.Exit(1)
 	}
 }
 
-// addStreamer は配信者を追加する。
-func addStreamer(username string) {
+// previewEmbed は通知タイプの架空の変更からEmbedを構築してJSONで表示する。webhookURLが空でなければ送信もする。
+// 本番と同じBuildEmbedを使うため、表示設定やテンプレートの調整結果をそのまま確認できる。languageが空なら日本語で表示する。
+func previewEmbed(changeType config.ChangeType, username, webhookURL string, language config.Language) {
 	cfg, err := config.Load(configPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
 		os.Exit(1)
 	}
-
-	if findStreamer(cfg.Streamers, username) != nil {
-		fmt.Fprintf(os.Stderr, "エラー: %s は既に登録されています\n", username)
+	httpclient.SetUserAgent(cfg.Network.UserAgent)
+	if err := httpclient.ConfigureTLS(cfg.Network.CAFile, cfg.Network.InsecureSkipVerify); err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
 		os.Exit(1)
 	}
-
-	webhookName := promptInput("Webhook名 (任意): ")
-	webhookURL := promptInput("Webhook URL: ")
-	if !validateWebhookURL(webhookURL) {
+	if !slices.Contains(previewTypes, changeType) {
+		fmt.Fprintf(os.Stderr, "エラー: 不明な通知タイプです: %s (%s)\n", changeType, strings.Join(previewTypes, "/"))
+		os.Exit(1)
+	}
+	if webhookURL != "" && !validateWebhookURL(webhookURL) {
 		fmt.Fprintln(os.Stderr, "エラー: 無効なWebhook URLです")
 		os.Exit(1)
 	}
+	switch language {
+	case "", config.LanguageJa, config.LanguageEn:
+	default:
+		fmt.Fprintf(os.Stderr, "エラー: 不明な言語です: %s (ja/en)\n", language)
+		os.Exit(1)
+	}
 
-	newStreamer := config.StreamerConfig{
-		Username: username,
-		Webhooks: []config.WebhookConfig{
-			{
-				Name:          webhookName,
-				URL:           webhookURL,
-				Notifications: defaultNotifications(),
-			},
-		},
+	state := previewState(cfg, username)
+	change := previewChange(changeType, state, time.Now())
+
+	opts := discord.NewEmbedOptions(cfg)
+	opts.Language = language
+	if streamer := findStreamer(cfg.Streamers, username); streamer != nil {
+		if color, ok := streamer.EmbedColor(config.WebhookConfig{}); ok {
+			opts.Color = &color
+		}
 	}
+	embed := discord.BuildEmbed(change, opts)
 
-	cfg.Streamers = append(cfg.Streamers, newStreamer)
-	if err := config.Save(configPath, cfg); err != nil {
+	var content string
+	if opts.IncludeContent {
+		content = discord.BuildContent(change, opts)
+		fmt.Printf("本文: %s\n", content)
+	}
+	data, err := json.MarshalIndent(embed, "", "  ")
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
 		os.Exit(1)
 	}
-	fmt.Printf("%s を追加しました\n", username)
-}
+	fmt.Println(string(data))
 
-// removeStreamer は配信者を削除する。
-func removeStreamer(username string) {
-	cfg, err := config.Load(configPath)
-	if err != nil {
+	if webhookURL == "" {
+		return
+	}
+	info := discord.StreamerInfo{DisplayName: state.DisplayName, ProfileImageURL: state.ProfileImageURL}
+	info.Content = content
+	if err := discord.SendWebhook(context.Background(), webhookURL, embed, info, ""); err != nil {
 		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
 		os.Exit(1)
 	}
+	fmt.Println("プレビューを送信しました")
+}
 
-	index := findStreamerIndex(cfg.Streamers, username)
-	if index == -1 {
-		fmt.Fprintf(os.Stderr, "エラー: %s は登録されていません\n", username)
-		os.Exit(1)
+// previewTypes はpreviewで指定できる通知タイプ。
+var previewTypes = []string{
+	config.ChangeOnline, config.ChangeOffline, config.ChangeTitleChange, config.ChangeGameChange,
+	config.ChangeTitleAndGame, config.ChangeScheduledReminder, config.ChangeProfileUpdate, config.ChangeReconnect,
+	config.ChangeStillLive, config.ChangeVodPublished,
+}
+
+// previewState はプレビュー用の配信者状態を返す。Twitchからユーザー情報を取得できなければユーザー名だけで作る。
+func previewState(cfg *config.Config, username string) monitor.StreamerState {
+	state := monitor.StreamerState{
+		Username:    strings.ToLower(username),
+		DisplayName: username,
+		IsLive:      true,
+		Title:       "プレビュー用の配信タイトル",
+		GameName:    "Just Chatting",
+		ViewerCount: 123,
+		Language:    "ja",
 	}
+	state.ThumbnailURL = fmt.Sprintf("https://static-cdn.jtvnw.net/previews-ttv/live_user_%s-{width}x{height}.jpg", state.Username)
 
-	cfg.Streamers = append(cfg.Streamers[:index], cfg.Streamers[index+1:]...)
-	if err := config.Save(configPath, cfg); err != nil {
-		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
-		os.Exit(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	api := monitor.NewTwitchAPI(cfg.Twitch)
+	users, err := api.GetUsers(ctx, []string{username})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "警告: ユーザー情報を取得できないためユーザー名で表示します (%v)\n", err)
+		return state
 	}
-	fmt.Printf("%s を削除しました\n", username)
+	if user, ok := users[strings.ToLower(username)]; ok {
+		state.UserID = user.ID
+		state.Username = user.Login
+		state.DisplayName = user.DisplayName
+		state.ProfileImageURL = user.ProfileImageURL
+	} else {
+		fmt.Fprintf(os.Stderr, "警告: %s がTwitchで見つからないためユーザー名で表示します\n", username)
+	}
+	return state
 }
 
-// listStreamers は登録済み配信者一覧を表示する。
-func listStreamers() {
+// previewStreamID はプレビューで使う架空の配信ID。
+const previewStreamID = "40000000001"
+
+// previewChange は通知タイプに応じた架空の変更を作る。changeTypeはpreviewTypesのいずれか。
+func previewChange(changeType config.ChangeType, state monitor.StreamerState, now time.Time) monitor.DetectedChange {
+	startedAt := now.Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	state.StartedAt = startedAt
+	state.StreamID = previewStreamID
+	change := monitor.DetectedChange{
+		Type:     changeType,
+		Platform: config.PlatformTwitch,
+		Streamer: state.Username,
+	}
+	switch changeType {
+	case config.ChangeOnline, config.ChangeOffline, config.ChangeReconnect, config.ChangeStillLive, config.ChangeVodPublished:
+		change.StreamID = previewStreamID
+	}
+
+	switch changeType {
+	case config.ChangeOnline:
+		// 共通の配信中の状態をそのまま使う。フォロワー数はonlineFieldsで表示する場合の確認用
+		followers := 4567
+		change.FollowerCount = &followers
+	case config.ChangeOffline:
+		change.StreamStartedAt = startedAt
+		state.IsLive = false
+		state.StartedAt = ""
+		state.ThumbnailURL = ""
+		state.ViewerCount = 0
+		state.StreamID = ""
+	case config.ChangeTitleChange:
+		change.OldValue = "変更前の配信タイトル"
+		change.NewValue = state.Title
+	case config.ChangeGameChange:
+		change.OldValue = "Minecraft"
+		change.NewValue = state.GameName
+	case config.ChangeTitleAndGame:
+		change.OldTitle = "変更前の配信タイトル"
+		change.NewTitle = state.Title
+		change.OldGame = "Minecraft"
+		change.NewGame = state.GameName
+	case config.ChangeScheduledReminder:
+		change.ScheduledStartAt = now.Add(15 * time.Minute).UTC().Format(time.RFC3339)
+		state.IsLive = false
+		state.StartedAt = ""
+		state.ThumbnailURL = ""
+	case config.ChangeProfileUpdate:
+		change.OldValue = state.DisplayName + "_old"
+		change.NewValue = state.DisplayName
+		change.OldProfileImageURL = state.ProfileImageURL
+	case config.ChangeReconnect:
+		change.StreamStartedAt = startedAt
+		change.OfflineDuration = 45 * time.Second
+	case config.ChangeStillLive:
+		change.StreamStartedAt = startedAt
+	case config.ChangeVodPublished:
+		change.StreamStartedAt = startedAt
+		change.NewValue = "2000000001"
+		change.NewTitle = state.Title
+		change.VodURL = "https://www.twitch.tv/videos/2000000001"
+		change.VodDuration = 2*time.Hour + 15*time.Minute
+		state.IsLive = false
+		state.StartedAt = ""
+		state.ThumbnailURL = ""
+		state.ViewerCount = 0
+		state.StreamID = ""
+	}
+	change.CurrentState = state
+	return change
+}
+
+// validateConfig は設定を検証し、全Discord Webhookの疎通を並列で確認する。
+func validateConfig(concurrency int, timeout time.Duration) {
 	cfg, err := config.Load(configPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
 		os.Exit(1)
 	}
+	httpclient.SetUserAgent(cfg.Network.UserAgent)
+	if err := httpclient.ConfigureTLS(cfg.Network.CAFile, cfg.Network.InsecureSkipVerify); err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("設定ファイル: OK")
+	for _, w := range cfg.Warnings() {
+		fmt.Printf("  警告: %s\n", w)
+	}
 
-	if len(cfg.Streamers) == 0 {
-		fmt.Println("登録されている配信者はいません")
+	type target struct {
+		streamer string
+		label    string
+	}
+	var targets []target
+	var urls []string
+	for _, s := range cfg.Streamers {
+		for _, w := range s.Webhooks {
+			if w.Type == config.WebhookGeneric {
+				continue
+			}
+			for _, u := range w.Targets() {
+				label := webhookLabel(w)
+				if w.IsGroup() {
+					label += " (" + truncateURL(u, 50) + ")"
+				}
+				targets = append(targets, target{streamer: s.Username, label: label})
+				urls = append(urls, u)
+			}
+		}
+	}
+
+	if len(urls) == 0 {
+		fmt.Println("確認対象のDiscord Webhookがありません")
 		return
 	}
 
-	fmt.Println("登録済み配信者:")
-	for _, s := range cfg.Streamers {
-		fmt.Printf("  - %s (Webhook: %d件)\n", s.Username, len(s.Webhooks))
+	fmt.Printf("\nWebhook疎通確認 (%d件, 並列数: %d):\n", len(urls), concurrency)
+	results := discord.VerifyWebhooks(context.Background(), urls, concurrency, timeout)
+
+	invalid := 0
+	for i, r := range results {
+		t := targets[i]
+		if !r.Valid() {
+			invalid++
+			fmt.Printf("  NG  %s / %s: %v\n", t.streamer, t.label, r.Err)
+			continue
+		}
+		rateLimit := "不明"
+		if r.RateLimitRemaining >= 0 {
+			rateLimit = fmt.Sprintf("残り%d", r.RateLimitRemaining)
+		}
+		fmt.Printf("  OK  %s / %s: Bot名=%s チャンネルID=%s レート制限=%s\n",
+			t.streamer, t.label, r.Info.Name, r.Info.ChannelID, rateLimit)
+	}
+
+	fmt.Printf("\n有効: %d件, 無効: %d件\n", len(results)-invalid, invalid)
+	if invalid > 0 {
+		os.Exit(1)
 	}
 }
 
-// addWebhook は配信者にWebhookを追加する。
-func addWebhook(username string) {
-	cfg, err := config.Load(configPath)
+// readImportFile はインポート用JSONを読み込む。config.jsonと同じ {"streamers": [...]} 形式か、配信者設定の配列を受け付ける。
+func readImportFile(path string) ([]config.StreamerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("インポートファイルの読み込みに失敗: %w", err)
+	}
+
+	var list []config.StreamerConfig
+	if err := json.Unmarshal(data, &list); err == nil {
+		return list, nil
+	}
+	var wrapped struct {
+		Streamers []config.StreamerConfig `json:"streamers"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err != nil {
+		return nil, fmt.Errorf("インポートファイルのJSON解析に失敗: %w", err)
+	}
+	return wrapped.Streamers, nil
+}
+
+// importStreamers はファイルから配信者設定を一括追加する。1件でも失敗した場合は何も追加しない。
+func importStreamers(path string, skipExisting bool) {
+	streamers, err := readImportFile(path)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
 		os.Exit(1)
 	}
-
-	streamer := findStreamer(cfg.Streamers, username)
-	if streamer == nil {
-		fmt.Fprintf(os.Stderr, "エラー: %s は登録されていません\n", username)
+	if len(streamers) == 0 {
+		fmt.Fprintln(os.Stderr, "エラー: インポートする配信者がありません")
 		os.Exit(1)
 	}
 
-	webhookName := promptInput("Webhook名 (任意): ")
-	webhookURL := promptInput("Webhook URL: ")
-	if !validateWebhookURL(webhookURL) {
-		fmt.Fprintln(os.Stderr, "エラー: 無効なWebhook URLです")
+	added, skipped := 0, 0
+	ops := make([]config.Op, len(streamers))
+	for i, s := range streamers {
+		ops[i] = func(cfg *config.Config) error {
+			username, err := config.NormalizeUsername(s.Username)
+			if err != nil {
+				return fmt.Errorf("%d件目: %w", i+1, err)
+			}
+			s.Username = username
+			if findStreamer(cfg.Streamers, s.Username) != nil {
+				if skipExisting {
+					skipped++
+					return nil
+				}
+				return fmt.Errorf("%s は既に登録されています (--skip-existing でスキップできます)", s.Username)
+			}
+			cfg.Streamers = append(cfg.Streamers, s)
+			added++
+			return nil
+		}
+	}
+
+	if err := config.Apply(configPath, ops...); err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
 		os.Exit(1)
 	}
+	fmt.Printf("%d人をインポートしました", added)
+	if skipped > 0 {
+		fmt.Printf(" (登録済みのため%d人をスキップ)", skipped)
+	}
+	fmt.Println()
+}
 
-	for _, w := range streamer.Webhooks {
-		if w.URL == webhookURL {
-			fmt.Fprintln(os.Stderr, "エラー: このWebhookは既に登録されています")
-			os.Exit(1)
+// copySettings はコピー元の配信者のWebhook設定を他の配信者に上書きコピーする。1人でも失敗した場合は何も変更しない。
+func copySettings(from string, targets []string) {
+	ops := make([]config.Op, len(targets))
+	for i, to := range targets {
+		ops[i] = func(cfg *config.Config) error {
+			src := findStreamer(cfg.Streamers, from)
+			if src == nil {
+				return fmt.Errorf("%s は登録されていません", from)
+			}
+			dst := findStreamer(cfg.Streamers, to)
+			if dst == nil {
+				return fmt.Errorf("%s は登録されていません", to)
+			}
+			dst.Webhooks = cloneWebhooks(src.Webhooks)
+			dst.Color = src.Color
+			return nil
 		}
 	}
 
-	streamer.Webhooks = append(streamer.Webhooks, config.WebhookConfig{
-		Name:          webhookName,
-		URL:           webhookURL,
-		Notifications: defaultNotifications(),
-	})
-
-	if err := config.Save(configPath, cfg); err != nil {
+	if err := config.Apply(configPath, ops...); err != nil {
 		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
 		os.Exit(1)
 	}
-	fmt.Printf("%s にWebhookを追加しました (合計: %d件)\n", username, len(streamer.Webhooks))
+	fmt.Printf("%s の設定を%d人にコピーしました\n", from, len(targets))
 }
 
-// removeWebhook は配信者からWebhookを削除する。
-func removeWebhook(username string) {
+// replayDeadLetters はデッドレターファイルの通知を再送する。送信できた通知はファイルから削除し、
+// 再び失敗した通知は最終エラーを更新して残す。
+func replayDeadLetters(path string) {
 	cfg, err := config.Load(configPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
 		os.Exit(1)
 	}
-
-	streamer := findStreamer(cfg.Streamers, username)
-	if streamer == nil {
-		fmt.Fprintf(os.Stderr, "エラー: %s は登録されていません\n", username)
+	httpclient.SetUserAgent(cfg.Network.UserAgent)
+	if err := httpclient.ConfigureTLS(cfg.Network.CAFile, cfg.Network.InsecureSkipVerify); err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
 		os.Exit(1)
 	}
 
-	if len(streamer.Webhooks) == 0 {
-		fmt.Fprintln(os.Stderr, "エラー: Webhookが登録されていません")
+	entries, err := discord.ReadDeadLetters(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
 		os.Exit(1)
 	}
+	if len(entries) == 0 {
+		fmt.Println("再送する通知はありません")
+		return
+	}
 
-	fmt.Println("登録済みWebhook:")
-	for i, w := range streamer.Webhooks {
-		label := w.Name
-		if label == "" {
-			label = truncateURL(w.URL, 50)
+	var remaining []discord.DeadLetterEntry
+	for i, e := range entries {
+		err := discord.SendWebhook(context.Background(), e.WebhookURL, e.Embed, e.Streamer, e.Key)
+		label := fmt.Sprintf("%s (%s)", e.Embed.Title, e.FailedAt.Local().Format(time.DateTime))
+		if err != nil {
+			fmt.Printf("  %d. %s: 失敗 (%v)\n", i+1, label, err)
+			e.Attempts++
+			e.LastError = err.Error()
+			remaining = append(remaining, e)
+			continue
 		}
-		enabled := getEnabledNotificationTypes(w.Notifications)
-		fmt.Printf("  %d. %s (%s)\n", i+1, label, enabled)
+		fmt.Printf("  %d. %s: 送信しました\n", i+1, label)
 	}
 
-	input := promptInput("削除する番号: ")
-	index, err := strconv.Atoi(input)
-	if err != nil || index < 1 || index > len(streamer.Webhooks) {
-		fmt.Fprintln(os.Stderr, "エラー: 無効な番号です")
+	if err := discord.WriteDeadLetters(path, remaining); err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: デッドレターファイルの更新に失敗: %v\n", err)
 		os.Exit(1)
 	}
-	index-- // 0-based
-
-	streamer.Webhooks = append(streamer.Webhooks[:index], streamer.Webhooks[index+1:]...)
-	if err := config.Save(configPath, cfg); err != nil {
-		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+	fmt.Printf("%d件中%d件を再送しました\n", len(entries), len(entries)-len(remaining))
+	if len(remaining) > 0 {
+		fmt.Printf("送信できなかった%d件は %s に残しています\n", len(remaining), path)
 		os.Exit(1)
 	}
-	fmt.Printf("Webhookを削除しました (残り: %d件)\n", len(streamer.Webhooks))
 }
 
-// configureWebhook は配信者のWebhook通知設定を変更する。
-func configureWebhook(username string) {
+// dedupeWebhooks は複数箇所に設定されたWebhook URLを表示する。applyがtrueなら同じ配信者内の重複をまとめて保存する。
+func dedupeWebhooks(apply bool) {
 	cfg, err := config.Load(configPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
 		os.Exit(1)
 	}
 
-	streamer := findStreamer(cfg.Streamers, username)
-	if streamer == nil {
-		fmt.Fprintf(os.Stderr, "エラー: %s は登録されていません\n", username)
-		os.Exit(1)
+	dups := cfg.DuplicateWebhooks()
+	if len(dups) == 0 {
+		fmt.Println("重複したWebhook URLはありません")
+		return
 	}
 
-	if len(streamer.Webhooks) == 0 {
-		fmt.Fprintln(os.Stderr, "エラー: Webhookが登録されていません")
+	sameStreamer := 0
+	for _, d := range dups {
+		kind := "配信者間で共有"
+		if d.SameStreamer {
+			kind = "同じ配信者内で重複"
+			sameStreamer++
+		}
+		fmt.Printf("%s [%s]\n", truncateURL(d.URL, 60), kind)
+		for _, l := range d.Locations {
+			fmt.Printf("  - %s\n", l)
+		}
+	}
+
+	if sameStreamer == 0 {
+		fmt.Println("\n配信者間の共有のみのため、まとめる必要はありません")
+		return
+	}
+	if !apply {
+		fmt.Println("\n同じ配信者内の重複をまとめるには --apply を指定してください")
+		return
+	}
+
+	var removed int
+	err = config.Apply(configPath, func(cfg *config.Config) error {
+		removed = cfg.DedupeWebhooks()
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
 		os.Exit(1)
 	}
+	fmt.Printf("\n重複した送信先を%d件まとめました\n", removed)
+}
 
-	fmt.Println("登録済みWebhook:")
-	for i, w := range streamer.Webhooks {
-		label := w.Name
-		if label == "" {
-			label = truncateURL(w.URL, 50)
-		}
-		enabled := getEnabledNotificationTypes(w.Notifications)
-		fmt.Printf("  %d. %s (%s)\n", i+1, label, enabled)
+// cloneWebhooks はスライスやmapを共有しないようWebhook設定を複製する。
+func cloneWebhooks(webhooks []config.WebhookConfig) []config.WebhookConfig {
+	cloned := make([]config.WebhookConfig, len(webhooks))
+	for i, w := range webhooks {
+		w.URLs = slices.Clone(w.URLs)
+		w.Tags = slices.Clone(w.Tags)
+		w.Headers = maps.Clone(w.Headers)
+		w.GameFilter = slices.Clone(w.GameFilter)
+		w.IdentityByType = maps.Clone(w.IdentityByType)
+		cloned[i] = w
 	}
+	return cloned
+}
 
-	input := promptInput("\n設定する番号: ")
-	index, err := strconv.Atoi(input)
-	if err != nil || index < 1 || index > len(streamer.Webhooks) {
-		fmt.Fprintln(os.Stderr, "エラー: 無効な番号です")
+// setSetting は全体設定の1項目を変更する。変更後の設定をValidateで検証してから保存する。
+func setSetting(key, value string) {
+	var set func(cfg *config.Config) (oldValue string)
+	switch key {
+	case "polling-interval":
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "エラー: ポーリング間隔は秒数(整数)で指定してください")
+			os.Exit(1)
+		}
+		set = func(cfg *config.Config) string {
+			oldValue := strconv.Itoa(cfg.Polling.IntervalSeconds)
+			cfg.Polling.IntervalSeconds = seconds
+			return oldValue
+		}
+	case "log-level":
+		value = strings.ToLower(value)
+		set = func(cfg *config.Config) string {
+			oldValue := cfg.Log.Level
+			cfg.Log.Level = value
+			return oldValue
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "エラー: 不明な設定項目です: %s (polling-interval/log-level)\n", key)
 		os.Exit(1)
 	}
-	index-- // 0-based
 
-	w := &streamer.Webhooks[index]
-	fmt.Println("\n通知設定 (y/n):")
+	var oldValue string
+	updateConfig(func(cfg *config.Config) error {
+		oldValue = set(cfg)
+		return cfg.Validate()
+	})
+	fmt.Printf("%s: %s → %s\n", key, oldValue, value)
+}
 
-	onlineInput := promptInput(fmt.Sprintf("  online [%s]: ", boolToYN(w.Notifications.Online)))
-	offlineInput := promptInput(fmt.Sprintf("  offline [%s]: ", boolToYN(w.Notifications.Offline)))
-	titleInput := promptInput(fmt.Sprintf("  titleChange [%s]: ", boolToYN(w.Notifications.TitleChange)))
-	gameInput := promptInput(fmt.Sprintf("  gameChange [%s]: ", boolToYN(w.Notifications.GameChange)))
+// weekdayNames は曜日の日本語表記。
+var weekdayNames = [...]string{"日", "月", "火", "水", "木", "金", "土"}
+
+// formatSlot は予測枠を「火曜20時頃 (確率 75%)」形式で表示用に整形する。
+func formatSlot(slot history.Slot) string {
+	return fmt.Sprintf("%s曜%d時頃 (確率 %.0f%%)", weekdayNames[slot.Weekday], slot.Hour, slot.Probability*100)
+}
 
-	w.Notifications = config.NotificationSettings{
-		Online:      parseYesNo(onlineInput, w.Notifications.Online),
-		Offline:     parseYesNo(offlineInput, w.Notifications.Offline),
-		TitleChange: parseYesNo(titleInput, w.Notifications.TitleChange),
-		GameChange:  parseYesNo(gameInput, w.Notifications.GameChange),
+// predictStream は配信履歴から次回配信を予測して表示する。
+func predictStream(username string) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
+		os.Exit(1)
 	}
 
-	if err := config.Save(configPath, cfg); err != nil {
+	if findStreamer(cfg.Streamers, username) == nil {
+		fmt.Fprintf(os.Stderr, "エラー: %s は登録されていません\n", username)
+		os.Exit(1)
+	}
+
+	store, err := history.Open(cfg.HistoryPath())
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "エラー: %v\n", err)
 		os.Exit(1)
 	}
-	fmt.Printf("\nWebhook %d の設定を更新しました\n", index+1)
+
+	prediction, err := history.Predict(store.Sessions(username), time.Now(), time.Local)
+	if errors.Is(err, history.ErrInsufficientData) {
+		fmt.Printf("%s: 予測に十分なデータがありません (必要な配信回数: %d回以上)\n", username, history.MinSessionsForPrediction)
+		if !cfg.History.Enabled {
+			fmt.Println("配信履歴の記録が無効です。config.json の history.enabled を true にしてください")
+		}
+		return
+	}
+
+	fmt.Printf("%s の次回配信予測 (配信%d回 / %d週分の履歴より):\n", username, prediction.Sessions, prediction.Weeks)
+	fmt.Printf("  %sの可能性が高い → %s\n", formatSlot(prediction.Next), prediction.Next.Next.Format("2006-01-02 15:04"))
+	fmt.Println("\n候補:")
+	for i, c := range prediction.Candidates {
+		fmt.Printf("  %d. %s\n", i+1, formatSlot(c))
+	}
 }
 
 // parseYesNo は入力をboolに変換する。空文字列の場合は現在値を返す。
@@ -352,14 +1563,42 @@ func printUsage() {
 	fmt.Printf(`
 使い方:
   %s                            監視を開始
+  %s run [--tag <tag>]          指定タグのWebhookにのみ送信して監視を開始
+  %s init                       対話形式で設定ファイルを作成
   %s add <username>             配信者を追加
   %s remove <username>          配信者を削除
   %s list                       配信者一覧を表示
+  %s info <username>            配信者の設定とポーリングのヘルス状態を表示
+  %s check <username> [--prev <file>] [--send]
+                                1人分のポーリングを実行し検出される変更を表示 (--sendで送信)
+  %s matrix [--json]            配信者×通知タイプごとに有効なWebhook数を表示
+  %s stats reset [<username>]   累計の配信記録を消去 (省略時は全配信者)
   %s webhook add <username>     Webhookを追加
   %s webhook remove <username>  Webhookを削除
   %s webhook config <username>  Webhook通知設定を変更
+  %s webhook url add <username>     Webhookに送信先URLを追加 (グループ化)
+  %s webhook url remove <username>  Webhookグループから送信先URLを削除
+  %s webhook test <username> [--tag <tag>]
+                                Webhookにテスト通知を送信
+  %s validate [--concurrency <n>] [--timeout <秒>]
+                                設定を検証しWebhook疎通を確認
+  %s config dedupe [--apply]    重複したWebhook URLを表示 (--applyで同じ配信者内の重複をまとめる)
+  %s preview <type> <username> [--webhook <url>] [--lang <ja|en>]
+                                通知タイプのEmbedをJSONで表示 (--webhookで送信)
+  %s predict <username>         配信履歴から次回配信を予測
+  %s set polling-interval <秒>  ポーリング間隔を変更
+  %s set log-level <level>      ログレベルを変更 (debug/info/warn/error)
+  %s import <file> [--skip-existing]
+                                JSONファイルから配信者を一括追加 (失敗時は全て取り消し)
+  %s copy-settings <from> <to>...
+                                配信者のWebhook設定を他の配信者にコピー
+  %s replay-dlq <file>          デッドレターファイルの通知を再送 (送信できた通知はファイルから削除)
+  %s version                    バージョン情報を表示
   %s help                       このヘルプを表示
-`, exe, exe, exe, exe, exe, exe, exe, exe)
+
+共通オプション:
+  --profile <name>              config.jsonのprofilesから使用するプロファイルを選択
+`, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe, exe)
 }
 
 // promptUsername はユーザー名を対話的に取得する。
@@ -387,6 +1626,7 @@ func interactiveMode() {
 		{key: "4", label: "Webhookを追加", action: func() { addWebhook(promptUsername()) }},
 		{key: "5", label: "Webhookを削除", action: func() { removeWebhook(promptUsername()) }},
 		{key: "6", label: "Webhook通知設定", action: func() { configureWebhook(promptUsername()) }},
+		{key: "7", label: "Webhookテスト送信", action: func() { testWebhook(promptUsername(), nil) }},
 	}
 
 	fmt.Println("Stream Notifier CLI")
@@ -440,12 +1680,35 @@ func Run(args []string) {
 	case "remove":
 		removeStreamer(requireUsername(args, 1))
 
+	case "init":
+		initConfig()
+
 	case "list":
 		listStreamers()
 
+	case "info":
+		showStreamerInfo(requireUsername(args, 1))
+
+	case "check":
+		checkStreamer(requireUsername(args, 1), flagValue(args[2:], "prev"), slices.Contains(args[2:], "--send"))
+
+	case "matrix":
+		showMatrix(slices.Contains(args[1:], "--json"))
+
+	case "stats":
+		if len(args) < 2 || args[1] != "reset" {
+			fmt.Fprintln(os.Stderr, "エラー: stats reset を指定してください")
+			os.Exit(1)
+		}
+		var username string
+		if len(args) >= 3 {
+			username = requireUsername(args, 2)
+		}
+		resetLifetimeStats(username)
+
 	case "webhook":
 		if len(args) < 2 {
-			fmt.Fprintln(os.Stderr, "エラー: webhook add/remove/config を指定してください")
+			fmt.Fprintln(os.Stderr, "エラー: webhook add/remove/config/test/url を指定してください")
 			os.Exit(1)
 		}
 		switch args[1] {
@@ -455,10 +1718,79 @@ func Run(args []string) {
 			removeWebhook(requireUsername(args, 2))
 		case "config":
 			configureWebhook(requireUsername(args, 2))
+		case "test":
+			testWebhook(requireUsername(args, 2), config.ParseTags(flagValue(args[3:], "tag")))
+		case "url":
+			if len(args) < 3 {
+				fmt.Fprintln(os.Stderr, "エラー: webhook url add/remove を指定してください")
+				os.Exit(1)
+			}
+			switch args[2] {
+			case "add":
+				addGroupURL(requireUsername(args, 3))
+			case "remove":
+				removeGroupURL(requireUsername(args, 3))
+			default:
+				fmt.Fprintln(os.Stderr, "エラー: webhook url add/remove を指定してください")
+				os.Exit(1)
+			}
 		default:
-			fmt.Fprintln(os.Stderr, "エラー: webhook add/remove/config を指定してください")
+			fmt.Fprintln(os.Stderr, "エラー: webhook add/remove/config/test/url を指定してください")
+			os.Exit(1)
+		}
+
+	case "validate":
+		concurrency := parseIntFlag(args[1:], "concurrency", defaultVerifyConcurrency)
+		timeout := parseIntFlag(args[1:], "timeout", defaultVerifyTimeoutSeconds)
+		validateConfig(concurrency, time.Duration(timeout)*time.Second)
+
+	case "config":
+		if len(args) < 2 || args[1] != "dedupe" {
+			fmt.Fprintln(os.Stderr, "エラー: config dedupe を指定してください")
+			os.Exit(1)
+		}
+		dedupeWebhooks(slices.Contains(args[2:], "--apply"))
+
+	case "preview":
+		if len(args) < 3 {
+			fmt.Fprintf(os.Stderr, "エラー: 通知タイプとユーザー名を指定してください (%s)\n", strings.Join(previewTypes, "/"))
+			os.Exit(1)
+		}
+		previewEmbed(args[1], args[2], flagValue(args[3:], "webhook"), flagValue(args[3:], "lang"))
+
+	case "predict":
+		predictStream(requireUsername(args, 1))
+
+	case "set":
+		if len(args) < 3 {
+			fmt.Fprintln(os.Stderr, "エラー: 設定項目と値を指定してください (例: set polling-interval 60)")
+			os.Exit(1)
+		}
+		setSetting(args[1], args[2])
+
+	case "import":
+		if len(args) < 2 || strings.HasPrefix(args[1], "--") {
+			fmt.Fprintln(os.Stderr, "エラー: インポートするファイルを指定してください")
+			os.Exit(1)
+		}
+		importStreamers(args[1], slices.Contains(args[2:], "--skip-existing"))
+
+	case "copy-settings":
+		if len(args) < 3 {
+			fmt.Fprintln(os.Stderr, "エラー: コピー元とコピー先のユーザー名を指定してください")
 			os.Exit(1)
 		}
+		copySettings(args[1], args[2:])
+
+	case "replay-dlq":
+		if len(args) < 2 || strings.HasPrefix(args[1], "--") {
+			fmt.Fprintln(os.Stderr, "エラー: デッドレターファイルを指定してください")
+			os.Exit(1)
+		}
+		replayDeadLetters(args[1])
+
+	case "version", "--version":
+		fmt.Printf("Stream Notifier %s\n", version.String())
 
 	case "help", "--help", "-h":
 		printUsage()
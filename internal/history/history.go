@@ -0,0 +1,154 @@
+// Package history は配信履歴の記録と、それに基づく配信予測を提供する。
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Session は1回分の配信記録。EndedAtがゼロ値なら配信中(または終了を観測できなかった)。
+type Session struct {
+	Username  string    `json:"username"`
+	StartedAt time.Time `json:"startedAt"`
+	EndedAt   time.Time `json:"endedAt,omitzero"`
+	Title     string    `json:"title,omitempty"`
+	Game      string    `json:"game,omitempty"`
+}
+
+// Store は配信履歴をディスクに永続化する。
+type Store struct {
+	path string
+
+	mu       sync.Mutex
+	sessions []Session
+}
+
+// Open は履歴ファイルを読み込んでStoreを作成する。ファイルがなければ空の履歴で開始する。
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("配信履歴の読み込みに失敗: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.sessions); err != nil {
+		return nil, fmt.Errorf("配信履歴の解析に失敗: %w", err)
+	}
+	return s, nil
+}
+
+// RecordStart は配信開始を記録する。同じ開始時刻の配信中セッションがあれば何もしない(再起動時の重複防止)。
+func (s *Store) RecordStart(username string, startedAt time.Time, title, game string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := s.openSession(username); i >= 0 && s.sessions[i].StartedAt.Equal(startedAt) {
+		return
+	}
+	s.sessions = append(s.sessions, Session{
+		Username:  strings.ToLower(username),
+		StartedAt: startedAt,
+		Title:     title,
+		Game:      game,
+	})
+	s.save()
+}
+
+// RecordEnd は配信中セッションに終了時刻を記録する。
+func (s *Store) RecordEnd(username string, endedAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.openSession(username)
+	if i < 0 {
+		return
+	}
+	s.sessions[i].EndedAt = endedAt
+	s.save()
+}
+
+// Sessions は指定配信者の配信記録を古い順に返す。
+func (s *Store) Sessions(username string) []Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	username = strings.ToLower(username)
+	var result []Session
+	for _, sess := range s.sessions {
+		if sess.Username == username {
+			result = append(result, sess)
+		}
+	}
+	return result
+}
+
+// openSession は指定配信者の最新の配信中セッションのインデックスを返す。なければ-1。
+func (s *Store) openSession(username string) int {
+	username = strings.ToLower(username)
+	for i := len(s.sessions) - 1; i >= 0; i-- {
+		if s.sessions[i].Username != username {
+			continue
+		}
+		if s.sessions[i].EndedAt.IsZero() {
+			return i
+		}
+		return -1
+	}
+	return -1
+}
+
+// save は履歴を一時ファイル経由でアトミックに書き出す。呼び出し側でmuを保持すること。
+func (s *Store) save() {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		slog.Error("配信履歴ディレクトリの作成に失敗", "error", err)
+		return
+	}
+
+	data, err := json.MarshalIndent(s.sessions, "", "  ")
+	if err != nil {
+		slog.Error("配信履歴のシリアライズに失敗", "error", err)
+		return
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		slog.Error("配信履歴の保存に失敗", "error", err)
+		return
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		slog.Error("配信履歴の保存に失敗", "error", err)
+	}
+}
+
+// StreamsPerWeek は直近window内の週あたり配信回数を返す。
+// 履歴が1週間分に満たない場合は判断できないためokはfalse。
+func (s *Store) StreamsPerWeek(username string, now time.Time, window time.Duration) (perWeek float64, ok bool) {
+	sessions := s.Sessions(username)
+	if len(sessions) == 0 {
+		return 0, false
+	}
+
+	const week = 7 * 24 * time.Hour
+	span := min(now.Sub(sessions[0].StartedAt), window)
+	if span < week {
+		return 0, false
+	}
+
+	count := 0
+	for _, sess := range sessions {
+		if now.Sub(sess.StartedAt) <= window {
+			count++
+		}
+	}
+	return float64(count) / (float64(span) / float64(week)), true
+}
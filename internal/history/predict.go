@@ -0,0 +1,126 @@
+package history
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"time"
+)
+
+// MinSessionsForPrediction は予測に必要な最低配信回数。
+const MinSessionsForPrediction = 5
+
+// ErrInsufficientData は予測に十分な配信履歴がないことを表す。
+var ErrInsufficientData = errors.New("予測に十分なデータがありません")
+
+// Slot は曜日・時間帯ごとの配信開始確率。
+type Slot struct {
+	Weekday time.Weekday
+	Hour    int
+	// Probability はこの曜日・時間帯に配信が始まった週の割合(0〜1)。
+	Probability float64
+	// Next はnow以降で次にこの曜日・時間帯が来る時刻。
+	Next time.Time
+}
+
+// Prediction は次回配信の予測結果。
+type Prediction struct {
+	// Next は次回配信の推定。確率の高い候補のうち直近のもの。
+	Next Slot
+	// Candidates は確率の高い順の候補(最大3件)。
+	Candidates []Slot
+	Sessions   int
+	Weeks      int
+}
+
+// Predict は配信履歴の曜日・時間帯別の開始頻度から次回配信を推定する。
+// 時刻はlocのタイムゾーンで集計する。
+func Predict(sessions []Session, now time.Time, loc *time.Location) (Prediction, error) {
+	if len(sessions) < MinSessionsForPrediction {
+		return Prediction{}, ErrInsufficientData
+	}
+
+	type slotKey struct {
+		weekday time.Weekday
+		hour    int
+	}
+	type weekKey struct {
+		year, week int
+		slot       slotKey
+	}
+
+	// 同じ週・同じ枠で複数回開始していても1回として数える
+	seen := make(map[weekKey]bool)
+	counts := make(map[slotKey]int)
+	first, last := sessions[0].StartedAt, sessions[0].StartedAt
+	for _, s := range sessions {
+		first = minTime(first, s.StartedAt)
+		last = maxTime(last, s.StartedAt)
+
+		t := s.StartedAt.In(loc)
+		year, week := t.ISOWeek()
+		k := slotKey{t.Weekday(), t.Hour()}
+		if wk := (weekKey{year, week, k}); !seen[wk] {
+			seen[wk] = true
+			counts[k]++
+		}
+	}
+
+	weeks := int(last.Sub(first).Hours()/(24*7)) + 1
+
+	var slots []Slot
+	for k, n := range counts {
+		slots = append(slots, Slot{
+			Weekday:     k.weekday,
+			Hour:        k.hour,
+			Probability: min(float64(n)/float64(weeks), 1),
+			Next:        nextOccurrence(now.In(loc), k.weekday, k.hour),
+		})
+	}
+	slices.SortFunc(slots, func(a, b Slot) int {
+		if c := cmp.Compare(b.Probability, a.Probability); c != 0 {
+			return c
+		}
+		return a.Next.Compare(b.Next)
+	})
+
+	candidates := slots[:min(len(slots), 3)]
+	next := candidates[0]
+	for _, c := range candidates[1:] {
+		// 最有力候補と大差ない確率なら直近の枠を次回配信とみなす
+		if c.Probability >= next.Probability*0.8 && c.Next.Before(next.Next) {
+			next = c
+		}
+	}
+
+	return Prediction{
+		Next:       next,
+		Candidates: candidates,
+		Sessions:   len(sessions),
+		Weeks:      weeks,
+	}, nil
+}
+
+// nextOccurrence はnow以降で次に指定曜日・時刻(正時)になる時刻を返す。
+func nextOccurrence(now time.Time, weekday time.Weekday, hour int) time.Time {
+	days := (int(weekday) - int(now.Weekday()) + 7) % 7
+	t := time.Date(now.Year(), now.Month(), now.Day()+days, hour, 0, 0, 0, now.Location())
+	if !t.After(now) {
+		t = t.AddDate(0, 0, 7)
+	}
+	return t
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,92 @@
+// Package httpclient は外部へのHTTPリクエストで共通に使うクライアントを提供する。
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync/atomic"
+
+	"github.com/yuu1111/StreamNotifier/internal/version"
+)
+
+// transport はClientのTransport。ConfigureTLSで送信に使うTransportを差し替える。
+var transport = &userAgentTransport{base: http.DefaultTransport}
+
+// Client は全リクエストにUser-Agentを付与するHTTPクライアント。http.DefaultClientの代わりに使う。
+var Client = &http.Client{Transport: transport}
+
+// userAgent は設定で上書きされたUser-Agent。空ならDefaultUserAgentを使う。
+var userAgent atomic.Value
+
+// DefaultUserAgent はUser-Agent未設定時の値を返す。
+func DefaultUserAgent() string {
+	return "StreamNotifier/" + version.Version
+}
+
+// SetUserAgent は送信するUser-Agentを設定する。空文字列ならデフォルトに戻す。
+func SetUserAgent(ua string) {
+	userAgent.Store(ua)
+}
+
+// UserAgent は現在のUser-Agentを返す。
+func UserAgent() string {
+	if ua, _ := userAgent.Load().(string); ua != "" {
+		return ua
+	}
+	return DefaultUserAgent()
+}
+
+// userAgentTransport はリクエストにUser-Agentヘッダーを付与する。
+type userAgentTransport struct {
+	base http.RoundTripper
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// genericのheaders等で個別に指定されている場合はそちらを優先する
+	if req.Header.Get("User-Agent") != "" {
+		return t.base.RoundTrip(req)
+	}
+	// RoundTripperは渡されたリクエストを変更してはならないため複製する
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", UserAgent())
+	return t.base.RoundTrip(req)
+}
+
+// ConfigureTLS はTwitch APIとDiscordへの送信で使うTLS設定を変更する。起動時、リクエストを送る前に呼び出す。
+// caFileを指定するとシステムの証明書に加えてPEM形式の証明書を信頼する(社内プロキシ等の独自CA向け)。
+// insecureがtrueなら証明書の検証を行わない。両方とも未指定ならデフォルトのTransportを使う。
+func ConfigureTLS(caFile string, insecure bool) error {
+	if caFile == "" && !insecure {
+		transport.base = http.DefaultTransport
+		return nil
+	}
+
+	tlsConfig := &tls.Config{}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return fmt.Errorf("network.caFileの読み込みに失敗: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("network.caFile: PEM形式の証明書が見つかりません: %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if insecure {
+		slog.Warn("network.insecureSkipVerifyが有効です。TLS証明書を検証しないため、通信の盗聴・改ざんを検出できません。検証用途以外では使用しないでください")
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.TLSClientConfig = tlsConfig
+	transport.base = base
+	return nil
+}
@@ -0,0 +1,62 @@
+// Package lock は同じ設定で複数のインスタンスが動くのを防ぐOSレベルのファイルロックを提供する。
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// ErrLocked は他のプロセスがロックを保持していることを表す。
+var ErrLocked = errors.New("他のインスタンスがロックを保持しています")
+
+// retryInterval はWaitでロック取得を再試行する間隔。
+const retryInterval = time.Second
+
+// Lock は取得済みのファイルロック。プロセスが異常終了した場合もOSが解放する。
+type Lock struct {
+	f *os.File
+}
+
+// TryAcquire はpathのロックを取得する。他のプロセスが保持していればErrLockedを返す。
+func TryAcquire(path string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("ロックファイルのディレクトリ作成に失敗: %w", err)
+	}
+	f, err := lockFile(path)
+	if err != nil {
+		if errors.Is(err, ErrLocked) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("ロックの取得に失敗: %w", err)
+	}
+	// 調査用に保持しているプロセスを書いておく
+	if err := f.Truncate(0); err == nil {
+		_, _ = f.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+	}
+	return &Lock{f: f}, nil
+}
+
+// Wait はpathのロックを取得できるまで待つ。ctxがキャンセルされたらctx.Err()を返す。
+func Wait(ctx context.Context, path string) (*Lock, error) {
+	for {
+		l, err := TryAcquire(path)
+		if !errors.Is(err, ErrLocked) {
+			return l, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// Release はロックを解放する。ロックファイル自体は次回の取得で再利用するため削除しない。
+func (l *Lock) Release() error {
+	return l.f.Close()
+}
@@ -0,0 +1,29 @@
+//go:build windows
+
+package lock
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// errSharingViolation は他のハンドルが共有を許可せずに開いているファイルを開こうとした際のエラー。
+const errSharingViolation syscall.Errno = 32
+
+// lockFile はpathを共有なしで開くことで排他ロックする。ハンドルを閉じると解放される。
+func lockFile(path string) (*os.File, error) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	h, err := syscall.CreateFile(name, syscall.GENERIC_READ|syscall.GENERIC_WRITE, 0, nil,
+		syscall.OPEN_ALWAYS, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		if errors.Is(err, errSharingViolation) {
+			return nil, ErrLocked
+		}
+		return nil, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	return os.NewFile(uintptr(h), path), nil
+}
@@ -0,0 +1,100 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+	"github.com/yuu1111/StreamNotifier/pkg/discord"
+)
+
+// CircuitBreaker は単位時間あたりの総通知数を制限し、超過時は一定時間すべての通知を停止する。
+// 設定ミスや状態振動による通知の暴走に対する最後の安全装置。
+type CircuitBreaker struct {
+	max      int
+	window   time.Duration
+	pause    time.Duration
+	alertURL string
+
+	mu          sync.Mutex
+	sent        []time.Time
+	pausedUntil time.Time
+	suppressed  int
+}
+
+// NewCircuitBreaker は設定からCircuitBreakerを作成する。上限が0なら無効としてnilを返す。
+func NewCircuitBreaker(cfg config.CircuitBreakerConfig) *CircuitBreaker {
+	if cfg.MaxNotifications <= 0 {
+		return nil
+	}
+	return &CircuitBreaker{
+		max:      cfg.MaxNotifications,
+		window:   time.Duration(cfg.WindowSeconds) * time.Second,
+		pause:    time.Duration(cfg.PauseSeconds) * time.Second,
+		alertURL: cfg.AlertWebhookURL,
+	}
+}
+
+// Allow は通知を送信してよいか判定し、送信する場合はカウントする。
+func (b *CircuitBreaker) Allow(ctx context.Context) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(b.pausedUntil) {
+		b.suppressed++
+		return false
+	}
+	if !b.pausedUntil.IsZero() {
+		slog.Warn("通知の停止を解除しました", "suppressed", b.suppressed)
+		b.pausedUntil = time.Time{}
+		b.suppressed = 0
+		b.sent = nil
+	}
+
+	// ウィンドウ外の送信記録を捨てる
+	cutoff := now.Add(-b.window)
+	i := 0
+	for i < len(b.sent) && b.sent[i].Before(cutoff) {
+		i++
+	}
+	b.sent = b.sent[i:]
+
+	if len(b.sent) >= b.max {
+		b.pausedUntil = now.Add(b.pause)
+		b.suppressed = 1
+		b.alert(ctx)
+		return false
+	}
+
+	b.sent = append(b.sent, now)
+	return true
+}
+
+// alert は停止状態に入ったことを管理者に知らせる。
+func (b *CircuitBreaker) alert(ctx context.Context) {
+	slog.Error("通知数が上限を超えたため全通知を一時停止します",
+		"max", b.max,
+		"windowSeconds", int(b.window.Seconds()),
+		"pauseSeconds", int(b.pause.Seconds()))
+
+	if b.alertURL == "" {
+		return
+	}
+
+	embed := discord.Embed{
+		Title: "通知を一時停止しました",
+		Description: fmt.Sprintf("%d秒間に%d件を超える通知が発生したため、%d秒間すべての通知を停止します。設定や配信者の状態を確認してください。",
+			int(b.window.Seconds()), b.max, int(b.pause.Seconds())),
+		Color:     0xff0000,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+	go func() {
+		if err := discord.SendWebhook(ctx, b.alertURL, embed, discord.StreamerInfo{DisplayName: "Stream Notifier"}, ""); err != nil {
+			slog.Error("管理アラート送信失敗", "error", err)
+		}
+	}()
+}
@@ -0,0 +1,86 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+	"github.com/yuu1111/StreamNotifier/pkg/discord"
+	"github.com/yuu1111/StreamNotifier/pkg/monitor"
+)
+
+// DiscordNotifier はDiscord WebhookにEmbedを送信するNotifier。Webhookグループなら全URLへ並列送信する。
+type DiscordNotifier struct {
+	urls      []string
+	embedOpts discord.EmbedOptions
+	queue     *discord.RetryQueue
+	// webhook は投稿者名・アイコンの上書きの参照元。
+	webhook config.WebhookConfig
+	// onSent は送信したメッセージの通知先。nilならメッセージIDを取得しない(wait=trueを付けない)。
+	onSent func(url string, embed discord.Embed, msg discord.Message)
+}
+
+// Notify は変更からEmbedを構築してDiscordへ送信する。
+func (n *DiscordNotifier) Notify(ctx context.Context, change monitor.DetectedChange) error {
+	embed := discord.BuildEmbed(change, n.embedOpts)
+	streamerInfo := discord.StreamerInfo{
+		DisplayName:     change.CurrentState.DisplayName,
+		ProfileImageURL: change.CurrentState.ProfileImageURL,
+	}
+	identity := n.webhook.IdentityFor(change.Type)
+	if identity.Username != "" {
+		streamerInfo.DisplayName = identity.Username
+	}
+	if identity.AvatarURL != "" {
+		streamerInfo.ProfileImageURL = identity.AvatarURL
+	}
+	if n.embedOpts.IncludeContent {
+		streamerInfo.Content = discord.BuildContent(change, n.embedOpts)
+	}
+	// 再送で重複投稿しないよう、リトライキューからの再送にも同じキーを使う
+	key := discord.IdempotencyKey(change)
+	if n.onSent != nil {
+		return n.notifyTracked(ctx, embed, streamerInfo, key)
+	}
+	if len(n.urls) == 1 {
+		err := discord.SendWebhook(ctx, n.urls[0], embed, streamerInfo, key)
+		n.enqueueIfRetryable(n.urls[0], embed, streamerInfo, key, err)
+		return err
+	}
+
+	errs := discord.SendToMultipleWebhooks(ctx, n.urls, embed, streamerInfo, key)
+	for i, err := range errs {
+		n.enqueueIfRetryable(n.urls[i], embed, streamerInfo, key, err)
+	}
+	return errors.Join(errs...)
+}
+
+// notifyTracked は作成されたメッセージを取得しながら送信し、onSentへ渡す。
+func (n *DiscordNotifier) notifyTracked(ctx context.Context, embed discord.Embed, streamerInfo discord.StreamerInfo, key string) error {
+	msgs, errs := discord.SendToMultipleWebhookMessages(ctx, n.urls, embed, streamerInfo, key)
+	for i, err := range errs {
+		if errors.Is(err, discord.ErrAlreadySent) {
+			errs[i] = nil
+			continue
+		}
+		if err != nil {
+			n.enqueueIfRetryable(n.urls[i], embed, streamerInfo, key, err)
+			continue
+		}
+		n.onSent(n.urls[i], embed, *msgs[i])
+	}
+	return errors.Join(errs...)
+}
+
+// enqueueIfRetryable は一時的な送信エラーならリトライキューに積む。
+// 再送しない送信エラーはデッドレターファイルに書き出す(設定時のみ)。
+func (n *DiscordNotifier) enqueueIfRetryable(url string, embed discord.Embed, streamerInfo discord.StreamerInfo, key string, err error) {
+	if err == nil {
+		return
+	}
+	if n.queue != nil && discord.IsRetryable(err) {
+		n.queue.Enqueue(url, embed, streamerInfo, key, err)
+		return
+	}
+	discord.RecordDeadLetter(url, embed, streamerInfo, key, err)
+}
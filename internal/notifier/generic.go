@@ -0,0 +1,77 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"text/template"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/internal/httpclient"
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+	"github.com/yuu1111/StreamNotifier/pkg/monitor"
+)
+
+// GenericNotifier はテンプレートから構築した任意のJSONを汎用HTTPエンドポイントへPOSTするNotifier。
+type GenericNotifier struct {
+	urls    []string
+	headers map[string]string
+	tmpl    *template.Template
+}
+
+// NewGenericNotifier はWebhook設定からGenericNotifierを作成する。
+func NewGenericNotifier(w config.WebhookConfig) (*GenericNotifier, error) {
+	tmpl, err := config.ParseBodyTemplate("body", w.Template)
+	if err != nil {
+		return nil, fmt.Errorf("テンプレートの解析に失敗: %w", err)
+	}
+	return &GenericNotifier{urls: w.Targets(), headers: w.Headers, tmpl: tmpl}, nil
+}
+
+// Notify はDetectedChangeをテンプレートに渡してボディを生成し、全送信先へ送信する。
+func (n *GenericNotifier) Notify(ctx context.Context, change monitor.DetectedChange) error {
+	var body bytes.Buffer
+	if err := n.tmpl.Execute(&body, change); err != nil {
+		return fmt.Errorf("テンプレートの実行に失敗: %w", err)
+	}
+
+	var errs []error
+	for _, url := range n.urls {
+		errs = append(errs, n.post(ctx, url, body.Bytes()))
+	}
+	return errors.Join(errs...)
+}
+
+// post は単一の送信先へボディをPOSTする。
+func (n *GenericNotifier) post(ctx context.Context, url string, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("リクエスト作成に失敗: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range n.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("送信に失敗: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("送信失敗: %d %s", resp.StatusCode, string(respBody))
+	}
+
+	slog.Debug("generic Webhook送信成功", "url", url)
+	return nil
+}
@@ -0,0 +1,202 @@
+// Package notifier は検出した変更を各種通知先へ配信する。
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/internal/audit"
+	"github.com/yuu1111/StreamNotifier/internal/readsync"
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+	"github.com/yuu1111/StreamNotifier/pkg/discord"
+	"github.com/yuu1111/StreamNotifier/pkg/monitor"
+)
+
+// Notifier は変更通知の送信先。
+type Notifier interface {
+	Notify(ctx context.Context, change monitor.DetectedChange) error
+}
+
+// New はWebhook設定の種別に応じたNotifierを作成する。queueがnilでなければDiscord送信失敗時にキューへ積む。
+func New(w config.WebhookConfig, embedOpts discord.EmbedOptions, queue *discord.RetryQueue) (Notifier, error) {
+	switch w.Type {
+	case "", config.WebhookDiscord:
+		return &DiscordNotifier{urls: w.Targets(), embedOpts: embedOpts, queue: queue, webhook: w}, nil
+	case config.WebhookGeneric:
+		return NewGenericNotifier(w)
+	default:
+		return nil, fmt.Errorf("不明なWebhook種別: %s", w.Type)
+	}
+}
+
+// Dispatcher は検出した変更を配信者のWebhook設定に従って送信する。
+type Dispatcher struct {
+	embedOpts discord.EmbedOptions
+	queue     *discord.RetryQueue
+	breaker   *CircuitBreaker
+	tags      []string
+	readSync  *readsync.Tracker
+	audit     *audit.Logger
+}
+
+// NewDispatcher はDispatcherインスタンスを作成する。queueはリトライキューが無効ならnil。
+func NewDispatcher(cfg *config.Config, queue *discord.RetryQueue) *Dispatcher {
+	return &Dispatcher{
+		embedOpts: discord.NewEmbedOptions(cfg),
+		queue:     queue,
+		breaker:   NewCircuitBreaker(cfg.CircuitBreaker),
+	}
+}
+
+// SetTags は送信対象をいずれかのタグを持つWebhookに限定する。空なら全Webhookが対象。
+func (d *Dispatcher) SetTags(tags []string) {
+	d.tags = tags
+}
+
+// SetReadSync は送信したDiscordメッセージを既読同期の対象として記録する。
+func (d *Dispatcher) SetReadSync(t *readsync.Tracker) {
+	d.readSync = t
+}
+
+// SetAudit は通知ごとの送信判断と結果を監査ログに記録する。
+func (d *Dispatcher) SetAudit(l *audit.Logger) {
+	d.audit = l
+}
+
+// record は監査ログが有効なら1つのWebhookに対する通知の結果を記録する。
+func (d *Dispatcher) record(change monitor.DetectedChange, index int, webhook config.WebhookConfig, outcome audit.Outcome, reason audit.Reason, err error) {
+	if d.audit == nil {
+		return
+	}
+	embedOpts := d.embedOpts
+	embedOpts.Language = webhook.Language
+	r := audit.Record{
+		Streamer:     change.Streamer,
+		Type:         change.Type,
+		Title:        discord.BuildEmbed(change, embedOpts).Title,
+		OldValue:     change.OldValue,
+		NewValue:     change.NewValue,
+		Webhook:      webhookLabel(webhook),
+		WebhookIndex: index,
+		Outcome:      outcome,
+		Reason:       reason,
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	d.audit.Write(r)
+}
+
+// webhookLabel はログに表示するWebhookの名前を返す。
+func webhookLabel(w config.WebhookConfig) string {
+	if w.Name == "" {
+		return "Webhook"
+	}
+	return w.Name
+}
+
+// Dispatch は変更ごとに通知が有効なWebhookへ送信する。
+// 同じURLが複数のWebhookに設定されていても1つの変更はURLごとに1回だけ送り、最初に一致したWebhookの設定を使う。
+func (d *Dispatcher) Dispatch(ctx context.Context, changes []monitor.DetectedChange, sc config.StreamerConfig) {
+	for _, change := range changes {
+		// 同じ変更を複数のWebhookに送ったメッセージを既読同期で1グループとして扱う
+		groupID := fmt.Sprintf("%s/%s/%d", change.Streamer, change.Type, time.Now().UnixNano())
+		// sent はこの変更を送信済みのURL
+		sent := make(map[string]bool)
+
+		for i, webhook := range sc.Webhooks {
+			if !config.IsNotificationEnabled(change.Type, webhook.Notifications) {
+				d.record(change, i, webhook, audit.OutcomeSuppressed, audit.ReasonDisabled, nil)
+				continue
+			}
+			if !webhook.HasAnyTag(d.tags) {
+				d.record(change, i, webhook, audit.OutcomeSuppressed, audit.ReasonTag, nil)
+				continue
+			}
+			if !webhook.AllowsGame(change.Type, change.CurrentState.GameID, change.CurrentState.GameName) {
+				slog.Debug("ゲームフィルタにより抑制", "streamer", change.Streamer, "type", change.Type, "game", change.CurrentState.GameName)
+				d.record(change, i, webhook, audit.OutcomeSuppressed, audit.ReasonGameFilter, nil)
+				continue
+			}
+			if !withoutDisabledTargets(&webhook) {
+				slog.Debug("送信を停止したWebhookのため抑制", "streamer", change.Streamer, "type", change.Type, "webhook", webhookLabel(webhook))
+				d.record(change, i, webhook, audit.OutcomeSuppressed, audit.ReasonWebhookDisabled, nil)
+				continue
+			}
+			if !coalesceTargets(&webhook, sent) {
+				slog.Debug("同じURLへ送信済みのため統合", "streamer", change.Streamer, "type", change.Type)
+				d.record(change, i, webhook, audit.OutcomeSuppressed, audit.ReasonDuplicateURL, nil)
+				continue
+			}
+			if d.breaker != nil && !d.breaker.Allow(ctx) {
+				slog.Debug("通知停止中のため抑制", "streamer", change.Streamer, "type", change.Type)
+				d.record(change, i, webhook, audit.OutcomeSuppressed, audit.ReasonCircuitOpen, nil)
+				continue
+			}
+
+			logMsg := fmt.Sprintf("[%s] %s → %s",
+				change.CurrentState.DisplayName, change.Type, webhookLabel(webhook))
+			if change.NewValue != "" {
+				logMsg += fmt.Sprintf(" (%s)", change.NewValue)
+			}
+			slog.Info(logMsg)
+
+			embedOpts := d.embedOpts
+			embedOpts.Language = webhook.Language
+			if color, ok := sc.EmbedColor(webhook); ok {
+				embedOpts.Color = &color
+			}
+
+			n, err := New(webhook, embedOpts, d.queue)
+			if err != nil {
+				slog.Error("Notifier作成失敗", "error", err)
+				d.record(change, i, webhook, audit.OutcomeFailed, "", err)
+				continue
+			}
+			if dn, ok := n.(*DiscordNotifier); ok && d.readSync != nil {
+				dn.onSent = func(url string, embed discord.Embed, msg discord.Message) {
+					d.readSync.Record(groupID, url, embed, msg)
+				}
+			}
+			if err := n.Notify(ctx, change); err != nil {
+				slog.Error("Webhook送信失敗", "error", err)
+				d.record(change, i, webhook, audit.OutcomeFailed, "", err)
+				continue
+			}
+			d.record(change, i, webhook, audit.OutcomeSent, "", nil)
+		}
+	}
+}
+
+// withoutDisabledTargets は404/401が続いて送信を停止したURLをWebhookの送信先から除く。送信先が残らなければfalseを返す。
+func withoutDisabledTargets(w *config.WebhookConfig) bool {
+	all := w.Targets()
+	targets := slices.DeleteFunc(slices.Clone(all), discord.IsWebhookDisabled)
+	if len(targets) == 0 {
+		return false
+	}
+	if len(targets) < len(all) {
+		w.URL, w.URLs = targets[0], targets[1:]
+	}
+	return true
+}
+
+// coalesceTargets は送信済みのURLをWebhookの送信先から除き、残りを送信済みとして記録する。
+// 送信先が残らなければfalseを返す。
+func coalesceTargets(w *config.WebhookConfig, sent map[string]bool) bool {
+	all := w.Targets()
+	targets := slices.DeleteFunc(slices.Clone(all), func(u string) bool { return sent[u] })
+	if len(targets) == 0 {
+		return false
+	}
+	if len(targets) < len(all) {
+		w.URL, w.URLs = targets[0], targets[1:]
+	}
+	for _, u := range targets {
+		sent[u] = true
+	}
+	return true
+}
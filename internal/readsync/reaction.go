@@ -0,0 +1,74 @@
+package readsync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/internal/httpclient"
+)
+
+const discordAPIBaseURL = "https://discord.com/api/v10"
+
+// ReactionSource はメッセージに既読リアクションが付いたかを判定する。
+//
+// Webhookはリアクションを受け取れないため、検知にはBot Tokenが必要になる。
+// リアルタイムに検知するにはGateway接続(MESSAGE_REACTION_ADDイベント)が必要だが、
+// 外部依存なしで常時WebSocket接続を維持するのは過剰なため、現在はREST APIのポーリング実装のみを提供する。
+// Gatewayで検知する場合はこのインターフェースを実装して差し替える。
+type ReactionSource interface {
+	HasReaction(ctx context.Context, channelID, messageID string) (bool, error)
+}
+
+// restReactionSource はBot TokenでREST APIを呼び出してリアクションを確認する。
+type restReactionSource struct {
+	token string
+	emoji string
+}
+
+// NewRESTReactionSource はREST APIのポーリングでリアクションを確認するReactionSourceを作成する。
+func NewRESTReactionSource(botToken, emoji string) ReactionSource {
+	return &restReactionSource{token: botToken, emoji: emoji}
+}
+
+// HasReaction は指定の絵文字でリアクションしたユーザーが1人以上いるか判定する。
+func (s *restReactionSource) HasReaction(ctx context.Context, channelID, messageID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	reqURL := fmt.Sprintf("%s/channels/%s/messages/%s/reactions/%s?limit=1",
+		discordAPIBaseURL, url.PathEscape(channelID), url.PathEscape(messageID), url.PathEscape(s.emoji))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return false, fmt.Errorf("リアクション取得リクエスト作成に失敗: %w", err)
+	}
+	req.Header.Set("Authorization", "Bot "+s.token)
+
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("リアクション取得に失敗: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("リアクション取得レスポンスの読み込みに失敗: %w", err)
+	}
+	// メッセージが削除済みなど
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("リアクション取得エラー: %d %s", resp.StatusCode, string(body))
+	}
+
+	var users []json.RawMessage
+	if err := json.Unmarshal(body, &users); err != nil {
+		return false, fmt.Errorf("リアクション取得レスポンスの解析に失敗: %w", err)
+	}
+	return len(users) > 0, nil
+}
@@ -0,0 +1,237 @@
+// Package readsync は複数の通知先に送った同一通知の既読状態を同期する。
+package readsync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+	"github.com/yuu1111/StreamNotifier/pkg/discord"
+)
+
+const (
+	defaultEmoji       = "✅"
+	defaultPollSeconds = 30
+	defaultMaxAgeHours = 24
+
+	// readColor は既読表示に編集したEmbedの色。
+	readColor = 0x747f8d
+)
+
+// Sent はWebhookで送信済みの1メッセージ。
+type Sent struct {
+	WebhookURL string        `json:"webhookUrl"`
+	MessageID  string        `json:"messageId"`
+	ChannelID  string        `json:"channelId"`
+	Embed      discord.Embed `json:"embed"`
+}
+
+// Group は同じ論理通知として送信したメッセージのまとまり。
+type Group struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Messages  []Sent    `json:"messages"`
+}
+
+// Tracker は送信済みメッセージをグループ単位で追跡し、既読リアクションを検知したら
+// 同じグループの全メッセージを既読表示に編集または削除する。
+type Tracker struct {
+	source   ReactionSource
+	action   config.ReadSyncAction
+	interval time.Duration
+	maxAge   time.Duration
+	path     string
+
+	mu     sync.Mutex
+	groups []Group
+}
+
+// New は設定からTrackerを作成し、前回終了時の追跡データを読み込む。
+func New(cfg config.ReadSyncConfig) (*Tracker, error) {
+	emoji := cfg.Emoji
+	if emoji == "" {
+		emoji = defaultEmoji
+	}
+	action := cfg.Action
+	if action == "" {
+		action = config.ReadSyncEdit
+	}
+	pollSeconds := cfg.PollSeconds
+	if pollSeconds == 0 {
+		pollSeconds = defaultPollSeconds
+	}
+	maxAgeHours := cfg.MaxAgeHours
+	if maxAgeHours == 0 {
+		maxAgeHours = defaultMaxAgeHours
+	}
+	path := cfg.Path
+	if path == "" {
+		path = config.DefaultReadSyncPath
+	}
+
+	t := &Tracker{
+		source:   NewRESTReactionSource(cfg.BotToken, emoji),
+		action:   action,
+		interval: time.Duration(pollSeconds) * time.Second,
+		maxAge:   time.Duration(maxAgeHours) * time.Hour,
+		path:     path,
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("既読同期データの読み込みに失敗: %w", err)
+	}
+	if err := json.Unmarshal(data, &t.groups); err != nil {
+		return nil, fmt.Errorf("既読同期データの解析に失敗: %w", err)
+	}
+	return t, nil
+}
+
+// Record は送信したメッセージをグループに追加する。
+func (t *Tracker) Record(groupID, webhookURL string, embed discord.Embed, msg discord.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sent := Sent{WebhookURL: webhookURL, MessageID: msg.ID, ChannelID: msg.ChannelID, Embed: embed}
+	for i := range t.groups {
+		if t.groups[i].ID == groupID {
+			t.groups[i].Messages = append(t.groups[i].Messages, sent)
+			t.save()
+			return
+		}
+	}
+	t.groups = append(t.groups, Group{ID: groupID, CreatedAt: time.Now(), Messages: []Sent{sent}})
+	t.save()
+}
+
+// Run はctxがキャンセルされるまで定期的にリアクションを確認する。
+func (t *Tracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.check(ctx)
+		}
+	}
+}
+
+// check は追跡中の各グループに既読リアクションが付いたか確認し、付いていれば同期する。
+func (t *Tracker) check(ctx context.Context) {
+	t.mu.Lock()
+	groups := t.groups
+	t.groups = nil
+	t.mu.Unlock()
+
+	now := time.Now()
+	var remaining []Group
+	for _, g := range groups {
+		if ctx.Err() != nil {
+			remaining = append(remaining, g)
+			continue
+		}
+		if now.Sub(g.CreatedAt) > t.maxAge {
+			continue
+		}
+		// 送信先が1つなら同期の必要がない(送信中の可能性もあるので期限までは保持する)
+		if len(g.Messages) < 2 {
+			remaining = append(remaining, g)
+			continue
+		}
+
+		read, err := t.isRead(ctx, g)
+		if err != nil {
+			slog.Warn("既読リアクションの確認に失敗", "group", g.ID, "error", err)
+		}
+		if !read {
+			remaining = append(remaining, g)
+			continue
+		}
+		t.markRead(ctx, g)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// 確認中に記録されたメッセージを同じグループへ戻す
+	for _, added := range t.groups {
+		if i := slices.IndexFunc(remaining, func(g Group) bool { return g.ID == added.ID }); i >= 0 {
+			remaining[i].Messages = append(remaining[i].Messages, added.Messages...)
+		} else {
+			remaining = append(remaining, added)
+		}
+	}
+	t.groups = remaining
+	t.save()
+}
+
+// isRead はグループ内のいずれかのメッセージに既読リアクションが付いているか判定する。
+func (t *Tracker) isRead(ctx context.Context, g Group) (bool, error) {
+	var errs []error
+	for _, m := range g.Messages {
+		ok, err := t.source.HasReaction(ctx, m.ChannelID, m.MessageID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, errors.Join(errs...)
+}
+
+// markRead はグループ内の全メッセージを既読表示に編集または削除する。
+func (t *Tracker) markRead(ctx context.Context, g Group) {
+	for _, m := range g.Messages {
+		var err error
+		if t.action == config.ReadSyncDelete {
+			err = discord.DeleteWebhookMessage(ctx, m.WebhookURL, m.MessageID)
+		} else {
+			embed := m.Embed
+			embed.Title = "✓ 既読: " + embed.Title
+			embed.Color = readColor
+			embed.Image = nil
+			err = discord.EditWebhookMessage(ctx, m.WebhookURL, m.MessageID, embed)
+		}
+		if err != nil {
+			slog.Warn("既読同期に失敗", "group", g.ID, "action", t.action, "error", err)
+		}
+	}
+	slog.Info("通知の既読を同期しました", "group", g.ID, "messages", len(g.Messages), "action", t.action)
+}
+
+// save は追跡データを一時ファイル経由でアトミックに書き出す。呼び出し側でmuを保持すること。
+func (t *Tracker) save() {
+	data, err := json.MarshalIndent(t.groups, "", "  ")
+	if err != nil {
+		slog.Error("既読同期データのJSON変換に失敗", "error", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		slog.Error("既読同期データの保存先作成に失敗", "error", err)
+		return
+	}
+
+	tmp := t.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		slog.Error("既読同期データの保存に失敗", "error", err)
+		return
+	}
+	if err := os.Rename(tmp, t.path); err != nil {
+		slog.Error("既読同期データの保存に失敗", "error", err)
+	}
+}
@@ -0,0 +1,21 @@
+package server
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// overlayHTML はOBSブラウザソース用のオーバーレイページ。
+// 表示設定はクエリパラメータで指定する:
+//   - types: 表示する通知タイプ(カンマ区切り、デフォルト "online,offline")
+//   - position: top-left / top-right / bottom-left / bottom-right (デフォルト top-right)
+//   - duration: 1件あたりの表示秒数 (デフォルト 8)
+//
+//go:embed overlay.html
+var overlayHTML []byte
+
+// serveOverlay はオーバーレイページを返す。
+func serveOverlay(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(overlayHTML)
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+)
+
+// goroutineLogInterval はゴルーチン数を記録する間隔。
+const goroutineLogInterval = 5 * time.Minute
+
+// registerPprof は/debug/pprof/配下にプロファイリング用ハンドラを登録する。
+func registerPprof(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+}
+
+// watchGoroutines はゴルーチン数の推移を定期的にログ出力する。リーク調査用。
+func watchGoroutines(ctx context.Context) {
+	ticker := time.NewTicker(goroutineLogInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			slog.Info("ゴルーチン数", "count", runtime.NumGoroutine())
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Package server は監視プロセスに付随するHTTPサーバーを提供する。
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+)
+
+// DefaultPort はポート未設定時に使用するポート番号。
+const DefaultPort = 6060
+
+// StatusProvider は/statusで公開する値を返す関数。
+type StatusProvider func() any
+
+// Server はデバッグ用エンドポイント等を公開するHTTPサーバー。
+type Server struct {
+	cfg config.ServerConfig
+	mux *http.ServeMux
+	// hub はserver.overlay有効時のWebSocket配信先。無効ならnil。
+	hub *Hub
+}
+
+// New はServerインスタンスを作成する。
+func New(cfg config.ServerConfig) *Server {
+	s := &Server{cfg: cfg, mux: http.NewServeMux()}
+	if cfg.Pprof {
+		registerPprof(s.mux)
+	}
+	if cfg.Overlay {
+		s.hub = newHub()
+		s.mux.Handle("/ws", s.hub)
+		s.mux.HandleFunc("/overlay", serveOverlay)
+	}
+	return s
+}
+
+// Broadcast はWebSocketクライアントへイベントを配信する。server.overlayが無効なら何もしない。
+func (s *Server) Broadcast(v any) {
+	if s.hub == nil {
+		return
+	}
+	s.hub.Broadcast(v)
+}
+
+// Enabled はいずれかのエンドポイントが有効でサーバーを起動すべきかを返す。
+func (s *Server) Enabled() bool {
+	return s.cfg.Pprof || s.cfg.Status || s.cfg.Overlay
+}
+
+// SetStatusProvider は/statusのハンドラを登録する。server.statusが無効なら何もしない。
+func (s *Server) SetStatusProvider(provider StatusProvider) {
+	if !s.cfg.Status {
+		return
+	}
+	s.mux.HandleFunc("/status", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(provider()); err != nil {
+			slog.Warn("ステータス出力失敗", "error", err)
+		}
+	})
+}
+
+// Handle は任意のハンドラを登録する。
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// addr はバインドアドレスを返す。
+func (s *Server) addr() string {
+	port := s.cfg.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+	host := ""
+	if s.cfg.LocalhostOnly {
+		host = "127.0.0.1"
+	}
+	return fmt.Sprintf("%s:%d", host, port)
+}
+
+// Run はHTTPサーバーを起動する。ctxがキャンセルされるまで実行する。
+func (s *Server) Run(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.addr(),
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	if s.cfg.Pprof {
+		go watchGoroutines(ctx)
+	}
+	if s.hub != nil {
+		// Shutdownはハイジャック済みの接続を閉じないため個別に切断する
+		go func() {
+			<-ctx.Done()
+			s.hub.closeAll()
+		}()
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("HTTPサーバー起動", "addr", srv.Addr, "pprof", s.cfg.Pprof, "status", s.cfg.Status, "overlay", s.cfg.Overlay)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return fmt.Errorf("HTTPサーバーの起動に失敗: %w", err)
+	}
+}
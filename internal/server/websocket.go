@@ -0,0 +1,254 @@
+package server
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// websocketGUID はRFC 6455で規定されたハンドシェイク用のGUID。
+	websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	// clientBufferSize はクライアントごとの未送信メッセージの上限。超えた分は破棄する。
+	clientBufferSize = 16
+	// writeTimeout はフレーム送信のタイムアウト。
+	writeTimeout = 10 * time.Second
+	// maxClientFrameSize はクライアントから受け付けるフレームの最大サイズ。
+	maxClientFrameSize = 4096
+)
+
+// WebSocketのopcode
+const (
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xA
+)
+
+// Hub はWebSocketクライアントを管理し、イベントを一斉配信する。
+// サーバーからの送信専用の最小実装で、クライアントからのメッセージはping/close以外無視する。
+type Hub struct {
+	mu      sync.Mutex
+	clients map[*wsClient]struct{}
+}
+
+// wsClient は接続中のWebSocketクライアント。
+type wsClient struct {
+	conn net.Conn
+	send chan []byte
+	once sync.Once
+	// writeMu は送信ループとpong/close応答のフレームが混ざらないようにする。
+	writeMu sync.Mutex
+}
+
+func newHub() *Hub {
+	return &Hub{clients: make(map[*wsClient]struct{})}
+}
+
+// Broadcast はvをJSONに変換して全クライアントへ送信する。送信が詰まっているクライアント宛てのメッセージは破棄する。
+func (h *Hub) Broadcast(v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		slog.Warn("WebSocket配信データの変換に失敗", "error", err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		select {
+		case c.send <- data:
+		default:
+			slog.Warn("WebSocketクライアントの送信バッファが満杯のため破棄", "remote", c.conn.RemoteAddr().String())
+		}
+	}
+}
+
+// closeAll は全クライアントを切断する。
+func (h *Hub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		c.close()
+		delete(h.clients, c)
+	}
+}
+
+func (h *Hub) remove(c *wsClient) {
+	h.mu.Lock()
+	delete(h.clients, c)
+	h.mu.Unlock()
+	c.close()
+}
+
+func (c *wsClient) close() {
+	c.once.Do(func() {
+		close(c.send)
+		_ = c.conn.Close()
+	})
+}
+
+// ServeHTTP はWebSocketハンドシェイクを行い、クライアントを登録する。
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") ||
+		!headerContains(r.Header.Get("Connection"), "upgrade") || key == "" {
+		http.Error(w, "WebSocket接続のみ受け付けます", http.StatusBadRequest)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "WebSocketに対応していません", http.StatusInternalServerError)
+		return
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		slog.Warn("WebSocket接続の確立に失敗", "error", err)
+		return
+	}
+
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	accept := base64.StdEncoding.EncodeToString(sum[:])
+	_, err = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + accept + "\r\n\r\n")
+	if err == nil {
+		err = rw.Flush()
+	}
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+
+	c := &wsClient{conn: conn, send: make(chan []byte, clientBufferSize)}
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+	slog.Debug("WebSocketクライアント接続", "remote", conn.RemoteAddr().String())
+
+	go c.writeLoop()
+	c.readLoop(rw.Reader)
+	h.remove(c)
+	slog.Debug("WebSocketクライアント切断", "remote", conn.RemoteAddr().String())
+}
+
+// writeLoop は送信キューのメッセージをテキストフレームで送る。
+func (c *wsClient) writeLoop() {
+	for data := range c.send {
+		if err := c.writeFrame(opText, data); err != nil {
+			_ = c.conn.Close()
+			return
+		}
+	}
+}
+
+// readLoop は切断されるまでクライアントのフレームを読み捨てる。pingにはpongを返す。
+func (c *wsClient) readLoop(r *bufio.Reader) {
+	for {
+		op, payload, err := readFrame(r)
+		if err != nil {
+			return
+		}
+		switch op {
+		case opClose:
+			_ = c.writeFrame(opClose, nil)
+			return
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeFrame は単一フレーム(FIN付き、マスクなし)を送信する。
+func (c *wsClient) writeFrame(op byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	header := []byte{0x80 | op}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	_ = c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// readFrame はクライアントからのフレームを1つ読み込み、マスクを解除して返す。
+func readFrame(r *bufio.Reader) (op byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	op = head[0] & 0x0F
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > maxClientFrameSize {
+		return 0, nil, errors.New("WebSocketフレームが大きすぎます")
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(r, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return op, payload, nil
+}
+
+// headerContains はカンマ区切りのヘッダー値にtokenが含まれるか判定する。
+func headerContains(value, token string) bool {
+	for _, v := range strings.Split(value, ",") {
+		if strings.EqualFold(strings.TrimSpace(v), token) {
+			return true
+		}
+	}
+	return false
+}
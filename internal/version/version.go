@@ -0,0 +1,16 @@
+// Package version はビルド情報を提供する。値はビルド時に-ldflagsで注入される。
+package version
+
+import "fmt"
+
+// ビルド時に -ldflags "-X github.com/yuu1111/StreamNotifier/internal/version.Version=..." で上書きする。
+var (
+	Version = "dev"
+	Commit  = "dev"
+	Date    = "dev"
+)
+
+// String はバージョン情報を1行の文字列で返す。
+func String() string {
+	return fmt.Sprintf("%s (commit: %s, built: %s)", Version, Commit, Date)
+}
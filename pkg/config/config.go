@@ -0,0 +1,1392 @@
+// Package config はアプリケーション設定の読み込み・保存・バリデーションを提供する。
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChangeType は通知タイプを表す。
+type ChangeType = string
+
+const (
+	ChangeOnline       ChangeType = "online"
+	ChangeOffline      ChangeType = "offline"
+	ChangeTitleChange  ChangeType = "titleChange"
+	ChangeGameChange   ChangeType = "gameChange"
+	ChangeTitleAndGame ChangeType = "titleAndGameChange"
+	// ChangeScheduledReminder は配信スケジュールの開始前リマインダー。
+	ChangeScheduledReminder ChangeType = "scheduledReminder"
+	// ChangeProfileUpdate は表示名・プロフィール画像の変更。
+	ChangeProfileUpdate ChangeType = "profileUpdate"
+	// ChangeReconnect は配信終了から短時間で配信が再開したこと。配信終了・配信開始の代わりに通知する。
+	ChangeReconnect ChangeType = "reconnect"
+	// ChangeStillLive は長時間の配信で一定時間ごとに送る配信中の再告知。
+	ChangeStillLive ChangeType = "stillLive"
+	// ChangeVodPublished は配信終了後にアーカイブ(VOD)が公開されたこと。配信終了の通知とは別に送る。
+	ChangeVodPublished ChangeType = "vodPublished"
+)
+
+// Platform は配信プラットフォームを表す。
+type Platform = string
+
+const (
+	PlatformTwitch  Platform = "twitch"
+	PlatformYouTube Platform = "youtube"
+)
+
+// WebhookType は通知先の種別を表す。
+type WebhookType = string
+
+const (
+	WebhookDiscord WebhookType = "discord"
+	WebhookGeneric WebhookType = "generic"
+)
+
+// Theme はEmbed色の最適化対象とするDiscordの表示モードを表す。
+type Theme = string
+
+const (
+	ThemeDark  Theme = "dark"
+	ThemeLight Theme = "light"
+)
+
+// Language はEmbedの表示言語を表す。
+type Language = string
+
+const (
+	LanguageJa Language = "ja"
+	LanguageEn Language = "en"
+)
+
+// OnlineField は配信開始Embedに表示する項目を表す。
+type OnlineField = string
+
+const (
+	OnlineFieldGame      OnlineField = "game"
+	OnlineFieldStartTime OnlineField = "startTime"
+	OnlineFieldViewers   OnlineField = "viewers"
+	OnlineFieldLanguage  OnlineField = "language"
+	OnlineFieldThumbnail OnlineField = "thumbnail"
+	// OnlineFieldFollowers はフォロワー数。取得にtwitch.userAccessTokenが必要。
+	OnlineFieldFollowers OnlineField = "followers"
+)
+
+// DefaultOnlineFields はonlineFields省略時に配信開始Embedに表示する項目。
+var DefaultOnlineFields = []OnlineField{OnlineFieldGame, OnlineFieldStartTime, OnlineFieldThumbnail}
+
+// DefaultOnlineFieldOrder はonlineFieldOrder省略時の配信開始Embedのフィールドの並び順。thumbnailは画像のため含まない。
+var DefaultOnlineFieldOrder = []OnlineField{OnlineFieldGame, OnlineFieldStartTime, OnlineFieldViewers, OnlineFieldLanguage, OnlineFieldFollowers}
+
+// OnlineFieldOrder は配信開始Embedのフィールドの並び順を返す。orderに指定した項目を先に並べ、
+// 指定しなかった項目はDefaultOnlineFieldOrderの順で後ろに続ける。
+func OnlineFieldOrder(order []OnlineField) []OnlineField {
+	result := slices.Clone(order)
+	for _, f := range DefaultOnlineFieldOrder {
+		if !slices.Contains(result, f) {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// ReadSyncAction は既読同期時に同じ通知の他メッセージへ行う操作を表す。
+type ReadSyncAction = string
+
+const (
+	ReadSyncEdit   ReadSyncAction = "edit"
+	ReadSyncDelete ReadSyncAction = "delete"
+)
+
+// VodFallback はVOD待ちの配信終了通知がタイムアウトした場合の扱いを表す。
+type VodFallback = string
+
+const (
+	VodFallbackSend VodFallback = "send"
+	VodFallbackDrop VodFallback = "drop"
+)
+
+// BrokerType はメッセージブローカーの種別を表す。
+type BrokerType = string
+
+const (
+	BrokerNATS      BrokerType = "nats"
+	BrokerKafkaREST BrokerType = "kafka-rest"
+)
+
+// TwitchMode はTwitch APIの接続先を表す。
+type TwitchMode = string
+
+const (
+	TwitchModeLive TwitchMode = "live"
+	// TwitchModeMock はTwitchの代わりにフィクスチャファイルの配信者の状態を返す。ローカルでの動作確認用。
+	TwitchModeMock TwitchMode = "mock"
+)
+
+// LogLevel はログ出力レベルを表す。
+type LogLevel = string
+
+const (
+	LogDebug LogLevel = "debug"
+	LogInfo  LogLevel = "info"
+	LogWarn  LogLevel = "warn"
+	LogError LogLevel = "error"
+)
+
+const (
+	// WebhookURLPrefix はDiscord Webhook URLの必須プレフィックス。
+	WebhookURLPrefix = "https://discord.com/api/webhooks/"
+
+	// ThumbnailWidth はサムネイル画像の幅。
+	ThumbnailWidth = "440"
+
+	// ThumbnailHeight はサムネイル画像の高さ。
+	ThumbnailHeight = "248"
+
+	// DefaultRetryQueuePath はリトライキューのデフォルト保存先。
+	DefaultRetryQueuePath = "./data/retry-queue.json"
+
+	// DefaultRetryQueueMaxAgeMinutes はリトライキューのデフォルト保持時間(分)。
+	DefaultRetryQueueMaxAgeMinutes = 60
+
+	// DefaultDeadLetterPath は送信できなかった通知を書き出すデッドレターファイルのデフォルトの場所。
+	DefaultDeadLetterPath = "./data/dead-letter.jsonl"
+
+	// DefaultDeadLetterMaxEntries はデッドレターファイルに残すデフォルトの最大件数。
+	DefaultDeadLetterMaxEntries = 1000
+
+	// DefaultHistoryPath は配信履歴のデフォルト保存先。
+	DefaultHistoryPath = "./data/history.json"
+
+	// DefaultSingletonLockPath は多重起動防止のロックファイルのデフォルトの場所。
+	DefaultSingletonLockPath = "./data/stream-notifier.lock"
+
+	// DefaultReadSyncPath は既読同期の追跡データのデフォルト保存先。
+	DefaultReadSyncPath = "./data/read-sync.json"
+
+	// DefaultAuditLogPath は通知監査ログのデフォルトの書き出し先。
+	DefaultAuditLogPath = "./logs/audit.jsonl"
+
+	// DefaultHealthPath は配信者ごとのヘルス状態の書き出し先。infoコマンドが参照する。
+	DefaultHealthPath = "./data/health.json"
+
+	// DefaultLifetimeStatsPath は配信者ごとの累計の配信記録の保存先。list・infoコマンドが参照する。
+	DefaultLifetimeStatsPath = "./data/lifetime.json"
+
+	// DefaultStreamStartsPath は配信中の配信者の開始時刻の保存先。再起動後の配信時間の計算に使う。
+	DefaultStreamStartsPath = "./data/stream-starts.json"
+
+	// DefaultTwitchMockFile はtwitch.mode: "mock"で読み込むフィクスチャファイルのデフォルトの場所。
+	DefaultTwitchMockFile = "./data/twitch-mock.json"
+
+	// DefaultScheduleReminderMinutes はスケジュールリマインダーのデフォルト通知タイミング(開始何分前か)。
+	DefaultScheduleReminderMinutes = 15
+
+	// DefaultAdaptiveBackoffFactor はpolling.adaptiveでオフラインが続いた際に間隔を伸ばすデフォルトの倍率。
+	DefaultAdaptiveBackoffFactor = 2.0
+
+	// DefaultVodWaitMinutes はofflineRequireVodのWebhookやアーカイブ公開の通知でVODを待つデフォルトの最大時間(分)。
+	DefaultVodWaitMinutes = 30
+
+	// DefaultDeadWebhookFailures はWebhookへの送信を停止するまでの404/401のデフォルトの連続回数。
+	DefaultDeadWebhookFailures = 3
+
+	// DefaultSendConcurrency はWebhookグループの複数URLへ同時に送信するデフォルトの最大数。
+	DefaultSendConcurrency = 5
+)
+
+// NotificationSettings は通知種別ごとの有効/無効設定。
+type NotificationSettings struct {
+	Online      bool `json:"online"`
+	Offline     bool `json:"offline"`
+	TitleChange bool `json:"titleChange"`
+	GameChange  bool `json:"gameChange"`
+	// Schedule は配信スケジュールの開始前リマインダーを送るか。
+	Schedule bool `json:"schedule"`
+	// ProfileUpdate は表示名・プロフィール画像の変更を通知するか。
+	ProfileUpdate bool `json:"profileUpdate"`
+	// VodPublished は配信終了後にアーカイブが公開されたら通知するか。
+	VodPublished bool `json:"vodPublished,omitempty"`
+}
+
+// WebhookConfig はWebhook設定(URLと通知設定)。
+type WebhookConfig struct {
+	// Type は通知先の種別。省略時はdiscord。
+	Type          WebhookType          `json:"type,omitempty"`
+	Name          string               `json:"name,omitempty"`
+	URL           string               `json:"url,omitempty"`
+	Notifications NotificationSettings `json:"notifications"`
+	// URLs は同じ通知設定を共有する追加の送信先。指定するとWebhookグループとして全URLにfan-outする。
+	URLs []string `json:"urls,omitempty"`
+	// Template はgeneric用のJSONボディテンプレート(text/template)。DetectedChangeが渡される。
+	Template string `json:"template,omitempty"`
+	// Headers はgeneric用の追加HTTPヘッダー。
+	Headers map[string]string `json:"headers,omitempty"`
+	// Tags は送信先を絞り込むための自由なタグ。
+	Tags []string `json:"tags,omitempty"`
+	// Color はEmbedのアクセントカラー(#RRGGBB)。配信者のColorより優先する。
+	Color string `json:"color,omitempty"`
+	// MinViewersForOnline は配信開始通知を送る視聴者数の下限。達するまで通知を保留する。配信者の設定より優先する。
+	// 保留中は以降のポーリングで視聴者数を確認し、配信中に下限に達した時点で一度だけ送る。
+	// notifications.minUptimeSecondsと併用した場合は、最低配信時間を満たした後の視聴者数で判定する。
+	MinViewersForOnline int `json:"minViewersForOnline,omitempty"`
+	// MinViewers はMinViewersForOnlineの別名。両方を指定する場合は同じ値にする。
+	MinViewers int `json:"minViewers,omitempty"`
+	// DropOnlineIfEnded は保留中のまま配信が終わった場合に配信開始・終了通知を送らないか。
+	// falseなら配信終了時に保留していた配信開始通知を送る。
+	DropOnlineIfEnded bool `json:"dropOnlineIfEnded,omitempty"`
+	// OfflineRequireVod はVODのリンクが取得できるまで配信終了通知を保留するか。
+	OfflineRequireVod bool `json:"offlineRequireVod,omitempty"`
+	// OfflineVodFallback はVODが待機時間内に見つからなかった場合の扱い("send": リンクなしで送る/"drop": 送らない)。省略時はsend。
+	OfflineVodFallback VodFallback `json:"offlineVodFallback,omitempty"`
+	// GameFilter は配信開始・ゲーム変更を通知するゲーム(名前またはID)。空なら全ゲームを通知する。
+	GameFilter []string `json:"gameFilter,omitempty"`
+	// Identity はDiscordに投稿する際の名前・アイコンの上書き。省略時は配信者の表示名・プロフィール画像を使う。
+	Identity WebhookIdentity `json:"identity,omitzero"`
+	// IdentityByType は通知タイプ別の名前・アイコンの上書き。未指定の項目はIdentityの値を使う。
+	IdentityByType map[ChangeType]WebhookIdentity `json:"identityByType,omitempty"`
+	// Language はEmbedの表示言語("ja"/"en")。省略時はja。
+	Language Language `json:"language,omitempty"`
+}
+
+// WebhookIdentity はDiscord Webhookの投稿者名・アイコンの上書き。空の項目は上書きしない。
+type WebhookIdentity struct {
+	Username  string `json:"username,omitempty"`
+	AvatarURL string `json:"avatarUrl,omitempty"`
+}
+
+// IdentityFor は通知タイプに応じた投稿者の上書きを返す。IdentityByType、Identityの順に項目ごとに優先する。
+func (w WebhookConfig) IdentityFor(changeType ChangeType) WebhookIdentity {
+	identity := w.Identity
+	if byType, ok := w.IdentityByType[changeType]; ok {
+		if byType.Username != "" {
+			identity.Username = byType.Username
+		}
+		if byType.AvatarURL != "" {
+			identity.AvatarURL = byType.AvatarURL
+		}
+	}
+	return identity
+}
+
+// gameFilteredTypes はGameFilterを適用する通知タイプ。
+var gameFilteredTypes = map[ChangeType]bool{
+	ChangeOnline:       true,
+	ChangeReconnect:    true,
+	ChangeStillLive:    true,
+	ChangeGameChange:   true,
+	ChangeTitleAndGame: true,
+}
+
+// AllowsGame はGameFilterに照らして通知してよいかを返す。名前は大文字小文字を区別せず、IDは完全一致で比較する。
+// GameFilter対象外の通知タイプは常にtrue。
+func (w WebhookConfig) AllowsGame(changeType ChangeType, gameID, gameName string) bool {
+	if len(w.GameFilter) == 0 || !gameFilteredTypes[changeType] {
+		return true
+	}
+	for _, g := range w.GameFilter {
+		if (gameID != "" && g == gameID) || (gameName != "" && strings.EqualFold(g, gameName)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Targets はURLとURLsを合わせた送信先の一覧を返す。
+func (w WebhookConfig) Targets() []string {
+	targets := make([]string, 0, len(w.URLs)+1)
+	if w.URL != "" {
+		targets = append(targets, w.URL)
+	}
+	return append(targets, w.URLs...)
+}
+
+// IsGroup は複数の送信先を持つWebhookグループかを返す。
+func (w WebhookConfig) IsGroup() bool {
+	return len(w.Targets()) > 1
+}
+
+// HasAnyTag はWebhookがtagsのいずれかを持つか判定する。tagsが空なら常にtrue。
+func (w WebhookConfig) HasAnyTag(tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	for _, want := range tags {
+		if slices.Contains(w.Tags, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTags はカンマ区切りのタグ指定をスライスに変換する。空の要素は除く。
+func ParseTags(s string) []string {
+	var tags []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// StreamerConfig は配信者ごとの設定。
+type StreamerConfig struct {
+	Username string          `json:"username"`
+	Webhooks []WebhookConfig `json:"webhooks"`
+	// Color はEmbedのアクセントカラー(#RRGGBB)。配信開始・タイトル/ゲーム変更時に使用する。
+	Color string `json:"color,omitempty"`
+	// IntervalSeconds はこの配信者のポーリング間隔。0ならpolling設定(自動調整含む)に従う。
+	IntervalSeconds int `json:"intervalSeconds,omitempty"`
+	// MinViewersForOnline は全Webhook共通の配信開始通知を送る視聴者数の下限。0で無効。
+	MinViewersForOnline int `json:"minViewersForOnline,omitempty"`
+	// Routes は通知の種類ごとの送信先。WithRoutesが返すコピーでのみ参照したWebhookのnotificationsを置き換え、設定ファイルには書き戻さない。
+	Routes []RouteConfig `json:"routes,omitempty"`
+}
+
+// OnlineViewerThreshold はWebhook→配信者の順で設定された配信開始通知の視聴者数の下限を返す。0なら制限なし。
+func (s StreamerConfig) OnlineViewerThreshold(w WebhookConfig) int {
+	if w.MinViewersForOnline > 0 {
+		return w.MinViewersForOnline
+	}
+	if w.MinViewers > 0 {
+		return w.MinViewers
+	}
+	return s.MinViewersForOnline
+}
+
+// EmbedColor はWebhook→配信者の順で設定されたアクセントカラーを返す。未設定ならokはfalse。
+func (s StreamerConfig) EmbedColor(w WebhookConfig) (color int, ok bool) {
+	hex := w.Color
+	if hex == "" {
+		hex = s.Color
+	}
+	if hex == "" {
+		return 0, false
+	}
+	color, err := ParseHexColor(hex)
+	if err != nil {
+		return 0, false
+	}
+	return color, true
+}
+
+// TwitchConfig はTwitch API認証設定。
+type TwitchConfig struct {
+	ClientID     string `json:"clientId"`
+	ClientSecret string `json:"clientSecret"`
+	// ClientIDFile, ClientSecretFile はDocker/Kubernetesのsecret等、値を書いたファイルのパス。
+	// 指定時はインラインの値より優先する。
+	ClientIDFile     string `json:"clientIdFile,omitempty"`
+	ClientSecretFile string `json:"clientSecretFile,omitempty"`
+	// UserAccessToken はフォロワー数の取得に使うユーザーアクセストークン。未設定ならフォロワー数を表示しない。
+	UserAccessToken string `json:"userAccessToken,omitempty"`
+	// UserAccessTokenFile はユーザーアクセストークンを書いたファイルのパス。指定時はインラインの値より優先する。
+	UserAccessTokenFile string `json:"userAccessTokenFile,omitempty"`
+	// RequestsPerMinute は全Helix APIリクエストで共有する1分あたりの上限。省略時はTwitchの上限の800。
+	RequestsPerMinute int `json:"requestsPerMinute,omitempty"`
+	// BatchSize は1リクエストで指定する配信者数。省略時はHelixの上限の100。
+	// 長いURLを扱えないプロキシを経由する場合に小さくする。
+	BatchSize int `json:"batchSize,omitempty"`
+	// StartupRetrySeconds は起動時のトークン取得・ユーザー情報取得が一時的なエラーで失敗したときに再試行を続ける秒数。
+	// 0(省略時)なら再試行せずに終了する。認証情報の誤りは再試行しない。
+	StartupRetrySeconds int `json:"startupRetrySeconds,omitempty"`
+	// Mode はliveならTwitch APIへ接続し(省略時)、mockならMockFileのフィクスチャを配信者の状態として使う。
+	// mockではclientId・clientSecretは不要。
+	Mode TwitchMode `json:"mode,omitempty"`
+	// MockFile はmockで読み込むフィクスチャファイルのパス。省略時はDefaultTwitchMockFile。
+	MockFile string `json:"mockFile,omitempty"`
+
+	// inlineClientID, inlineClientSecret はファイルから読み込む前の値。
+	// Save時にファイルの内容を設定ファイルへ書き出さないよう保持する。
+	inlineClientID        string
+	inlineClientSecret    string
+	inlineUserAccessToken string
+}
+
+// IsMock はTwitch APIの代わりにフィクスチャファイルを使うかを返す。
+func (t TwitchConfig) IsMock() bool {
+	return t.Mode == TwitchModeMock
+}
+
+// MockFilePath はmockで読み込むフィクスチャファイルのパスを返す。
+func (t TwitchConfig) MockFilePath() string {
+	if t.MockFile != "" {
+		return t.MockFile
+	}
+	return DefaultTwitchMockFile
+}
+
+// resolveFiles はファイル指定のある認証情報をファイルから読み込む。前後の空白は取り除く。
+func (t *TwitchConfig) resolveFiles() error {
+	t.inlineClientID = t.ClientID
+	t.inlineClientSecret = t.ClientSecret
+	t.inlineUserAccessToken = t.UserAccessToken
+	if t.ClientIDFile != "" {
+		v, err := readSecretFile(t.ClientIDFile)
+		if err != nil {
+			return fmt.Errorf("twitch.clientIdFileの読み込みに失敗: %w", err)
+		}
+		t.ClientID = v
+	}
+	if t.ClientSecretFile != "" {
+		v, err := readSecretFile(t.ClientSecretFile)
+		if err != nil {
+			return fmt.Errorf("twitch.clientSecretFileの読み込みに失敗: %w", err)
+		}
+		t.ClientSecret = v
+	}
+	if t.UserAccessTokenFile != "" {
+		v, err := readSecretFile(t.UserAccessTokenFile)
+		if err != nil {
+			return fmt.Errorf("twitch.userAccessTokenFileの読み込みに失敗: %w", err)
+		}
+		t.UserAccessToken = v
+	}
+	return nil
+}
+
+// forSave はファイルから読み込んだ値をインラインの値に戻したコピーを返す。
+func (t TwitchConfig) forSave() TwitchConfig {
+	if t.ClientIDFile != "" {
+		t.ClientID = t.inlineClientID
+	}
+	if t.ClientSecretFile != "" {
+		t.ClientSecret = t.inlineClientSecret
+	}
+	if t.UserAccessTokenFile != "" {
+		t.UserAccessToken = t.inlineUserAccessToken
+	}
+	return t
+}
+
+// readSecretFile はsecretファイルを読み込み、前後の空白と改行を取り除いて返す。
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// PollingConfig はポーリング間隔設定。
+type PollingConfig struct {
+	IntervalSeconds int `json:"intervalSeconds"`
+	// LatencyWarnSeconds は配信開始から検知までの遅延がこの秒数を超えたら警告する。0で無効。
+	LatencyWarnSeconds int `json:"latencyWarnSeconds,omitempty"`
+	// OfflineGraceSeconds は配信終了とみなすまでにオフラインが続く必要がある秒数。
+	// /streamsが一時的に配信を返さない揺らぎで配信終了を通知しないようにする。0で無効。
+	OfflineGraceSeconds int `json:"offlineGraceSeconds,omitempty"`
+	// FetchOfflineChannelInfo は配信外の配信者のタイトル・ゲームを/channelsから取得するか。省略時は有効。
+	// 無効にすると配信外の状態はタイトル・ゲームを持たず、配信外のタイトル/ゲーム変更は通知しない。APIリクエストを減らせる。
+	FetchOfflineChannelInfo *bool `json:"fetchOfflineChannelInfo,omitempty"`
+	// Auto は配信履歴の配信頻度から配信者ごとのポーリング間隔を自動調整する設定。
+	Auto AutoIntervalConfig `json:"auto"`
+	// Adaptive は配信中かどうかで配信者ごとのポーリング間隔を調整する設定。
+	Adaptive AdaptiveIntervalConfig `json:"adaptive,omitzero"`
+}
+
+// OfflineChannelInfoEnabled は配信外の配信者のチャンネル情報を取得するかを返す。
+func (p PollingConfig) OfflineChannelInfoEnabled() bool {
+	return p.FetchOfflineChannelInfo == nil || *p.FetchOfflineChannelInfo
+}
+
+// AdaptiveIntervalConfig は配信状態に応じたポーリング間隔の調整設定。
+// 配信中はLiveSecondsで短い間隔でポーリングし、オフラインのポーリングが続くたびに間隔をBackoffFactor倍してMaxSecondsまで伸ばす。
+// 配信者個別のintervalSecondsを指定した配信者には適用しない。
+type AdaptiveIntervalConfig struct {
+	Enabled bool `json:"enabled"`
+	// LiveSeconds は配信中のポーリング間隔。省略時は通常の間隔のまま。
+	LiveSeconds int `json:"liveSeconds,omitempty"`
+	// MaxSeconds はオフライン時に伸ばす間隔の上限。
+	MaxSeconds int `json:"maxSeconds,omitempty"`
+	// BackoffFactor はオフラインのポーリングごとに間隔を伸ばす倍率。省略時は2。
+	BackoffFactor float64 `json:"backoffFactor,omitempty"`
+}
+
+// Backoff は間隔を伸ばす倍率を返す。
+func (a AdaptiveIntervalConfig) Backoff() float64 {
+	if a.BackoffFactor == 0 {
+		return DefaultAdaptiveBackoffFactor
+	}
+	return a.BackoffFactor
+}
+
+// AutoIntervalConfig はポーリング間隔の自動調整設定。
+// 頻繁に配信する配信者ほどMinSeconds、配信の少ない配信者ほどMaxSecondsに近づける。
+type AutoIntervalConfig struct {
+	Enabled    bool `json:"enabled"`
+	MinSeconds int  `json:"minSeconds,omitempty"`
+	MaxSeconds int  `json:"maxSeconds,omitempty"`
+}
+
+// PlatformStyle はプラットフォーム別の表示設定。空の項目はデフォルト値を使う。
+type PlatformStyle struct {
+	Emoji   string `json:"emoji,omitempty"`
+	IconURL string `json:"iconUrl,omitempty"`
+	Color   string `json:"color,omitempty"`
+}
+
+// NotificationConfig は全Webhook共通の通知挙動設定。
+type NotificationConfig struct {
+	// TitleDebounceSeconds はタイトル変更を保留する秒数。保留中に再変更がなければ最終タイトルで通知する。0で無効。
+	TitleDebounceSeconds int `json:"titleDebounceSeconds"`
+	// ShowPlatform はEmbedにプラットフォームの絵文字/アイコンを表示するか。Twitch単独運用なら不要。
+	ShowPlatform bool `json:"showPlatform"`
+	// Platforms はプラットフォーム別の表示設定の上書き。
+	Platforms map[Platform]PlatformStyle `json:"platforms,omitempty"`
+	// ExtractLinks はタイトル内のURLを「関連リンク」フィールドに抽出するか。
+	ExtractLinks bool `json:"extractLinks"`
+	// StripLinks はExtractLinks有効時に本文からURLを取り除くか。
+	StripLinks bool `json:"stripLinks"`
+	// ScheduleReminderMinutes はスケジュールリマインダーを配信予定の何分前に送るか。省略時は15分。
+	ScheduleReminderMinutes int `json:"scheduleReminderMinutes,omitempty"`
+	// Theme は通知タイプ別のデフォルト色をどちらの表示モード向けにするか("dark"/"light")。省略時はdark。
+	// 配信者/Webhook/プラットフォーム別のカスタム色はこの設定より優先される。
+	Theme Theme `json:"theme,omitempty"`
+	// CategoryColors はゲーム(カテゴリ)名から色(#RRGGBB)への対応。配信開始・ゲーム変更時の現在のゲームが一致すれば
+	// 通知タイプ別の色や配信者/Webhook別の色の代わりに使う。ゲーム名は大文字小文字を区別しない。
+	CategoryColors map[string]string `json:"categoryColors,omitempty"`
+	// VodWaitMinutes はofflineRequireVodのWebhookやアーカイブ公開の通知でVODを待つ最大時間(分)。省略時は30分。
+	VodWaitMinutes int `json:"vodWaitMinutes,omitempty"`
+	// DeadWebhookFailures はWebhookが削除・無効化された(404/401)と判断して送信を停止するまでの連続失敗回数。省略時は3回。
+	// 停止は再起動まで有効で、設定ファイルは変更しない。
+	DeadWebhookFailures int `json:"deadWebhookFailures,omitempty"`
+	// SendConcurrency はWebhookグループの複数URLへ同時に送信する最大数。省略時は5。
+	// DiscordのIPごとの制限に掛からないよう、URLの多いグループでも同時リクエスト数を抑える。
+	SendConcurrency int `json:"sendConcurrency,omitempty"`
+	// MinUptimeSeconds は配信開始を通知するまでに必要な配信継続秒数。満たす前に終わった配信は開始・終了とも通知しない。0で無効。
+	MinUptimeSeconds int `json:"minUptimeSeconds,omitempty"`
+	// IgnoreCosmeticTitleChanges は空白(前後・連続)や大文字小文字の違いのみのタイトル変更を通知しないか。
+	IgnoreCosmeticTitleChanges bool `json:"ignoreCosmeticTitleChanges,omitempty"`
+	// CombineWindowSeconds は別々のポーリングで検出したタイトル変更とゲーム変更を1つの通知に統合する待ち時間(秒)。0で無効。
+	CombineWindowSeconds int `json:"combineWindowSeconds,omitempty"`
+	// ReconnectWindowSeconds は配信終了の通知を保留する秒数。この間に配信が再開すれば配信終了・配信開始の代わりに
+	// 再接続(reconnect)として通知する。0で無効。
+	ReconnectWindowSeconds int `json:"reconnectWindowSeconds,omitempty"`
+	// StillLiveIntervalHours は配信が続いている間、配信開始(または前回の再告知)からこの時間ごとに
+	// 配信中の再告知(stillLive)を送る。0で無効。
+	StillLiveIntervalHours int `json:"stillLiveIntervalHours,omitempty"`
+	// OnlineFields は配信開始Embedに表示する項目(game/startTime/viewers/language/thumbnail/followers)。省略時はDefaultOnlineFields。
+	// 表示順は指定順によらずOnlineFieldOrderに従う。
+	OnlineFields []OnlineField `json:"onlineFields,omitempty"`
+	// OnlineFieldOrder は配信開始Embedのフィールドの並び順(game/startTime/viewers/language/followers)。
+	// 指定しなかった項目は既定の順で後ろに続く。省略時はDefaultOnlineFieldOrder。
+	OnlineFieldOrder []OnlineField `json:"onlineFieldOrder,omitempty"`
+	// FooterText は全Embedのフッターに付ける固定テキスト(例: "Powered by MyServer")。
+	// 経過時間などの動的なフッターがある場合は「 • 」で連結する。
+	FooterText string `json:"footerText,omitempty"`
+	// FooterIconURL はフッターに表示するアイコン画像のURL。
+	FooterIconURL string `json:"footerIconUrl,omitempty"`
+	// ThumbnailCacheBust は配信プレビュー画像のURLに取得時刻を付け、Discordに古い画像をキャッシュから表示させないようにするか。
+	ThumbnailCacheBust bool `json:"thumbnailCacheBust,omitempty"`
+	// ShowStreamID は配信開始・終了などのEmbedのフッターにTwitchの配信IDを表示するか。通知の対応付けの確認用。
+	ShowStreamID bool `json:"showStreamId,omitempty"`
+	// IncludeContent はEmbedに加えて、イベントの要約をプレーンテキストで本文(content)にも入れるか。
+	// スクリーンリーダーやモバイルのプッシュ通知のプレビューで内容が分かるようにする。
+	IncludeContent bool `json:"includeContent,omitempty"`
+}
+
+// ServerConfig は監視プロセスのHTTPサーバー設定。
+type ServerConfig struct {
+	Port          int  `json:"port"`
+	LocalhostOnly bool `json:"localhostOnly"`
+	// Pprof は/debug/pprof/の公開有無。セキュリティのためデフォルト無効。
+	Pprof bool `json:"pprof"`
+	// Status は/statusで実行統計をJSON公開するか。
+	Status bool `json:"status"`
+	// Overlay はOBSオーバーレイ(/overlay)と通知イベントのWebSocket配信(/ws)を有効にするか。
+	Overlay bool `json:"overlay"`
+}
+
+// RetryQueueConfig はWebhook送信失敗時のリトライキュー設定。
+type RetryQueueConfig struct {
+	Enabled bool `json:"enabled"`
+	// Path はキューの保存先。省略時はDefaultRetryQueuePath。
+	Path string `json:"path,omitempty"`
+	// MaxAgeMinutes はこの時間を超えて送信できなかった通知を破棄する。省略時は60分。
+	MaxAgeMinutes int `json:"maxAgeMinutes,omitempty"`
+}
+
+// DeadLetterConfig は最終的に送信できなかったWebhook送信の書き出し設定。
+// 書き出した通知はreplay-dlqコマンドで再送できる。
+type DeadLetterConfig struct {
+	Enabled bool `json:"enabled"`
+	// Path は書き出し先(JSON Lines)。省略時はDefaultDeadLetterPath。
+	Path string `json:"path,omitempty"`
+	// MaxEntries はファイルに残す最大件数。超えたら古いものから削除する。省略時はDefaultDeadLetterMaxEntries。
+	MaxEntries int `json:"maxEntries,omitempty"`
+}
+
+// DeadLetterPath はデッドレターファイルの場所を返す。
+func (d DeadLetterConfig) DeadLetterPath() string {
+	if d.Path != "" {
+		return d.Path
+	}
+	return DefaultDeadLetterPath
+}
+
+// MaxDeadLetters はデッドレターファイルに残す最大件数を返す。
+func (d DeadLetterConfig) MaxDeadLetters() int {
+	if d.MaxEntries > 0 {
+		return d.MaxEntries
+	}
+	return DefaultDeadLetterMaxEntries
+}
+
+// HistoryConfig は配信履歴(配信予測に使用)の記録設定。
+type HistoryConfig struct {
+	Enabled bool `json:"enabled"`
+	// Path は履歴の保存先。省略時はDefaultHistoryPath。
+	Path string `json:"path,omitempty"`
+}
+
+// StateConfig は監視プロセスが書き出す状態ファイルの保存先。省略した項目はdata/以下の既定のファイルを使い、
+// プロファイルを選択していればファイル名にプロファイル名を付ける。
+type StateConfig struct {
+	// HealthPath は配信者ごとのヘルス状態の書き出し先。省略時はDefaultHealthPath。
+	HealthPath string `json:"healthPath,omitempty"`
+	// LifetimeStatsPath は配信者ごとの累計の配信記録の保存先。省略時はDefaultLifetimeStatsPath。
+	LifetimeStatsPath string `json:"lifetimeStatsPath,omitempty"`
+	// StreamStartsPath は配信中の配信者の開始時刻の保存先。省略時はDefaultStreamStartsPath。
+	StreamStartsPath string `json:"streamStartsPath,omitempty"`
+}
+
+// ReadSyncConfig は同一通知の既読同期設定。
+// 複数のWebhookに送った同じ通知のどれかに既読リアクションが付いたら、残りを既読表示に編集または削除する。
+type ReadSyncConfig struct {
+	Enabled bool `json:"enabled"`
+	// BotToken はリアクション取得に使うDiscord Botのトークン。Botは対象チャンネルの閲覧権限が必要。
+	BotToken string `json:"botToken,omitempty"`
+	// Emoji は既読とみなすリアクション。省略時は"✅"。
+	Emoji string `json:"emoji,omitempty"`
+	// Action は既読時の操作("edit"/"delete")。省略時はedit。
+	Action ReadSyncAction `json:"action,omitempty"`
+	// PollSeconds はリアクションを確認する間隔。省略時は30秒。
+	PollSeconds int `json:"pollSeconds,omitempty"`
+	// MaxAgeHours はこの時間を過ぎた通知の追跡をやめる。省略時は24時間。
+	MaxAgeHours int `json:"maxAgeHours,omitempty"`
+	// Path は追跡データの保存先。省略時はDefaultReadSyncPath。
+	Path string `json:"path,omitempty"`
+}
+
+// CircuitBreakerConfig は全体の通知数上限(暴走防止)の設定。
+type CircuitBreakerConfig struct {
+	// MaxNotifications はWindowSeconds秒あたりの総通知数の上限。0で無効。
+	MaxNotifications int `json:"maxNotifications"`
+	WindowSeconds    int `json:"windowSeconds"`
+	// PauseSeconds は上限超過時に全通知を停止する秒数。
+	PauseSeconds int `json:"pauseSeconds"`
+	// AlertWebhookURL は停止時に管理アラートを送るDiscord Webhook URL(任意)。
+	AlertWebhookURL string `json:"alertWebhookUrl,omitempty"`
+}
+
+// BrokerConfig は検出イベントを発行するメッセージブローカーの設定。
+type BrokerConfig struct {
+	Enabled bool       `json:"enabled"`
+	Type    BrokerType `json:"type,omitempty"`
+	// URL はnatsなら"nats://host:4222"、kafka-restならREST ProxyのベースURL。
+	URL string `json:"url,omitempty"`
+	// Topic はnatsのsubjectまたはKafkaのトピック名。
+	Topic string `json:"topic,omitempty"`
+}
+
+// SingletonConfig は多重起動防止の設定。
+// 同じ設定で2つ目のインスタンスを起動すると通知が二重に送られるため、ロックを取得できなければ起動しない。
+type SingletonConfig struct {
+	Enabled bool `json:"enabled"`
+	// Path はロックファイルの場所。省略時はDefaultSingletonLockPath。
+	Path string `json:"path,omitempty"`
+	// Wait はロックを取得できない場合に起動を中止せず、他のインスタンスの終了を待つか。
+	Wait bool `json:"wait,omitempty"`
+}
+
+// LogConfig はログ設定。
+type LogConfig struct {
+	Level LogLevel `json:"level"`
+	// Compress は日付が変わった時点で前日以前のログファイルをgzip圧縮するか。
+	Compress bool           `json:"compress,omitempty"`
+	File     LogFileConfig  `json:"file,omitzero"`
+	Audit    LogAuditConfig `json:"audit,omitzero"`
+}
+
+// LogAuditConfig は通知監査ログの設定。
+// 通知ごとの送信先・抑制理由・送信結果をJSON Linesで記録し、アプリのログとは別に集計へ使えるようにする。
+type LogAuditConfig struct {
+	Enabled bool `json:"enabled"`
+	// Path は書き出し先。省略時はDefaultAuditLogPath。"-"なら標準出力に書き出す。
+	Path string `json:"path,omitempty"`
+}
+
+// LogFileConfig はログファイル出力の設定。
+type LogFileConfig struct {
+	// Enabled はログをファイルにも出力するか。省略時は有効。標準出力のみで収集するコンテナ環境などで無効にする。
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// FileEnabled はログファイル出力が有効かを返す。
+func (l LogConfig) FileEnabled() bool {
+	return l.File.Enabled == nil || *l.File.Enabled
+}
+
+// Config はアプリケーション全体の設定。
+type Config struct {
+	// SchemaVersion は設定ファイルの形式のバージョン。省略時は1として読み込み時に現在の形式へ移行する。
+	SchemaVersion int `json:"schemaVersion,omitempty"`
+
+	Twitch         TwitchConfig         `json:"twitch"`
+	Polling        PollingConfig        `json:"polling"`
+	Streamers      []StreamerConfig     `json:"streamers"`
+	Notifications  NotificationConfig   `json:"notifications"`
+	Server         ServerConfig         `json:"server"`
+	RetryQueue     RetryQueueConfig     `json:"retryQueue"`
+	DeadLetter     DeadLetterConfig     `json:"deadLetter"`
+	History        HistoryConfig        `json:"history"`
+	State          StateConfig          `json:"state,omitzero"`
+	ReadSync       ReadSyncConfig       `json:"readSync"`
+	CircuitBreaker CircuitBreakerConfig `json:"circuitBreaker"`
+	Broker         BrokerConfig         `json:"broker"`
+	Defaults       DefaultsConfig       `json:"defaults,omitzero"`
+	Network        NetworkConfig        `json:"network"`
+	Singleton      SingletonConfig      `json:"singleton"`
+	Log            LogConfig            `json:"log"`
+	// Timezone はログファイル名の日付やEmbedの時刻表示に使うIANAのタイムゾーン名(例: "America/New_York")。
+	// 省略時や読み込めない名前の場合はJST。
+	Timezone string `json:"timezone,omitempty"`
+	// Profiles は名前付きのプロファイル。選択したプロファイルの項目をトップレベルの設定に重ねて使う。
+	// 値はトップレベルと同じ形式で、上書きする項目のみ書く。
+	Profiles map[string]json.RawMessage `json:"profiles,omitempty"`
+}
+
+// DefaultLocation はtimezone省略時のタイムゾーン(JST)。
+var DefaultLocation = time.FixedZone("JST", 9*60*60)
+
+// Location はtimezoneのタイムゾーンを返す。省略時や読み込めない名前の場合はDefaultLocation。
+func (c *Config) Location() *time.Location {
+	if c.Timezone == "" {
+		return DefaultLocation
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return DefaultLocation
+	}
+	return loc
+}
+
+// DefaultNotificationSettings はdefaults.notifications省略時にCLIで追加するWebhookの通知設定。
+var DefaultNotificationSettings = NotificationSettings{
+	Online:      true,
+	Offline:     true,
+	TitleChange: true,
+	GameChange:  true,
+}
+
+// DefaultsConfig はCLIで配信者・Webhookを追加するときの初期値。
+type DefaultsConfig struct {
+	// Notifications は追加するWebhookの通知設定。省略時はDefaultNotificationSettings。
+	Notifications *NotificationSettings `json:"notifications,omitempty"`
+}
+
+// DefaultNotifications はCLIで追加するWebhookの通知設定を返す。
+func (c *Config) DefaultNotifications() NotificationSettings {
+	if c.Defaults.Notifications != nil {
+		return *c.Defaults.Notifications
+	}
+	return DefaultNotificationSettings
+}
+
+// NetworkConfig は外部へのHTTPリクエストの設定。
+type NetworkConfig struct {
+	// UserAgent は全リクエストに付与するUser-Agent。省略時は "StreamNotifier/<version>"。
+	UserAgent string `json:"userAgent,omitempty"`
+	// CAFile は追加で信頼するCA証明書(PEM形式)のパス。TLSを中継する社内プロキシ等で使う。
+	CAFile string `json:"caFile,omitempty"`
+	// InsecureSkipVerify はTLS証明書の検証を無効にする。安全でないため検証用途以外では使わない。
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+// ScheduleReminderLead はスケジュールリマインダーを配信予定の何分前に送るかを返す。
+func (c *Config) ScheduleReminderLead() time.Duration {
+	minutes := c.Notifications.ScheduleReminderMinutes
+	if minutes == 0 {
+		minutes = DefaultScheduleReminderMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// VodWait はofflineRequireVodのWebhookやアーカイブ公開の通知でVODを待つ最大時間を返す。
+func (c *Config) VodWait() time.Duration {
+	minutes := c.Notifications.VodWaitMinutes
+	if minutes == 0 {
+		minutes = DefaultVodWaitMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// DeadWebhookFailures はWebhookへの送信を停止するまでの404/401の連続回数を返す。
+func (c *Config) DeadWebhookFailures() int {
+	if c.Notifications.DeadWebhookFailures == 0 {
+		return DefaultDeadWebhookFailures
+	}
+	return c.Notifications.DeadWebhookFailures
+}
+
+// SendConcurrency はWebhookグループの複数URLへ同時に送信する最大数を返す。
+func (c *Config) SendConcurrency() int {
+	if c.Notifications.SendConcurrency == 0 {
+		return DefaultSendConcurrency
+	}
+	return c.Notifications.SendConcurrency
+}
+
+// HistoryPath は配信履歴の保存先を返す。
+func (c *Config) HistoryPath() string {
+	if c.History.Path != "" {
+		return c.History.Path
+	}
+	return DefaultHistoryPath
+}
+
+// AuditLogPath は通知監査ログの書き出し先を返す。
+func (c *Config) AuditLogPath() string {
+	if c.Log.Audit.Path != "" {
+		return c.Log.Audit.Path
+	}
+	return DefaultAuditLogPath
+}
+
+// SingletonLockPath は多重起動防止のロックファイルの場所を返す。
+// プロファイルごとに別の設定として扱うため、未指定ならプロファイル名を付けたファイルを使う。
+func (c *Config) SingletonLockPath() string {
+	if c.Singleton.Path != "" {
+		return c.Singleton.Path
+	}
+	return profilePath(DefaultSingletonLockPath)
+}
+
+// HealthPath は配信者ごとのヘルス状態の書き出し先を返す。未指定ならプロファイル名を付けた既定のファイルを使う。
+func (c *Config) HealthPath() string {
+	if c.State.HealthPath != "" {
+		return c.State.HealthPath
+	}
+	return profilePath(DefaultHealthPath)
+}
+
+// LifetimeStatsPath は累計の配信記録の保存先を返す。未指定ならプロファイル名を付けた既定のファイルを使う。
+func (c *Config) LifetimeStatsPath() string {
+	if c.State.LifetimeStatsPath != "" {
+		return c.State.LifetimeStatsPath
+	}
+	return profilePath(DefaultLifetimeStatsPath)
+}
+
+// StreamStartsPath は配信中の配信者の開始時刻の保存先を返す。未指定ならプロファイル名を付けた既定のファイルを使う。
+func (c *Config) StreamStartsPath() string {
+	if c.State.StreamStartsPath != "" {
+		return c.State.StreamStartsPath
+	}
+	return profilePath(DefaultStreamStartsPath)
+}
+
+// profilePath はプロファイルを選択していれば、defaultPathの拡張子の前にプロファイル名を付けたパスを返す。
+// 同じディレクトリで複数のプロファイルを動かしても、互いの状態ファイルを上書きしないようにする。
+func profilePath(defaultPath string) string {
+	if activeProfile == "" {
+		return defaultPath
+	}
+	ext := filepath.Ext(defaultPath)
+	return strings.TrimSuffix(defaultPath, ext) + "-" + activeProfile + ext
+}
+
+var (
+	// ErrConfigNotFound は設定ファイルが存在しないことを表す。初回起動の判定に使う。
+	ErrConfigNotFound = errors.New("設定ファイルが見つかりません")
+	// ErrInvalidJSON は設定ファイルがJSONとして解析できないことを表す。
+	ErrInvalidJSON = errors.New("設定ファイルのJSON解析に失敗")
+)
+
+// twitchLoginPattern はTwitchのユーザー名(ログイン名)として有効な文字列。英数字とアンダースコアの25文字以内。
+var twitchLoginPattern = regexp.MustCompile(`^[A-Za-z0-9_]{1,25}$`)
+
+// NormalizeUsername は前後の空白を除いて小文字にしたユーザー名を返す。Twitchのユーザー名として無効ならエラーを返す。
+func NormalizeUsername(username string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(username))
+	if normalized == "" {
+		return "", fmt.Errorf("ユーザー名を指定してください")
+	}
+	if !twitchLoginPattern.MatchString(normalized) {
+		return "", fmt.Errorf("Twitchのユーザー名に使用できない文字が含まれています: %q (英数字とアンダースコアのみ)", username)
+	}
+	return normalized, nil
+}
+
+// trimUsernames は手編集で紛れ込んだユーザー名の前後の空白を取り除く。
+func (c *Config) trimUsernames() {
+	for i := range c.Streamers {
+		c.Streamers[i].Username = strings.TrimSpace(c.Streamers[i].Username)
+	}
+}
+
+// ValidationError は設定値の検証エラー。
+type ValidationError struct {
+	// Field は問題のある設定項目のパス(例: "streamers[0].webhooks[1].url")。
+	Field string
+	Err   error
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// fieldPathPattern は検証エラーのメッセージ先頭の設定項目パスにマッチする。
+var fieldPathPattern = regexp.MustCompile(`^[A-Za-z0-9_.\[\]]+`)
+
+// Load は指定パスからconfig.jsonを読み込みバリデーションする。SetProfileでプロファイルを選択していればその設定を重ねる。
+// schemaVersionが古いファイルはメモリ上で現在の形式に移行し、検証に成功した場合のみ元の内容をpath.bakに残して書き戻す。
+// ファイルがなければErrConfigNotFound、JSONが不正ならErrInvalidJSON、検証に失敗すれば*ValidationErrorを返す。
+func Load(path string) (*Config, error) {
+	return load(path, false)
+}
+
+// load はLoadの本体。lockedはpathのロックを呼び出し元が取得済みであることを表す。
+func load(path string, locked bool) (*Config, error) {
+	original, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrConfigNotFound, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("設定ファイルの読み込みに失敗: %w", err)
+	}
+	migrated, changed, err := migrate(original)
+	if err != nil {
+		return nil, err
+	}
+	data := migrated
+	if activeProfile != "" {
+		if data, err = withProfile(data, activeProfile); err != nil {
+			return nil, err
+		}
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJSON, err)
+	}
+
+	if err := cfg.Twitch.resolveFiles(); err != nil {
+		return nil, err
+	}
+	cfg.trimUsernames()
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if changed {
+		writeMigrated(path, original, migrated, locked)
+	}
+	return &cfg, nil
+}
+
+// Save は設定をJSON形式で指定パスに保存する。
+// SetProfileでプロファイルを選択していれば、トップレベルとの差分をそのプロファイルに書き戻す。
+func Save(path string, cfg *Config) error {
+	if activeProfile != "" {
+		return saveProfile(path, activeProfile, cfg)
+	}
+	out := *cfg
+	out.SchemaVersion = CurrentSchemaVersion
+	out.Twitch = cfg.Twitch.forSave()
+	data, err := json.MarshalIndent(&out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("設定のJSON変換に失敗: %w", err)
+	}
+	return writeFile(path, data)
+}
+
+// writeFile は書き込み途中で失敗しても設定ファイルが壊れないよう、一時ファイル経由で置き換える。
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("設定ファイルの保存に失敗: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("設定ファイルの保存に失敗: %w", err)
+	}
+	return nil
+}
+
+// Validate は設定のバリデーションを行う。エラーは問題のある設定項目を示す*ValidationError。
+func (c *Config) Validate() error {
+	if err := c.validate(); err != nil {
+		// 検証エラーのメッセージは設定項目のパスから始める規約のため、先頭からパスを取り出す
+		field := strings.TrimRight(fieldPathPattern.FindString(err.Error()), ".")
+		return &ValidationError{Field: field, Err: err}
+	}
+	return nil
+}
+
+// validate は各設定項目を検証する。エラーメッセージは設定項目のパスから始めること。
+func (c *Config) validate() error {
+	switch c.Twitch.Mode {
+	case "", TwitchModeLive, TwitchModeMock:
+	default:
+		return fmt.Errorf("twitch.modeはlive/mock のいずれかを設定してください")
+	}
+	if c.Twitch.ClientID == "" && !c.Twitch.IsMock() {
+		return fmt.Errorf("twitch.clientIdまたはtwitch.clientIdFileは必須です")
+	}
+	if c.Twitch.ClientSecret == "" && !c.Twitch.IsMock() {
+		return fmt.Errorf("twitch.clientSecretまたはtwitch.clientSecretFileは必須です")
+	}
+	if c.Twitch.RequestsPerMinute < 0 {
+		return fmt.Errorf("twitch.requestsPerMinuteは0以上で設定してください")
+	}
+	if c.Twitch.BatchSize != 0 && (c.Twitch.BatchSize < 1 || c.Twitch.BatchSize > 100) {
+		return fmt.Errorf("twitch.batchSizeは1〜100で設定してください")
+	}
+	if c.Twitch.StartupRetrySeconds < 0 {
+		return fmt.Errorf("twitch.startupRetrySecondsは0以上で設定してください")
+	}
+	if c.Polling.IntervalSeconds < 10 {
+		return fmt.Errorf("polling.intervalSecondsは10以上で設定してください")
+	}
+	if auto := c.Polling.Auto; auto.Enabled {
+		if auto.MinSeconds < 10 || auto.MaxSeconds < auto.MinSeconds {
+			return fmt.Errorf("polling.auto.minSecondsは10以上、maxSecondsはminSeconds以上で設定してください")
+		}
+		if !c.History.Enabled {
+			return fmt.Errorf("polling.auto.enabledを使うにはhistory.enabledを有効にしてください")
+		}
+	}
+	if adaptive := c.Polling.Adaptive; adaptive.Enabled {
+		if adaptive.LiveSeconds != 0 && adaptive.LiveSeconds < 10 {
+			return fmt.Errorf("polling.adaptive.liveSecondsは10以上で設定してください")
+		}
+		if adaptive.MaxSeconds < c.Polling.IntervalSeconds {
+			return fmt.Errorf("polling.adaptive.maxSecondsはpolling.intervalSeconds以上で設定してください")
+		}
+		if adaptive.BackoffFactor != 0 && adaptive.BackoffFactor < 1 {
+			return fmt.Errorf("polling.adaptive.backoffFactorは1以上で設定してください")
+		}
+	}
+	if c.Polling.OfflineGraceSeconds < 0 {
+		return fmt.Errorf("polling.offlineGraceSecondsは0以上で設定してください")
+	}
+	if c.Polling.LatencyWarnSeconds < 0 {
+		return fmt.Errorf("polling.latencyWarnSecondsは0以上で設定してください")
+	}
+	if c.Notifications.TitleDebounceSeconds < 0 {
+		return fmt.Errorf("notifications.titleDebounceSecondsは0以上で設定してください")
+	}
+	if c.Notifications.MinUptimeSeconds < 0 {
+		return fmt.Errorf("notifications.minUptimeSecondsは0以上で設定してください")
+	}
+	if c.Notifications.CombineWindowSeconds < 0 {
+		return fmt.Errorf("notifications.combineWindowSecondsは0以上で設定してください")
+	}
+	if c.Notifications.ReconnectWindowSeconds < 0 {
+		return fmt.Errorf("notifications.reconnectWindowSecondsは0以上で設定してください")
+	}
+	for _, f := range c.Notifications.OnlineFields {
+		switch f {
+		case OnlineFieldGame, OnlineFieldStartTime, OnlineFieldViewers, OnlineFieldLanguage, OnlineFieldThumbnail, OnlineFieldFollowers:
+		default:
+			return fmt.Errorf("notifications.onlineFields: 不明な項目です: %q (game/startTime/viewers/language/thumbnail/followers)", f)
+		}
+	}
+	for i, f := range c.Notifications.OnlineFieldOrder {
+		if !slices.Contains(DefaultOnlineFieldOrder, f) {
+			return fmt.Errorf("notifications.onlineFieldOrder: 並び替えできない項目です: %q (game/startTime/viewers/language/followers)", f)
+		}
+		if slices.Contains(c.Notifications.OnlineFieldOrder[:i], f) {
+			return fmt.Errorf("notifications.onlineFieldOrder: %qが重複しています", f)
+		}
+	}
+	if c.Notifications.StillLiveIntervalHours < 0 {
+		return fmt.Errorf("notifications.stillLiveIntervalHoursは0以上で設定してください")
+	}
+	if c.Notifications.DeadWebhookFailures < 0 {
+		return fmt.Errorf("notifications.deadWebhookFailuresは0以上で設定してください")
+	}
+	if c.Notifications.SendConcurrency < 0 {
+		return fmt.Errorf("notifications.sendConcurrencyは0以上で設定してください")
+	}
+	if c.Notifications.VodWaitMinutes < 0 {
+		return fmt.Errorf("notifications.vodWaitMinutesは0以上で設定してください")
+	}
+	switch c.Notifications.Theme {
+	case "", ThemeDark, ThemeLight:
+	default:
+		return fmt.Errorf("notifications.theme: 不明なテーマです: %s (dark/lightのいずれか)", c.Notifications.Theme)
+	}
+	if c.Notifications.ScheduleReminderMinutes < 0 {
+		return fmt.Errorf("notifications.scheduleReminderMinutesは0以上で設定してください")
+	}
+	for game, hex := range c.Notifications.CategoryColors {
+		if strings.TrimSpace(game) == "" {
+			return fmt.Errorf("notifications.categoryColors: ゲーム名が空です")
+		}
+		if _, err := ParseHexColor(hex); err != nil {
+			return fmt.Errorf("notifications.categoryColors.%s: %w", game, err)
+		}
+	}
+	for platform, style := range c.Notifications.Platforms {
+		if platform != PlatformTwitch && platform != PlatformYouTube {
+			return fmt.Errorf("notifications.platforms.%s: 不明なプラットフォームです", platform)
+		}
+		if style.Color != "" {
+			if _, err := ParseHexColor(style.Color); err != nil {
+				return fmt.Errorf("notifications.platforms.%s.color: %w", platform, err)
+			}
+		}
+	}
+	if c.RetryQueue.MaxAgeMinutes < 0 {
+		return fmt.Errorf("retryQueue.maxAgeMinutesは0以上で設定してください")
+	}
+	if c.DeadLetter.MaxEntries < 0 {
+		return fmt.Errorf("deadLetter.maxEntriesは0以上で設定してください")
+	}
+	if rs := c.ReadSync; rs.Enabled {
+		if rs.BotToken == "" {
+			return fmt.Errorf("readSync.botTokenは必須です")
+		}
+		if rs.Action != "" && rs.Action != ReadSyncEdit && rs.Action != ReadSyncDelete {
+			return fmt.Errorf("readSync.actionは edit/delete のいずれかを設定してください")
+		}
+		if rs.PollSeconds < 0 || rs.MaxAgeHours < 0 {
+			return fmt.Errorf("readSync.pollSecondsとmaxAgeHoursは0以上で設定してください")
+		}
+	}
+	if cb := c.CircuitBreaker; cb.MaxNotifications > 0 {
+		if cb.WindowSeconds <= 0 || cb.PauseSeconds <= 0 {
+			return fmt.Errorf("circuitBreaker.windowSecondsとpauseSecondsは1以上で設定してください")
+		}
+		if cb.AlertWebhookURL != "" && !strings.HasPrefix(cb.AlertWebhookURL, WebhookURLPrefix) {
+			return fmt.Errorf("circuitBreaker.alertWebhookUrl: Discord Webhook URLの形式が無効です")
+		}
+	}
+	if c.Broker.Enabled {
+		if c.Broker.Type != BrokerNATS && c.Broker.Type != BrokerKafkaREST {
+			return fmt.Errorf("broker.typeは nats/kafka-rest のいずれかを設定してください")
+		}
+		if c.Broker.URL == "" || c.Broker.Topic == "" {
+			return fmt.Errorf("broker.urlとbroker.topicは必須です")
+		}
+		if c.Broker.Type == BrokerNATS && strings.ContainsAny(c.Broker.Topic, " \t\r\n") {
+			return fmt.Errorf("broker.topic: NATSのsubjectに空白は使用できません")
+		}
+	}
+	if c.Server.Port < 0 || c.Server.Port > 65535 {
+		return fmt.Errorf("server.portは0〜65535で設定してください")
+	}
+	if len(c.Streamers) == 0 {
+		return fmt.Errorf("streamersに1人以上の配信者を設定してください")
+	}
+
+	validLevels := map[string]bool{
+		LogDebug: true, LogInfo: true, LogWarn: true, LogError: true,
+	}
+	if !validLevels[c.Log.Level] {
+		return fmt.Errorf("log.levelは debug/info/warn/error のいずれかを設定してください")
+	}
+	if n := c.Defaults.Notifications; n != nil && *n == (NotificationSettings{}) {
+		return fmt.Errorf("defaults.notificationsで1つ以上の通知を有効にしてください")
+	}
+	if strings.ContainsAny(c.Network.UserAgent, "\r\n") {
+		return fmt.Errorf("network.userAgentに改行は使用できません")
+	}
+
+	// seen は小文字にしたユーザー名ごとの最初の設定位置
+	seen := make(map[string]int)
+	for i, s := range c.Streamers {
+		if s.Username == "" {
+			return fmt.Errorf("streamers[%d].usernameは必須です", i)
+		}
+		if !twitchLoginPattern.MatchString(s.Username) {
+			return fmt.Errorf("streamers[%d].username: Twitchのユーザー名に使用できない文字が含まれています: %q", i, s.Username)
+		}
+		if j, ok := seen[strings.ToLower(s.Username)]; ok {
+			return fmt.Errorf("streamers[%d].username: %s はstreamers[%d]と同じ配信者です", i, s.Username, j)
+		}
+		seen[strings.ToLower(s.Username)] = i
+		if len(s.Webhooks) == 0 {
+			return fmt.Errorf("streamers[%d].webhooksに1つ以上の設定が必要です", i)
+		}
+		if s.IntervalSeconds != 0 && s.IntervalSeconds < 10 {
+			return fmt.Errorf("streamers[%d].intervalSecondsは10以上で設定してください", i)
+		}
+		if s.MinViewersForOnline < 0 {
+			return fmt.Errorf("streamers[%d].minViewersForOnlineは0以上で設定してください", i)
+		}
+		if s.Color != "" {
+			if _, err := ParseHexColor(s.Color); err != nil {
+				return fmt.Errorf("streamers[%d].color: %w", i, err)
+			}
+		}
+		for j, w := range s.Webhooks {
+			if err := w.validate(); err != nil {
+				return fmt.Errorf("streamers[%d].webhooks[%d].%w", i, j, err)
+			}
+		}
+		if err := s.validateRoutes(); err != nil {
+			return fmt.Errorf("streamers[%d].%w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// validate はWebhook設定を種別に応じて検証する。エラーはフィールド名から始まる。
+func (w WebhookConfig) validate() error {
+	targets := w.Targets()
+	if len(targets) == 0 {
+		return fmt.Errorf("url: urlまたはurlsに1つ以上の送信先が必要です")
+	}
+
+	switch w.Type {
+	case "", WebhookDiscord:
+		for _, target := range targets {
+			if !strings.HasPrefix(target, WebhookURLPrefix) {
+				return fmt.Errorf("url: Discord Webhook URLの形式が無効です")
+			}
+		}
+	case WebhookGeneric:
+		for _, target := range targets {
+			u, err := url.Parse(target)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("url: http(s)のURLを指定してください")
+			}
+		}
+		if w.Template == "" {
+			return fmt.Errorf("template: genericではテンプレートが必須です")
+		}
+		if _, err := ParseBodyTemplate("body", w.Template); err != nil {
+			return fmt.Errorf("template: テンプレートの解析に失敗: %w", err)
+		}
+		for name, value := range w.Headers {
+			if !isValidHeaderName(name) {
+				return fmt.Errorf("headers: 無効なヘッダー名です: %q", name)
+			}
+			if strings.ContainsAny(value, "\r\n") {
+				return fmt.Errorf("headers.%s: 値に改行は使用できません", name)
+			}
+		}
+	default:
+		return fmt.Errorf("type: discord/generic のいずれかを設定してください")
+	}
+	if w.Color != "" {
+		if _, err := ParseHexColor(w.Color); err != nil {
+			return fmt.Errorf("color: %w", err)
+		}
+	}
+	if w.MinViewersForOnline < 0 {
+		return fmt.Errorf("minViewersForOnline: 0以上で設定してください")
+	}
+	if w.MinViewers < 0 {
+		return fmt.Errorf("minViewers: 0以上で設定してください")
+	}
+	if w.MinViewers > 0 && w.MinViewersForOnline > 0 && w.MinViewers != w.MinViewersForOnline {
+		return fmt.Errorf("minViewers: minViewersForOnlineの別名です。両方を指定する場合は同じ値にしてください")
+	}
+	switch w.OfflineVodFallback {
+	case "", VodFallbackSend, VodFallbackDrop:
+	default:
+		return fmt.Errorf("offlineVodFallback: send/drop のいずれかを設定してください")
+	}
+	for changeType := range w.IdentityByType {
+		if !isKnownChangeType(changeType) {
+			return fmt.Errorf("identityByType: 不明な通知タイプです: %q", changeType)
+		}
+	}
+	switch w.Language {
+	case "", LanguageJa, LanguageEn:
+	default:
+		return fmt.Errorf("language: ja/en のいずれかを設定してください")
+	}
+	return nil
+}
+
+// isKnownChangeType は通知タイプとして定義済みの値か判定する。
+func isKnownChangeType(changeType ChangeType) bool {
+	switch changeType {
+	case ChangeOnline, ChangeOffline, ChangeTitleChange, ChangeGameChange, ChangeTitleAndGame,
+		ChangeScheduledReminder, ChangeProfileUpdate, ChangeReconnect, ChangeStillLive, ChangeVodPublished:
+		return true
+	}
+	return false
+}
+
+// isValidHeaderName はHTTPヘッダー名として有効な文字列(RFC 7230のtoken)か判定する。
+func isValidHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ParseHexColor は"#9146ff"形式の16進カラー文字列をEmbed用の整数値に変換する。
+func ParseHexColor(s string) (int, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) != 6 {
+		return 0, fmt.Errorf("カラーコードは#RRGGBB形式で指定してください: %q", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("カラーコードは#RRGGBB形式で指定してください: %q", s)
+	}
+	return int(v), nil
+}
+
+// IsNotificationEnabled は変更タイプが通知設定で有効かどうかを判定する。
+func IsNotificationEnabled(changeType ChangeType, n NotificationSettings) bool {
+	switch changeType {
+	case ChangeOnline:
+		return n.Online
+	case ChangeOffline:
+		return n.Offline
+	case ChangeTitleChange:
+		return n.TitleChange
+	case ChangeGameChange:
+		return n.GameChange
+	case ChangeTitleAndGame:
+		// タイトル変更またはゲーム変更のどちらかが有効なら通知
+		return n.TitleChange || n.GameChange
+	case ChangeScheduledReminder:
+		return n.Schedule
+	case ChangeProfileUpdate:
+		return n.ProfileUpdate
+	case ChangeVodPublished:
+		return n.VodPublished
+	case ChangeReconnect:
+		// 配信開始の代わりに送るため配信開始の設定に従う
+		return n.Online
+	case ChangeStillLive:
+		// 配信開始の再告知のため配信開始の設定に従う
+		return n.Online
+	default:
+		return false
+	}
+}
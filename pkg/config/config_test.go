@@ -0,0 +1,65 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStatePathsFollowProfile(t *testing.T) {
+	var cfg Config
+	if got := cfg.HealthPath(); got != DefaultHealthPath {
+		t.Errorf("HealthPath() = %q, want %q", got, DefaultHealthPath)
+	}
+
+	SetProfile("work")
+	t.Cleanup(func() { SetProfile("") })
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"health", cfg.HealthPath(), "./data/health-work.json"},
+		{"lifetime", cfg.LifetimeStatsPath(), "./data/lifetime-work.json"},
+		{"streamStarts", cfg.StreamStartsPath(), "./data/stream-starts-work.json"},
+		{"singleton", cfg.SingletonLockPath(), "./data/stream-notifier-work.lock"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s path = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+
+	cfg.State.HealthPath = "/var/lib/stream-notifier/health.json"
+	if got := cfg.HealthPath(); got != cfg.State.HealthPath {
+		t.Errorf("HealthPath() = %q, want the configured path unchanged", got)
+	}
+}
+
+func TestWarningsReportDuplicateWebhooks(t *testing.T) {
+	const shared = "https://discord.com/api/webhooks/1/shared"
+	const twice = "https://discord.com/api/webhooks/2/twice"
+	cfg := Config{Streamers: []StreamerConfig{
+		{Username: "alice", Webhooks: []WebhookConfig{{URL: shared}, {URL: twice}, {URL: twice}}},
+		{Username: "bob", Webhooks: []WebhookConfig{{URL: shared}}},
+	}}
+
+	warnings := cfg.Warnings()
+	if len(warnings) != 2 {
+		t.Fatalf("Warnings() = %q, want one warning per duplicate URL", warnings)
+	}
+	tests := []struct {
+		name      string
+		warning   string
+		wantText  string
+		locations string
+	}{
+		{"cross streamer", warnings[0], "複数の配信者に同じWebhook URL", "streamers[0].webhooks[0], streamers[1].webhooks[0]"},
+		{"same streamer", warnings[1], "同じ配信者に同じWebhook URL", "streamers[0].webhooks[1], streamers[0].webhooks[2]"},
+	}
+	for _, tt := range tests {
+		if !strings.Contains(tt.warning, tt.wantText) || !strings.Contains(tt.warning, tt.locations) {
+			t.Errorf("%s warning = %q, want it to contain %q and %q", tt.name, tt.warning, tt.wantText, tt.locations)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DuplicateWebhook は複数の箇所に設定された同じWebhook URL。
+type DuplicateWebhook struct {
+	URL string
+	// Locations は設定箇所(例: "streamers[0].webhooks[1]")。
+	Locations []string
+	// SameStreamer は同じ配信者の中で重複しているか。この場合は同じ通知が二重に送られ得る。
+	// 複数の配信者で1つのチャンネルを共有するのは通常の使い方のため、配信者をまたぐ重複は問題にならない。
+	SameStreamer bool
+}
+
+// DuplicateWebhooks は複数箇所に設定されたWebhook URLを設定ファイル内の出現順に返す。
+func (c *Config) DuplicateWebhooks() []DuplicateWebhook {
+	type occurrence struct {
+		streamer int
+		location string
+	}
+	var order []string
+	occurrences := make(map[string][]occurrence)
+	for i, s := range c.Streamers {
+		for j, w := range s.Webhooks {
+			for _, u := range w.Targets() {
+				if _, ok := occurrences[u]; !ok {
+					order = append(order, u)
+				}
+				occurrences[u] = append(occurrences[u], occurrence{i, fmt.Sprintf("streamers[%d].webhooks[%d]", i, j)})
+			}
+		}
+	}
+
+	var dups []DuplicateWebhook
+	for _, u := range order {
+		occ := occurrences[u]
+		if len(occ) < 2 {
+			continue
+		}
+		d := DuplicateWebhook{URL: u}
+		seen := make(map[int]bool)
+		for _, o := range occ {
+			if seen[o.streamer] {
+				d.SameStreamer = true
+			}
+			seen[o.streamer] = true
+			d.Locations = append(d.Locations, o.location)
+		}
+		dups = append(dups, d)
+	}
+	return dups
+}
+
+// Warnings はエラーにはしないが見直しを勧める設定を返す。
+func (c *Config) Warnings() []string {
+	var warnings []string
+	if c.Timezone != "" {
+		if _, err := time.LoadLocation(c.Timezone); err != nil {
+			warnings = append(warnings, fmt.Sprintf("timezoneのタイムゾーンを読み込めないためJSTを使います: %s", c.Timezone))
+		}
+	}
+	for _, d := range c.DuplicateWebhooks() {
+		if d.SameStreamer {
+			warnings = append(warnings, fmt.Sprintf("同じ配信者に同じWebhook URLが複数設定されています (%s)", strings.Join(d.Locations, ", ")))
+		} else {
+			warnings = append(warnings, fmt.Sprintf("複数の配信者に同じWebhook URLが設定されています。1つのチャンネルを共有する意図なら問題ありません (%s)", strings.Join(d.Locations, ", ")))
+		}
+	}
+	return warnings
+}
+
+// DedupeWebhooks は配信者ごとに重複するWebhook URLを最初に設定したWebhookへまとめ、取り除いたURLの数を返す。
+// 取り除いたWebhookで有効な通知は最初のWebhookでも有効にする。送信先がなくなったWebhookは削除する。
+func (c *Config) DedupeWebhooks() int {
+	removed := 0
+	for i := range c.Streamers {
+		s := &c.Streamers[i]
+		// owner は各URLを最初に設定したWebhookのインデックス
+		owner := make(map[string]int)
+		var kept []WebhookConfig
+		for _, w := range s.Webhooks {
+			all := w.Targets()
+			var targets []string
+			for _, u := range all {
+				if k, ok := owner[u]; ok {
+					kept[k].Notifications = mergeNotifications(kept[k].Notifications, w.Notifications)
+					removed++
+					continue
+				}
+				if slices.Contains(targets, u) {
+					removed++
+					continue
+				}
+				targets = append(targets, u)
+			}
+			if len(targets) == 0 {
+				continue
+			}
+			for _, u := range targets {
+				owner[u] = len(kept)
+			}
+			if len(targets) < len(all) {
+				w.URL, w.URLs = targets[0], nil
+				if len(targets) > 1 {
+					w.URLs = targets[1:]
+				}
+			}
+			kept = append(kept, w)
+		}
+		s.Webhooks = kept
+	}
+	return removed
+}
+
+// mergeNotifications はいずれかで有効な通知を有効にした通知設定を返す。
+func mergeNotifications(a, b NotificationSettings) NotificationSettings {
+	return NotificationSettings{
+		Online:        a.Online || b.Online,
+		Offline:       a.Offline || b.Offline,
+		TitleChange:   a.TitleChange || b.TitleChange,
+		GameChange:    a.GameChange || b.GameChange,
+		Schedule:      a.Schedule || b.Schedule,
+		ProfileUpdate: a.ProfileUpdate || b.ProfileUpdate,
+		VodPublished:  a.VodPublished || b.VodPublished,
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// lockTimeout はロックの取得を待つ上限。
+	lockTimeout = 10 * time.Second
+	// lockRetryInterval はロック取得を再試行する間隔。
+	lockRetryInterval = 100 * time.Millisecond
+	// staleLockAge はこの時間より古いロックファイルを異常終了したプロセスの残骸とみなす。
+	// WithLock内の処理は読み込み・変更・保存のみで、通常は一瞬で終わる。
+	staleLockAge = 30 * time.Second
+)
+
+// WithLock はpathのロックを取得した状態で設定を読み込み、fnで変更して保存する。
+// fnがエラーを返した場合は保存しない。複数のCLIの同時実行で互いの変更を上書きしないようにするため、
+// 読み込みから保存までをロックの内側で行う。対話入力はロックの外で済ませてからfnを呼ぶこと。
+func WithLock(path string, fn func(*Config) error) error {
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cfg, err := load(path, true)
+	if err != nil {
+		return err
+	}
+	if err := fn(cfg); err != nil {
+		return err
+	}
+	return Save(path, cfg)
+}
+
+// lockFile はpath.lockを排他作成してロックを取得し、解放する関数を返す。
+// 取得できなければlockTimeoutまで待ち、staleLockAgeより古いロックは削除して取り直す。
+func lockFile(path string) (unlock func(), err error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			// 調査用に保持しているプロセスを書いておく
+			_, _ = f.WriteString(strconv.Itoa(os.Getpid()))
+			_ = f.Close()
+			return func() { _ = os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("設定ファイルのロックに失敗: %w", err)
+		}
+
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > staleLockAge {
+			// 削除に失敗しても次の作成で再判定する
+			_ = os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("設定ファイルが他の操作でロックされています (%s を確認してください)", lockPath)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// CurrentSchemaVersion はこのバージョンが読み書きする設定ファイルのschemaVersion。
+// schemaVersionのない設定ファイルはバージョン1として扱う。
+const CurrentSchemaVersion = 2
+
+// ErrSchemaTooNew は設定ファイルのschemaVersionがこのバージョンより新しいことを表す。
+var ErrSchemaTooNew = errors.New("設定ファイルがこのバージョンより新しい形式です。StreamNotifierをアップデートしてください")
+
+// migrations[i] はschemaVersion i+1 の設定をi+2 の形式に変換する。トップレベルの項目ごとのJSONを書き換える。
+var migrations = []func(doc map[string]json.RawMessage) error{
+	migrateV1ToV2,
+}
+
+// migrateV1ToV2 は省略時の値に頼っていた項目に既定値を書き込む。
+// log.levelは省略すると検証で失敗していたためinfoを補い、defaults.notificationsはCLIの既定値を明示する。
+func migrateV1ToV2(doc map[string]json.RawMessage) error {
+	var log map[string]json.RawMessage
+	if raw, ok := doc["log"]; ok {
+		if err := json.Unmarshal(raw, &log); err != nil {
+			return fmt.Errorf("log: %w", err)
+		}
+	}
+	if log == nil {
+		log = make(map[string]json.RawMessage)
+	}
+	if level, ok := log["level"]; !ok || string(level) == `""` {
+		log["level"] = json.RawMessage(`"` + LogInfo + `"`)
+	}
+	if err := setJSON(doc, "log", log); err != nil {
+		return err
+	}
+
+	var defaults map[string]json.RawMessage
+	if raw, ok := doc["defaults"]; ok {
+		if err := json.Unmarshal(raw, &defaults); err != nil {
+			return fmt.Errorf("defaults: %w", err)
+		}
+	}
+	if defaults == nil {
+		defaults = make(map[string]json.RawMessage)
+	}
+	if _, ok := defaults["notifications"]; !ok {
+		if err := setJSON(defaults, "notifications", DefaultNotificationSettings); err != nil {
+			return err
+		}
+	}
+	return setJSON(doc, "defaults", defaults)
+}
+
+// setJSON はvをJSONに変換してdoc[key]に設定する。
+func setJSON(doc map[string]json.RawMessage, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	doc[key] = data
+	return nil
+}
+
+// migrate は設定ファイルの内容を現在のschemaVersionの形式に変換する。変換した場合はchangedがtrue。
+// schemaVersionがこのバージョンより新しければErrSchemaTooNewを返す。
+func migrate(data []byte) (result []byte, changed bool, err error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, false, fmt.Errorf("%w: %w", ErrInvalidJSON, err)
+	}
+	version := 1
+	if raw, ok := doc["schemaVersion"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil || version < 1 {
+			return nil, false, fmt.Errorf("schemaVersion: 1以上の整数を指定してください: %s", raw)
+		}
+	}
+	if version > CurrentSchemaVersion {
+		return nil, false, fmt.Errorf("%w (設定ファイル: %d, 対応: %d)", ErrSchemaTooNew, version, CurrentSchemaVersion)
+	}
+	if version == CurrentSchemaVersion {
+		return data, false, nil
+	}
+
+	for v := version; v < CurrentSchemaVersion; v++ {
+		if err := migrations[v-1](doc); err != nil {
+			return nil, false, fmt.Errorf("schemaVersion %dから%dへの移行に失敗: %w", v, v+1, err)
+		}
+	}
+	if err := setJSON(doc, "schemaVersion", CurrentSchemaVersion); err != nil {
+		return nil, false, err
+	}
+	result, err = json.Marshal(doc)
+	if err != nil {
+		return nil, false, fmt.Errorf("設定のJSON変換に失敗: %w", err)
+	}
+	return result, true, nil
+}
+
+// writeMigrated は移行前の内容をpath.bakに残してから、移行後の内容をpathに書き戻す。
+// 検証に成功した後にだけ呼ぶ。lockedがfalseならpathのロックを取得し、その間にファイルが
+// 変更されていれば書き戻さない。書き戻しに失敗しても読み込みは続ける(読み取り専用のマウントなど)。
+func writeMigrated(path string, original, migrated []byte, locked bool) {
+	if !locked {
+		unlock, err := lockFile(path)
+		if err != nil {
+			slog.Warn("移行した設定ファイルを書き戻せませんでした。次回の起動時にも移行します", "path", path, "error", err)
+			return
+		}
+		defer unlock()
+
+		current, err := os.ReadFile(path)
+		if err != nil || !bytes.Equal(current, original) {
+			// 読み込み後に他の操作が書き換えたため、その内容を優先する
+			return
+		}
+	}
+
+	// Configを経由すると未知の項目が落ちるため、移行後のJSONをそのまま整形する
+	var out bytes.Buffer
+	if err := json.Indent(&out, migrated, "", "  "); err != nil {
+		slog.Warn("移行した設定ファイルを書き戻せませんでした。次回の起動時にも移行します", "path", path, "error", err)
+		return
+	}
+	backup := path + ".bak"
+	if err := writeFile(backup, original); err != nil {
+		slog.Warn("移行前の設定ファイルを退避できないため書き戻しません。次回の起動時にも移行します", "path", backup, "error", err)
+		return
+	}
+	if err := writeFile(path, out.Bytes()); err != nil {
+		slog.Warn("移行した設定ファイルを書き戻せませんでした。次回の起動時にも移行します", "path", path, "error", err)
+		return
+	}
+	slog.Info("設定ファイルを新しい形式に移行しました", "path", path, "schemaVersion", CurrentSchemaVersion, "backup", backup)
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// v1TestConfig はschemaVersionのない(バージョン1の)設定。log.levelとdefaultsを省略し、未知の項目を含む。
+const v1TestConfig = `{
+  "twitch": {"clientId": "id", "clientSecret": "secret"},
+  "polling": {"intervalSeconds": 60},
+  "x-comment": "kept across migration",
+  "streamers": [{
+    "username": "streamer",
+    "webhooks": [{"url": "https://discord.com/api/webhooks/1/live", "x-note": "nested"}]
+  }]
+}`
+
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMigrateV1ToV2(t *testing.T) {
+	out, changed, err := migrate([]byte(v1TestConfig))
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if !changed {
+		t.Fatal("changed = false, want true for a v1 file")
+	}
+
+	var doc struct {
+		SchemaVersion int    `json:"schemaVersion"`
+		Comment       string `json:"x-comment"`
+		Log           struct {
+			Level string `json:"level"`
+		} `json:"log"`
+		Defaults struct {
+			Notifications *NotificationSettings `json:"notifications"`
+		} `json:"defaults"`
+	}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.SchemaVersion != CurrentSchemaVersion {
+		t.Errorf("schemaVersion = %d, want %d", doc.SchemaVersion, CurrentSchemaVersion)
+	}
+	if doc.Log.Level != LogInfo {
+		t.Errorf("log.level = %q, want %q", doc.Log.Level, LogInfo)
+	}
+	if doc.Defaults.Notifications == nil || *doc.Defaults.Notifications != DefaultNotificationSettings {
+		t.Errorf("defaults.notifications = %+v, want %+v", doc.Defaults.Notifications, DefaultNotificationSettings)
+	}
+	if doc.Comment != "kept across migration" {
+		t.Errorf("unknown key x-comment = %q, want it preserved", doc.Comment)
+	}
+}
+
+func TestMigrateKeepsExplicitValues(t *testing.T) {
+	in := `{"log": {"level": "debug"}, "defaults": {"notifications": {"online": false}}}`
+	out, _, err := migrate([]byte(in))
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	var doc struct {
+		Log struct {
+			Level string `json:"level"`
+		} `json:"log"`
+		Defaults struct {
+			Notifications map[string]bool `json:"notifications"`
+		} `json:"defaults"`
+	}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Log.Level != "debug" {
+		t.Errorf("log.level = %q, want debug", doc.Log.Level)
+	}
+	if len(doc.Defaults.Notifications) != 1 || doc.Defaults.Notifications["online"] {
+		t.Errorf("defaults.notifications = %v, want the file's own value", doc.Defaults.Notifications)
+	}
+}
+
+func TestMigrateCurrentVersionUnchanged(t *testing.T) {
+	in := []byte(`{"schemaVersion": 2, "log": {"level": "warn"}}`)
+	out, changed, err := migrate(in)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if changed || string(out) != string(in) {
+		t.Errorf("migrate changed a current file: changed=%v out=%s", changed, out)
+	}
+}
+
+func TestMigrateRejectsNewerVersion(t *testing.T) {
+	_, _, err := migrate([]byte(`{"schemaVersion": 99}`))
+	if !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("err = %v, want ErrSchemaTooNew", err)
+	}
+}
+
+func TestLoadWritesMigratedFileWithBackup(t *testing.T) {
+	path := writeTestConfig(t, v1TestConfig)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Log.Level != LogInfo {
+		t.Errorf("Log.Level = %q, want %q", cfg.Log.Level, LogInfo)
+	}
+
+	backup, err := os.ReadFile(path + ".bak")
+	if err != nil {
+		t.Fatalf("backup not written: %v", err)
+	}
+	if string(backup) != v1TestConfig {
+		t.Errorf("backup = %s, want the original file", backup)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["schemaVersion"] != float64(CurrentSchemaVersion) {
+		t.Errorf("written schemaVersion = %v, want %d", doc["schemaVersion"], CurrentSchemaVersion)
+	}
+	if doc["x-comment"] != "kept across migration" {
+		t.Errorf("written file dropped the unknown key x-comment: %s", data)
+	}
+	webhook := doc["streamers"].([]any)[0].(map[string]any)["webhooks"].([]any)[0].(map[string]any)
+	if webhook["x-note"] != "nested" {
+		t.Errorf("written file dropped the nested unknown key x-note: %s", data)
+	}
+	if _, err := os.Stat(path + ".lock"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("lock file left behind: %v", err)
+	}
+}
+
+func TestLoadDoesNotWriteInvalidMigratedFile(t *testing.T) {
+	invalid := `{"twitch": {"clientId": "id", "clientSecret": "secret"}, "polling": {"intervalSeconds": 1}}`
+	path := writeTestConfig(t, invalid)
+
+	var verr *ValidationError
+	if _, err := Load(path); !errors.As(err, &verr) {
+		t.Fatalf("Load err = %v, want *ValidationError", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != invalid {
+		t.Errorf("invalid config was rewritten: %s", data)
+	}
+	if _, err := os.Stat(path + ".bak"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("backup written for an invalid config: %v", err)
+	}
+}
+
+func TestWithLockMigratesV1File(t *testing.T) {
+	path := writeTestConfig(t, v1TestConfig)
+	if err := WithLock(path, func(*Config) error { return nil }); err != nil {
+		t.Fatalf("WithLock: %v", err)
+	}
+	if _, err := os.Stat(path + ".bak"); err != nil {
+		t.Errorf("backup not written: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.SchemaVersion != CurrentSchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", cfg.SchemaVersion, CurrentSchemaVersion)
+	}
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// activeProfile はLoad・Save・WithLockが対象とするプロファイル名。空ならトップレベルの設定(デフォルトプロファイル)。
+var activeProfile string
+
+// SetProfile は以降のLoad・Save・WithLockが対象とするプロファイルを設定する。空文字でデフォルトに戻す。
+func SetProfile(name string) {
+	activeProfile = name
+}
+
+// Profile は対象のプロファイル名を返す。デフォルトプロファイルなら空文字。
+func Profile() string {
+	return activeProfile
+}
+
+// withProfile はトップレベルの設定にprofiles.<name>を重ねたJSONを返す。
+// オブジェクトは項目ごとに重ね、配列や値はプロファイルの値で置き換える。省略した項目はトップレベルの値を引き継ぐ。
+func withProfile(data []byte, name string) ([]byte, error) {
+	var file struct {
+		Profiles map[string]json.RawMessage `json:"profiles"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJSON, err)
+	}
+	overlay, ok := file.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("プロファイル %q が設定ファイルのprofilesにありません", name)
+	}
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(overlay, &keys); err != nil {
+		return nil, fmt.Errorf("%w: profiles.%s: %w", ErrInvalidJSON, name, err)
+	}
+	if _, ok := keys["profiles"]; ok {
+		return nil, fmt.Errorf("profiles.%sにprofilesは指定できません", name)
+	}
+	return mergeJSON(data, overlay), nil
+}
+
+// mergeJSON はbaseにoverlayを重ねたJSONを返す。両方がオブジェクトなら項目ごとに再帰的に重ね、それ以外はoverlayを使う。
+func mergeJSON(base, overlay json.RawMessage) json.RawMessage {
+	var b, o map[string]json.RawMessage
+	if json.Unmarshal(base, &b) != nil || json.Unmarshal(overlay, &o) != nil || b == nil || o == nil {
+		return overlay
+	}
+	for k, v := range o {
+		if bv, ok := b[k]; ok {
+			b[k] = mergeJSON(bv, v)
+		} else {
+			b[k] = v
+		}
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		return overlay
+	}
+	return data
+}
+
+// diffJSON はbaseに重ねるとcurrentになるJSONを返す。prevに指定済みの項目は値が同じでも残す。
+// オブジェクトは項目ごとに比較し、配列や値は異なればcurrentの値で置き換える。
+// 重ね合わせでは項目を削除できないため、currentで省略された項目はトップレベルの値に戻る。
+func diffJSON(base, current, prev json.RawMessage) (json.RawMessage, bool) {
+	var b, c, p map[string]json.RawMessage
+	if json.Unmarshal(base, &b) != nil || json.Unmarshal(current, &c) != nil || b == nil || c == nil {
+		if prev == nil && bytes.Equal(base, current) {
+			return nil, false
+		}
+		return current, true
+	}
+	_ = json.Unmarshal(prev, &p)
+
+	diff := make(map[string]json.RawMessage)
+	for k, cv := range c {
+		bv, inBase := b[k]
+		pv, inPrev := p[k]
+		if !inBase {
+			diff[k] = cv
+			continue
+		}
+		if !inPrev {
+			pv = nil
+		}
+		if d, ok := diffJSON(bv, cv, pv); ok {
+			diff[k] = d
+		}
+	}
+	if len(diff) == 0 && prev == nil {
+		return nil, false
+	}
+	data, err := json.Marshal(diff)
+	if err != nil {
+		return current, true
+	}
+	return data, true
+}
+
+// saveProfile はcfgとトップレベルの設定との差分をprofiles.<name>に書き戻す。トップレベルの設定は変更しない。
+func saveProfile(path, name string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("設定ファイルの読み込みに失敗: %w", err)
+	}
+	var base Config
+	if err := json.Unmarshal(data, &base); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidJSON, err)
+	}
+
+	out := *cfg
+	out.Twitch = cfg.Twitch.forSave()
+	out.Profiles = nil
+	current, err := json.Marshal(&out)
+	if err != nil {
+		return fmt.Errorf("設定のJSON変換に失敗: %w", err)
+	}
+	top := base
+	top.Profiles = nil
+	baseJSON, err := json.Marshal(&top)
+	if err != nil {
+		return fmt.Errorf("設定のJSON変換に失敗: %w", err)
+	}
+
+	prev := base.Profiles[name]
+	overlay, ok := diffJSON(baseJSON, current, prev)
+	if !ok {
+		overlay = json.RawMessage("{}")
+	}
+	if base.Profiles == nil {
+		base.Profiles = make(map[string]json.RawMessage)
+	}
+	base.Profiles[name] = overlay
+
+	// トップレベルはファイルの値をそのまま書き戻すため、認証情報のファイル指定の解決(forSave)は不要
+	out = base
+	result, err := json.MarshalIndent(&out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("設定のJSON変換に失敗: %w", err)
+	}
+	return writeFile(path, result)
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// RouteConfig は通知の種類ごとの送信先。送信先はwebhooksのnameで参照する。
+// 例: 配信開始・終了をWebhook "live" に、タイトル・ゲーム変更を "updates" に送る。
+type RouteConfig struct {
+	// Types は送る通知の種類。notificationsの項目名(online/offline/titleChange/gameChange/schedule/profileUpdate/vodPublished)で指定する。
+	Types []string `json:"types"`
+	// Webhooks は送信先のWebhookのname。
+	Webhooks []string `json:"webhooks"`
+}
+
+// routeTypes はroutesのtypesに指定できる項目と、対応する通知設定の有効化。
+var routeTypes = map[string]func(n *NotificationSettings){
+	"online":        func(n *NotificationSettings) { n.Online = true },
+	"offline":       func(n *NotificationSettings) { n.Offline = true },
+	"titleChange":   func(n *NotificationSettings) { n.TitleChange = true },
+	"gameChange":    func(n *NotificationSettings) { n.GameChange = true },
+	"schedule":      func(n *NotificationSettings) { n.Schedule = true },
+	"profileUpdate": func(n *NotificationSettings) { n.ProfileUpdate = true },
+	"vodPublished":  func(n *NotificationSettings) { n.VodPublished = true },
+}
+
+// WithRoutes はroutesを解決した設定のコピーを返す。コピーではroutesで参照されたWebhookの通知設定を
+// routesで指定した種類の和に置き換え、参照されていないWebhookは自身のnotificationsに従う。
+// 元の設定は変更しないため、解決した通知設定がSaveで設定ファイルに書き戻されることはない。
+// 監視・送信に使う設定に対して呼ぶこと。Validate済みの設定に対して呼ぶこと。
+func (c *Config) WithRoutes() *Config {
+	out := *c
+	out.Streamers = slices.Clone(c.Streamers)
+	for i := range out.Streamers {
+		s := &out.Streamers[i]
+		if len(s.Routes) == 0 {
+			continue
+		}
+		s.Webhooks = slices.Clone(s.Webhooks)
+		// routed はroutesで参照されたWebhookのインデックスごとの通知設定
+		routed := make(map[int]*NotificationSettings)
+		for _, r := range s.Routes {
+			for _, name := range r.Webhooks {
+				j := slices.IndexFunc(s.Webhooks, func(w WebhookConfig) bool { return w.Name == name })
+				n, ok := routed[j]
+				if !ok {
+					n = &NotificationSettings{}
+					routed[j] = n
+				}
+				for _, t := range r.Types {
+					routeTypes[t](n)
+				}
+			}
+		}
+		for j, n := range routed {
+			s.Webhooks[j].Notifications = *n
+		}
+	}
+	return &out
+}
+
+// validateRoutes は配信者のroutesを検証する。エラーはフィールド名から始まる。
+func (s StreamerConfig) validateRoutes() error {
+	for i, r := range s.Routes {
+		if len(r.Types) == 0 {
+			return fmt.Errorf("routes[%d].typesに1つ以上の通知の種類が必要です", i)
+		}
+		for _, t := range r.Types {
+			if _, ok := routeTypes[t]; !ok {
+				return fmt.Errorf("routes[%d].types: 不明な通知の種類です: %q (online/offline/titleChange/gameChange/schedule/profileUpdate/vodPublished)", i, t)
+			}
+		}
+		if len(r.Webhooks) == 0 {
+			return fmt.Errorf("routes[%d].webhooksに1つ以上のWebhookのnameが必要です", i)
+		}
+		for _, name := range r.Webhooks {
+			count := 0
+			for _, w := range s.Webhooks {
+				if w.Name == name {
+					count++
+				}
+			}
+			switch {
+			case name == "" || count == 0:
+				return fmt.Errorf("routes[%d].webhooks: nameが %q のWebhookがありません", i, name)
+			case count > 1:
+				return fmt.Errorf("routes[%d].webhooks: nameが %q のWebhookが複数あるため参照できません", i, name)
+			}
+		}
+	}
+	return nil
+}
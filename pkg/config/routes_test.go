@@ -0,0 +1,62 @@
+package config
+
+import "testing"
+
+const routesTestConfig = `{
+  "schemaVersion": 2,
+  "twitch": {"clientId": "id", "clientSecret": "secret"},
+  "polling": {"intervalSeconds": 60},
+  "log": {"level": "info"},
+  "streamers": [{
+    "username": "streamer",
+    "webhooks": [
+      {"name": "live", "url": "https://discord.com/api/webhooks/1/live",
+       "notifications": {"online": true, "offline": true, "titleChange": true, "gameChange": true}},
+      {"name": "updates", "url": "https://discord.com/api/webhooks/1/updates",
+       "notifications": {"online": true}}
+    ],
+    "routes": [{"types": ["online"], "webhooks": ["live"]}]
+  }]
+}`
+
+func writeRoutesTestConfig(t *testing.T) string {
+	t.Helper()
+	return writeTestConfig(t, routesTestConfig)
+}
+
+func TestWithRoutesResolvesCopy(t *testing.T) {
+	cfg, err := Load(writeRoutesTestConfig(t))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	routed := cfg.WithRoutes()
+	live := routed.Streamers[0].Webhooks[0].Notifications
+	if !live.Online || live.Offline || live.TitleChange || live.GameChange {
+		t.Errorf("routed live = %+v, want online only", live)
+	}
+	if got := routed.Streamers[0].Webhooks[1].Notifications; !got.Online {
+		t.Errorf("unrouted webhook lost its own settings: %+v", got)
+	}
+
+	orig := cfg.Streamers[0].Webhooks[0].Notifications
+	if !orig.Offline || !orig.TitleChange || !orig.GameChange {
+		t.Errorf("WithRoutes modified the original config: %+v", orig)
+	}
+}
+
+func TestWithLockKeepsRoutedWebhookSettings(t *testing.T) {
+	path := writeRoutesTestConfig(t)
+	if err := WithLock(path, func(*Config) error { return nil }); err != nil {
+		t.Fatalf("WithLock: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	n := cfg.Streamers[0].Webhooks[0].Notifications
+	if !n.Online || !n.Offline || !n.TitleChange || !n.GameChange {
+		t.Errorf("saved notifications = %+v, want all four enabled as written", n)
+	}
+}
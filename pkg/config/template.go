@@ -0,0 +1,23 @@
+package config
+
+import (
+	"encoding/json"
+	"text/template"
+)
+
+// templateFuncs はgeneric Webhookのボディテンプレートで使える関数。
+var templateFuncs = template.FuncMap{
+	// json は値をJSONリテラルとして出力する。文字列のエスケープ用。
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	},
+}
+
+// ParseBodyTemplate はgeneric Webhookのボディテンプレートを解析する。
+func ParseBodyTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+}
@@ -0,0 +1,23 @@
+package config
+
+import "fmt"
+
+// Op は設定への変更操作。エラーを返すとApply全体が取り消される。
+type Op func(cfg *Config) error
+
+// Apply はpathの設定を読み込み、opsをメモリ上で順に適用・検証し、全て成功した場合のみ一度だけ保存する。
+// 途中で失敗した場合は何も保存しないため、設定ファイルは元の状態のまま残る。
+func Apply(path string, ops ...Op) error {
+	return WithLock(path, func(cfg *Config) error {
+		for i, op := range ops {
+			if err := op(cfg); err != nil {
+				return fmt.Errorf("変更%d/%d件目で失敗したため全ての変更を取り消しました: %w", i+1, len(ops), err)
+			}
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("変更後の設定が不正なため全ての変更を取り消しました: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,311 @@
+package discord
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+	"github.com/yuu1111/StreamNotifier/pkg/monitor"
+)
+
+// EmbedBuilder は通知タイプ固有の内容(本文・フィールド・画像など)をEmbedに設定する。
+// embedにはタイトル・URL・色・Author・タイムスタンプの共通部分が設定済みで渡される。
+// プラットフォーム表示とアクセントカラーはビルダーの後にBuildEmbedが適用する。
+type EmbedBuilder func(embed *Embed, change monitor.DetectedChange, opts EmbedOptions)
+
+var (
+	embedBuildersMu sync.RWMutex
+	// embedBuilders は通知タイプごとのEmbedビルダー。
+	embedBuilders = map[config.ChangeType]EmbedBuilder{
+		config.ChangeOnline:            buildOnlineEmbed,
+		config.ChangeOffline:           buildOfflineEmbed,
+		config.ChangeTitleChange:       buildTitleChangeEmbed,
+		config.ChangeGameChange:        buildGameChangeEmbed,
+		config.ChangeTitleAndGame:      buildTitleAndGameEmbed,
+		config.ChangeScheduledReminder: buildScheduledReminderEmbed,
+		config.ChangeProfileUpdate:     buildProfileUpdateEmbed,
+		config.ChangeReconnect:         buildReconnectEmbed,
+		config.ChangeStillLive:         buildStillLiveEmbed,
+		config.ChangeVodPublished:      buildVodPublishedEmbed,
+	}
+)
+
+// RegisterEmbedBuilder は通知タイプのEmbedビルダーを登録する。登録済みのタイプは置き換える。
+// 新しい通知タイプの追加や、既存タイプの表示をカスタマイズする場合に使う。
+func RegisterEmbedBuilder(changeType config.ChangeType, fn EmbedBuilder) {
+	embedBuildersMu.Lock()
+	defer embedBuildersMu.Unlock()
+	embedBuilders[changeType] = fn
+}
+
+// lookupEmbedBuilder は通知タイプのEmbedビルダーを返す。
+func lookupEmbedBuilder(changeType config.ChangeType) (EmbedBuilder, bool) {
+	embedBuildersMu.RLock()
+	defer embedBuildersMu.RUnlock()
+	fn, ok := embedBuilders[changeType]
+	return fn, ok
+}
+
+// buildOnlineEmbed は配信開始のEmbedを構築する。
+func buildOnlineEmbed(embed *Embed, change monitor.DetectedChange, opts EmbedOptions) {
+	state := change.CurrentState
+	m := opts.messages()
+	links, title := opts.titleLinks(state.Title)
+	embed.Description = orDefault(title, m.noTitle)
+
+	// available は表示できる値のある項目。並び順はOnlineFieldOrderに従う
+	available := map[config.OnlineField]EmbedField{
+		config.OnlineFieldGame:    {Name: m.fieldGame, Value: orDefault(state.GameName, m.notSet), Inline: true},
+		config.OnlineFieldViewers: {Name: m.fieldViewers, Value: strconv.Itoa(state.ViewerCount), Inline: true},
+	}
+	if state.StartedAt != "" {
+		startTime, err := time.Parse(time.RFC3339, state.StartedAt)
+		if err == nil {
+			available[config.OnlineFieldStartTime] = EmbedField{Name: m.fieldStartTime, Value: opts.formatTime(startTime), Inline: true}
+
+			if elapsed := formatElapsedTime(state.StartedAt, m); elapsed != "" {
+				embed.Footer = &EmbedFooter{Text: elapsed}
+			}
+		}
+	}
+	if state.Language != "" {
+		available[config.OnlineFieldLanguage] = EmbedField{Name: m.fieldLanguage, Value: state.Language, Inline: true}
+	}
+	if change.FollowerCount != nil {
+		available[config.OnlineFieldFollowers] = EmbedField{Name: m.fieldFollowers, Value: strconv.Itoa(*change.FollowerCount), Inline: true}
+	}
+
+	var fields []EmbedField
+	for _, f := range config.OnlineFieldOrder(opts.OnlineFieldOrder) {
+		if field, ok := available[f]; ok && opts.showOnlineField(f) {
+			fields = append(fields, field)
+		}
+	}
+
+	if len(links) > 0 {
+		fields = append(fields, buildLinksField(links, m))
+	}
+
+	embed.Fields = fields
+	if opts.showOnlineField(config.OnlineFieldThumbnail) {
+		embed.Image = streamPreview(state, opts)
+	}
+}
+
+// streamPreview は配信プレビュー画像を返す。プレビューのURLがなければnil。
+func streamPreview(state monitor.StreamerState, opts EmbedOptions) *EmbedImage {
+	if state.ThumbnailURL == "" {
+		return nil
+	}
+	thumbnailURL := strings.ReplaceAll(state.ThumbnailURL, "{width}", config.ThumbnailWidth)
+	thumbnailURL = strings.ReplaceAll(thumbnailURL, "{height}", config.ThumbnailHeight)
+	if opts.ThumbnailCacheBust {
+		// Discordは画像をURL単位でキャッシュするため、送信ごとに異なるURLにして最新のプレビューを取得させる
+		thumbnailURL = appendQuery(thumbnailURL, "t", strconv.FormatInt(now().Unix(), 10))
+	}
+	return &EmbedImage{URL: thumbnailURL}
+}
+
+// buildReconnectEmbed は短時間の中断からの配信再開のEmbedを構築する。
+func buildReconnectEmbed(embed *Embed, change monitor.DetectedChange, opts EmbedOptions) {
+	state := change.CurrentState
+	m := opts.messages()
+	embed.Description = m.reconnected
+	embed.Fields = []EmbedField{
+		{Name: m.fieldGame, Value: orDefault(state.GameName, m.notSet), Inline: true},
+		{Name: m.fieldDowntime, Value: formatDowntime(change.OfflineDuration, m), Inline: true},
+	}
+	if elapsed := formatElapsedTime(change.StreamStartedAt, m); elapsed != "" {
+		embed.Footer = &EmbedFooter{Text: elapsed}
+	}
+	embed.Image = streamPreview(state, opts)
+}
+
+// buildStillLiveEmbed は長時間配信の再告知のEmbedを構築する。現在の配信時間・視聴者数を表示する。
+func buildStillLiveEmbed(embed *Embed, change monitor.DetectedChange, opts EmbedOptions) {
+	state := change.CurrentState
+	m := opts.messages()
+	links, title := opts.titleLinks(state.Title)
+	embed.Description = orDefault(title, m.noTitle)
+
+	fields := []EmbedField{
+		{Name: m.fieldGame, Value: orDefault(state.GameName, m.notSet), Inline: true},
+	}
+	if state.StartedAt != "" {
+		fields = append(fields, EmbedField{Name: m.fieldStreamTime, Value: formatDuration(state.StartedAt, m), Inline: true})
+	}
+	fields = append(fields, EmbedField{Name: m.fieldViewers, Value: strconv.Itoa(state.ViewerCount), Inline: true})
+	if len(links) > 0 {
+		fields = append(fields, buildLinksField(links, m))
+	}
+	embed.Fields = fields
+	if elapsed := formatElapsedTime(state.StartedAt, m); elapsed != "" {
+		embed.Footer = &EmbedFooter{Text: elapsed}
+	}
+	embed.Image = streamPreview(state, opts)
+}
+
+// buildOfflineEmbed は配信終了のEmbedを構築する。
+func buildOfflineEmbed(embed *Embed, change monitor.DetectedChange, opts EmbedOptions) {
+	m := opts.messages()
+	embed.Description = m.streamEnded
+
+	var fields []EmbedField
+	endedAt := now()
+
+	if change.StreamStartedAt != "" {
+		startTime, err := time.Parse(time.RFC3339, change.StreamStartedAt)
+		if err == nil {
+			duration := formatDuration(change.StreamStartedAt, m)
+			fields = append(fields, EmbedField{
+				Name:  m.fieldStreamTime,
+				Value: fmt.Sprintf("%s → %s (%s)", opts.formatTime(startTime), opts.formatTime(endedAt), duration),
+			})
+		} else {
+			fields = append(fields, EmbedField{
+				Name:   m.fieldEndTime,
+				Value:  opts.formatTime(endedAt),
+				Inline: true,
+			})
+		}
+	} else {
+		fields = append(fields, EmbedField{
+			Name:   m.fieldEndTime,
+			Value:  opts.formatTime(endedAt),
+			Inline: true,
+		})
+	}
+
+	if change.VodURL != "" {
+		fields = append(fields, EmbedField{
+			Name:  m.fieldVod,
+			Value: fmt.Sprintf("[%s](%s)", m.watchVod, change.VodURL),
+		})
+	} else {
+		// VODを残さないチャンネルでも通知から辿れるようにする
+		fields = append(fields, EmbedField{
+			Name:  m.fieldChannel,
+			Value: fmt.Sprintf("[%s](%s)", m.watchChannel, embed.URL),
+		})
+	}
+
+	embed.Fields = fields
+
+	if change.VodThumbnailURL != "" {
+		embed.Image = &EmbedImage{URL: change.VodThumbnailURL}
+	}
+}
+
+// buildTitleChangeEmbed はタイトル変更のEmbedを構築する。
+func buildTitleChangeEmbed(embed *Embed, change monitor.DetectedChange, opts EmbedOptions) {
+	m := opts.messages()
+	links, newTitle := opts.titleLinks(change.NewValue)
+	embed.Fields = []EmbedField{
+		{Name: m.fieldBefore, Value: orDefault(change.OldValue, m.none)},
+		{Name: m.fieldAfter, Value: orDefault(newTitle, m.none)},
+	}
+	if len(links) > 0 {
+		embed.Fields = append(embed.Fields, buildLinksField(links, m))
+	}
+}
+
+// buildGameChangeEmbed はゲーム変更のEmbedを構築する。
+func buildGameChangeEmbed(embed *Embed, change monitor.DetectedChange, opts EmbedOptions) {
+	m := opts.messages()
+	embed.Fields = []EmbedField{
+		{Name: m.fieldBefore, Value: orDefault(change.OldValue, m.notSet), Inline: true},
+		{Name: m.fieldAfter, Value: orDefault(change.NewValue, m.notSet), Inline: true},
+	}
+}
+
+// buildTitleAndGameEmbed はタイトル・ゲーム同時変更のEmbedを構築する。
+func buildTitleAndGameEmbed(embed *Embed, change monitor.DetectedChange, opts EmbedOptions) {
+	m := opts.messages()
+	links, newTitle := opts.titleLinks(change.NewTitle)
+	embed.Fields = []EmbedField{
+		{
+			Name:  m.fieldTitle,
+			Value: fmt.Sprintf("%s\n→ %s", orDefault(change.OldTitle, m.none), orDefault(newTitle, m.none)),
+		},
+		{
+			Name:  m.fieldGame,
+			Value: fmt.Sprintf("%s\n→ %s", orDefault(change.OldGame, m.notSet), orDefault(change.NewGame, m.notSet)),
+		},
+	}
+	if len(links) > 0 {
+		embed.Fields = append(embed.Fields, buildLinksField(links, m))
+	}
+}
+
+// buildScheduledReminderEmbed は配信予定リマインダーのEmbedを構築する。
+func buildScheduledReminderEmbed(embed *Embed, change monitor.DetectedChange, opts EmbedOptions) {
+	m := opts.messages()
+	embed.Description = orDefault(change.NewTitle, m.noTitleYet)
+	embed.Fields = []EmbedField{
+		{Name: m.fieldCategory, Value: orDefault(change.NewGame, m.notSet), Inline: true},
+	}
+	if start, err := time.Parse(time.RFC3339, change.ScheduledStartAt); err == nil {
+		embed.Fields = append(embed.Fields, EmbedField{
+			Name:   m.fieldScheduledStart,
+			Value:  fmt.Sprintf("%s (<t:%d:R>)", opts.formatTime(start), start.Unix()),
+			Inline: true,
+		})
+	}
+}
+
+// buildProfileUpdateEmbed は表示名・プロフィール画像変更のEmbedを構築する。
+func buildProfileUpdateEmbed(embed *Embed, change monitor.DetectedChange, opts EmbedOptions) {
+	state := change.CurrentState
+	m := opts.messages()
+	if change.OldValue != change.NewValue {
+		embed.Fields = append(embed.Fields, EmbedField{
+			Name:  m.fieldDisplayName,
+			Value: fmt.Sprintf("%s → %s", orDefault(change.OldValue, m.none), orDefault(change.NewValue, m.none)),
+		})
+	}
+	if change.OldProfileImageURL != state.ProfileImageURL {
+		embed.Fields = append(embed.Fields, EmbedField{
+			Name:  m.fieldProfileImage,
+			Value: fmt.Sprintf(m.profileImageChange, change.OldProfileImageURL),
+		})
+		if state.ProfileImageURL != "" {
+			embed.Thumbnail = &EmbedImage{URL: state.ProfileImageURL}
+		}
+	}
+}
+
+// appendQuery はURLにクエリパラメータを追加する。
+func appendQuery(rawURL, key, value string) string {
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+	return rawURL + sep + url.QueryEscape(key) + "=" + url.QueryEscape(value)
+}
+
+// buildVodPublishedEmbed はアーカイブ公開のEmbedを構築する。リンク先はチャンネルではなくVODにする。
+func buildVodPublishedEmbed(embed *Embed, change monitor.DetectedChange, opts EmbedOptions) {
+	m := opts.messages()
+	embed.Description = orDefault(change.NewTitle, m.noTitle)
+	if change.VodURL != "" {
+		embed.URL = change.VodURL
+		embed.Fields = append(embed.Fields, EmbedField{
+			Name:  m.fieldVod,
+			Value: fmt.Sprintf("[%s](%s)", m.watchVod, change.VodURL),
+		})
+	}
+	if change.VodDuration > 0 {
+		embed.Fields = append(embed.Fields, EmbedField{
+			Name:   m.fieldStreamTime,
+			Value:  formatMinutes(int(change.VodDuration.Minutes()), m),
+			Inline: true,
+		})
+	}
+	if change.VodThumbnailURL != "" {
+		embed.Image = &EmbedImage{URL: change.VodThumbnailURL}
+	}
+}
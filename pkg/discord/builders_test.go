@@ -0,0 +1,60 @@
+package discord
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+	"github.com/yuu1111/StreamNotifier/pkg/monitor"
+)
+
+func TestEmbedBuildersRegistered(t *testing.T) {
+	for _, tc := range goldenCases() {
+		if _, ok := lookupEmbedBuilder(tc.change.Type); !ok {
+			t.Errorf("no builder registered for %s", tc.change.Type)
+		}
+	}
+}
+
+// TestBuildEmbedRegistryMatchesGolden はBuildEmbedが通知タイプごとの処理をレジストリからのみ引き、
+// 登録されたビルダーを差し替えて戻した後もゴールデンファイルとバイト単位で一致することを確認する。
+func TestBuildEmbedRegistryMatchesGolden(t *testing.T) {
+	useGoldenClock(t)
+
+	for _, tc := range goldenCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			orig, _ := lookupEmbedBuilder(tc.change.Type)
+			RegisterEmbedBuilder(tc.change.Type, func(embed *Embed, _ monitor.DetectedChange, _ EmbedOptions) {
+				embed.Description = "custom"
+			})
+			if got := BuildEmbed(tc.change, goldenOptions(config.ThemeDark)); got.Description != "custom" {
+				t.Errorf("registered builder not used: description = %q", got.Description)
+			}
+			RegisterEmbedBuilder(tc.change.Type, orig)
+
+			want, err := os.ReadFile(filepath.Join("testdata", tc.name+"_"+config.ThemeDark+".golden"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := marshalEmbed(t, BuildEmbed(tc.change, goldenOptions(config.ThemeDark))); !bytes.Equal(got, want) {
+				t.Errorf("output after restoring the builder differs from the golden file\ngot:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestBuildEmbedUnregisteredType(t *testing.T) {
+	useGoldenClock(t)
+
+	change := monitor.DetectedChange{Type: "custom", CurrentState: goldenState()}
+	embed := BuildEmbed(change, goldenOptions(config.ThemeDark))
+	// ビルダーのないタイプは共通部分のみのEmbedになる
+	if embed.Description != "" || embed.Fields != nil || embed.Image != nil {
+		t.Errorf("BuildEmbed(custom) = %+v, want only the common parts", embed)
+	}
+	if embed.URL != "https://twitch.tv/streamer" || embed.Author == nil || embed.Author.Name != "Streamer" {
+		t.Errorf("BuildEmbed(custom) common parts = %+v", embed)
+	}
+}
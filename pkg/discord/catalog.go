@@ -0,0 +1,186 @@
+package discord
+
+import "github.com/yuu1111/StreamNotifier/pkg/config"
+
+// messages はEmbedに表示する文言の言語別カタログ。
+type messages struct {
+	// titles は通知タイプ別のEmbedタイトル。
+	titles map[config.ChangeType]string
+	// contents は通知タイプ別の本文(content)の要約の書式。配信者の表示名を埋め込む。
+	contents map[config.ChangeType]string
+
+	// 値がない場合の表示
+	noTitle      string
+	noTitleYet   string
+	notSet       string
+	none         string
+	unknown      string
+	streamEnded  string
+	live         string
+	watchVod     string
+	watchChannel string
+	reconnected  string
+	// profileImageChange はプロフィール画像変更の表示書式。変更前の画像URLを埋め込む。
+	profileImageChange string
+
+	// フィールド名
+	fieldGame           string
+	fieldStartTime      string
+	fieldStreamTime     string
+	fieldEndTime        string
+	fieldVod            string
+	fieldChannel        string
+	fieldBefore         string
+	fieldAfter          string
+	fieldTitle          string
+	fieldCategory       string
+	fieldScheduledStart string
+	fieldDisplayName    string
+	fieldProfileImage   string
+	fieldLinks          string
+	fieldDowntime       string
+	fieldViewers        string
+	fieldLanguage       string
+	fieldFollowers      string
+
+	// seconds・minutes・hoursMinutes は配信時間の書式。
+	seconds      string
+	minutes      string
+	hoursMinutes string
+	// liveFor は経過時間から配信中のフッターを作る書式。
+	liveFor string
+	// justStarted は開始から1分未満の配信中のフッター。
+	justStarted string
+}
+
+// catalogs は言語ごとの文言カタログ。
+var catalogs = map[config.Language]*messages{
+	config.LanguageJa: {
+		titles: map[config.ChangeType]string{
+			config.ChangeOnline:            "配信開始",
+			config.ChangeOffline:           "配信終了",
+			config.ChangeTitleChange:       "タイトル変更",
+			config.ChangeGameChange:        "ゲーム変更",
+			config.ChangeTitleAndGame:      "タイトル・ゲーム変更",
+			config.ChangeScheduledReminder: "まもなく配信予定",
+			config.ChangeProfileUpdate:     "プロフィール更新",
+			config.ChangeReconnect:         "配信復帰",
+			config.ChangeStillLive:         "まだ配信中",
+			config.ChangeVodPublished:      "アーカイブ公開",
+		},
+		contents: map[config.ChangeType]string{
+			config.ChangeOnline:            "🔴 %s が配信開始",
+			config.ChangeOffline:           "⚫ %s の配信が終了",
+			config.ChangeTitleChange:       "✏️ %s がタイトルを変更",
+			config.ChangeGameChange:        "🎮 %s がゲームを変更",
+			config.ChangeTitleAndGame:      "✏️ %s がタイトル・ゲームを変更",
+			config.ChangeScheduledReminder: "⏰ %s がまもなく配信予定",
+			config.ChangeProfileUpdate:     "👤 %s がプロフィールを更新",
+			config.ChangeReconnect:         "🔴 %s の配信が復帰",
+			config.ChangeStillLive:         "🔴 %s がまだ配信中",
+			config.ChangeVodPublished:      "📼 %s のアーカイブが公開",
+		},
+		noTitle:             "(タイトルなし)",
+		noTitleYet:          "(タイトル未定)",
+		notSet:              "(未設定)",
+		none:                "(なし)",
+		unknown:             "不明",
+		streamEnded:         "配信が終了しました",
+		live:                "配信中",
+		watchVod:            "この配信を見る",
+		watchChannel:        "チャンネルを見る",
+		reconnected:         "配信が復帰しました",
+		profileImageChange:  "[変更前](%s) → 変更後(右上)",
+		fieldGame:           "ゲーム",
+		fieldStartTime:      "開始時刻",
+		fieldStreamTime:     "配信時間",
+		fieldEndTime:        "終了時刻",
+		fieldVod:            "VOD",
+		fieldChannel:        "チャンネル",
+		fieldBefore:         "変更前",
+		fieldAfter:          "変更後",
+		fieldTitle:          "タイトル",
+		fieldCategory:       "カテゴリ",
+		fieldScheduledStart: "開始予定",
+		fieldDisplayName:    "表示名",
+		fieldProfileImage:   "プロフィール画像",
+		fieldLinks:          "関連リンク",
+		fieldDowntime:       "中断",
+		fieldViewers:        "視聴者数",
+		fieldLanguage:       "言語",
+		fieldFollowers:      "フォロワー数",
+		seconds:             "%d秒",
+		minutes:             "%d分",
+		hoursMinutes:        "%d時間%d分",
+		liveFor:             "%s前から配信中",
+		justStarted:         "たった今配信開始",
+	},
+	config.LanguageEn: {
+		titles: map[config.ChangeType]string{
+			config.ChangeOnline:            "Live now",
+			config.ChangeOffline:           "Stream ended",
+			config.ChangeTitleChange:       "Title changed",
+			config.ChangeGameChange:        "Category changed",
+			config.ChangeTitleAndGame:      "Title & category changed",
+			config.ChangeScheduledReminder: "Starting soon",
+			config.ChangeProfileUpdate:     "Profile updated",
+			config.ChangeReconnect:         "Stream reconnected",
+			config.ChangeStillLive:         "Still live",
+			config.ChangeVodPublished:      "VOD published",
+		},
+		contents: map[config.ChangeType]string{
+			config.ChangeOnline:            "🔴 %s is live",
+			config.ChangeOffline:           "⚫ %s ended the stream",
+			config.ChangeTitleChange:       "✏️ %s changed the title",
+			config.ChangeGameChange:        "🎮 %s changed the category",
+			config.ChangeTitleAndGame:      "✏️ %s changed the title & category",
+			config.ChangeScheduledReminder: "⏰ %s is starting soon",
+			config.ChangeProfileUpdate:     "👤 %s updated their profile",
+			config.ChangeReconnect:         "🔴 %s is back live",
+			config.ChangeStillLive:         "🔴 %s is still live",
+			config.ChangeVodPublished:      "📼 %s published a VOD",
+		},
+		noTitle:             "(no title)",
+		noTitleYet:          "(title TBD)",
+		notSet:              "(not set)",
+		none:                "(none)",
+		unknown:             "unknown",
+		streamEnded:         "The stream has ended",
+		live:                "Live",
+		watchVod:            "Watch this stream",
+		watchChannel:        "Visit channel",
+		reconnected:         "The stream is back",
+		profileImageChange:  "[Before](%s) → After (top right)",
+		fieldGame:           "Category",
+		fieldStartTime:      "Started",
+		fieldStreamTime:     "Duration",
+		fieldEndTime:        "Ended",
+		fieldVod:            "VOD",
+		fieldChannel:        "Channel",
+		fieldBefore:         "Before",
+		fieldAfter:          "After",
+		fieldTitle:          "Title",
+		fieldCategory:       "Category",
+		fieldScheduledStart: "Scheduled",
+		fieldDisplayName:    "Display name",
+		fieldProfileImage:   "Profile image",
+		fieldLinks:          "Links",
+		fieldDowntime:       "Downtime",
+		fieldViewers:        "Viewers",
+		fieldLanguage:       "Language",
+		fieldFollowers:      "Followers",
+		seconds:             "%ds",
+		minutes:             "%dm",
+		hoursMinutes:        "%dh %dm",
+		liveFor:             "Live for %s",
+		justStarted:         "Just went live",
+	},
+}
+
+// messages は表示言語の文言カタログを返す。未指定・未知の言語は日本語とする。
+func (o EmbedOptions) messages() *messages {
+	if m, ok := catalogs[o.Language]; ok {
+		return m
+	}
+	return catalogs[config.LanguageJa]
+}
@@ -0,0 +1,70 @@
+package discord
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+	"github.com/yuu1111/StreamNotifier/pkg/monitor"
+)
+
+// BuildContent は変更情報からWebhookの本文(content)に入れるプレーンテキストの要約を構築する。
+// Embedを読み上げられないスクリーンリーダーやモバイルのプッシュ通知のプレビュー向け。
+// Discordの本文の上限に収まらなければ、タイトルなどの詳細を切り詰める。
+func BuildContent(change monitor.DetectedChange, opts EmbedOptions) string {
+	state := change.CurrentState
+	m := opts.messages()
+	name := orDefault(state.DisplayName, change.Streamer)
+
+	var headline string
+	if format, ok := m.contents[change.Type]; ok {
+		headline = fmt.Sprintf(format, name)
+	} else {
+		headline = fmt.Sprintf("%s: %s", name, orDefault(m.titles[change.Type], change.Type))
+	}
+	link := "https://twitch.tv/" + state.Username
+	if (change.Type == config.ChangeOffline || change.Type == config.ChangeVodPublished) && change.VodURL != "" {
+		link = change.VodURL
+	}
+
+	detail := contentDetail(change, m)
+	if detail == "" {
+		return headline + " " + link
+	}
+	budget := maxContentChars - utf8.RuneCountInString(headline+": "+" "+link)
+	if utf8.RuneCountInString(detail) > budget {
+		if budget <= 1 {
+			return headline + " " + link
+		}
+		detail = string([]rune(detail)[:budget-1]) + "…"
+	}
+	return headline + ": " + detail + " " + link
+}
+
+// contentDetail は本文の要約に添えるタイトル・ゲームなどの詳細を返す。詳細のない通知タイプは空文字。
+func contentDetail(change monitor.DetectedChange, m *messages) string {
+	state := change.CurrentState
+	switch change.Type {
+	case config.ChangeOnline, config.ChangeReconnect, config.ChangeStillLive:
+		return withGame(orDefault(state.Title, m.noTitle), state.GameName)
+	case config.ChangeTitleAndGame:
+		return withGame(orDefault(change.NewTitle, m.noTitle), change.NewGame)
+	case config.ChangeTitleChange:
+		return orDefault(change.NewTitle, m.noTitle)
+	case config.ChangeGameChange:
+		return orDefault(change.NewGame, m.notSet)
+	case config.ChangeScheduledReminder:
+		return withGame(orDefault(change.NewTitle, m.noTitleYet), change.NewGame)
+	case config.ChangeVodPublished:
+		return orDefault(change.NewTitle, m.noTitle)
+	}
+	return ""
+}
+
+// withGame はタイトルの後にゲーム名を括弧書きで付ける。ゲーム名が空ならタイトルのみ。
+func withGame(title, game string) string {
+	if game == "" {
+		return title
+	}
+	return fmt.Sprintf("%s (%s)", title, game)
+}
@@ -0,0 +1,73 @@
+package discord
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+// ErrWebhookDisabled は削除・無効化されたWebhookへの送信を停止していることを表す。
+var ErrWebhookDisabled = errors.New("Webhookが削除または無効化されたため送信を停止しています")
+
+// deadWebhooks は404/401が続いたWebhookの記録。送信の停止はプロセスの実行中のみ有効で、設定ファイルは変更しない。
+var deadWebhooks = struct {
+	mu sync.Mutex
+	// threshold は送信を停止するまでの404/401の連続回数。
+	threshold int
+	// failures はURLごとの404/401の連続回数。
+	failures map[string]int
+	// disabled は送信を停止したURL。
+	disabled map[string]bool
+}{
+	threshold: 3,
+	failures:  make(map[string]int),
+	disabled:  make(map[string]bool),
+}
+
+// SetDeadWebhookThreshold はWebhookへの送信を停止するまでの404/401の連続回数を設定する。1未満は1とみなす。
+func SetDeadWebhookThreshold(n int) {
+	deadWebhooks.mu.Lock()
+	defer deadWebhooks.mu.Unlock()
+	deadWebhooks.threshold = max(n, 1)
+}
+
+// IsWebhookDisabled はWebhookへの送信を停止しているかを返す。
+func IsWebhookDisabled(webhookURL string) bool {
+	deadWebhooks.mu.Lock()
+	defer deadWebhooks.mu.Unlock()
+	return deadWebhooks.disabled[webhookURL]
+}
+
+// isWebhookGone はWebhookが削除された(404)かトークンが無効(401)であることを示すエラーかを返す。
+func isWebhookGone(err error) bool {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	return statusErr.StatusCode == http.StatusNotFound || statusErr.StatusCode == http.StatusUnauthorized
+}
+
+// observeWebhookResult は送信結果を記録し、404/401が閾値の回数続いたWebhookへの送信を停止する。
+// 停止したときは一度だけ警告を出力する。
+func observeWebhookResult(webhookURL string, err error) {
+	deadWebhooks.mu.Lock()
+	defer deadWebhooks.mu.Unlock()
+
+	if !isWebhookGone(err) {
+		// 一時的なエラーでは削除の判断ができないため、連続回数は成功時のみリセットする
+		if err == nil {
+			delete(deadWebhooks.failures, webhookURL)
+		}
+		return
+	}
+	deadWebhooks.failures[webhookURL]++
+	if deadWebhooks.failures[webhookURL] < deadWebhooks.threshold || deadWebhooks.disabled[webhookURL] {
+		return
+	}
+	deadWebhooks.disabled[webhookURL] = true
+	slog.Error("Webhookが削除または無効化されているため、再起動するまで送信を停止します。設定から削除するか、新しいURLに置き換えてください",
+		"url", truncate(webhookURL, 50),
+		"failures", deadWebhooks.failures[webhookURL],
+		"error", err)
+}
@@ -0,0 +1,136 @@
+package discord
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DeadLetterEntry は最終的に送信できなかったWebhook送信。デッドレターファイルに1行のJSONとして書き出す。
+type DeadLetterEntry struct {
+	WebhookURL string       `json:"webhookUrl"`
+	Embed      Embed        `json:"embed"`
+	Streamer   StreamerInfo `json:"streamer"`
+	// Key は最初の送信時の冪等キー。再送でも同じキーを使う。
+	Key       string    `json:"key,omitempty"`
+	FailedAt  time.Time `json:"failedAt"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"lastError"`
+}
+
+// deadLetter は書き出し先のデッドレターファイル。pathが空なら書き出さない。
+var deadLetter = struct {
+	mu         sync.Mutex
+	path       string
+	maxEntries int
+}{}
+
+// SetDeadLetter は最終的に送信できなかったWebhook送信をpathへ書き出すよう設定する。
+// ファイルはmaxEntries件を超えたら古いものから削除する。pathが空なら書き出さない。
+func SetDeadLetter(path string, maxEntries int) {
+	deadLetter.mu.Lock()
+	defer deadLetter.mu.Unlock()
+	deadLetter.path = path
+	deadLetter.maxEntries = max(maxEntries, 1)
+}
+
+// recordDeadLetter はデッドレターファイルが設定されていれば、送信できなかったWebhook送信を書き出す。
+// 削除・無効化されたWebhookへの送信は再送しても届かないため書き出さない。
+func recordDeadLetter(webhookURL string, embed Embed, streamer StreamerInfo, key string, attempts int, sendErr error) {
+	if errors.Is(sendErr, ErrWebhookDisabled) {
+		return
+	}
+	deadLetter.mu.Lock()
+	defer deadLetter.mu.Unlock()
+	if deadLetter.path == "" {
+		return
+	}
+
+	entry := DeadLetterEntry{
+		WebhookURL: webhookURL,
+		Embed:      embed,
+		Streamer:   streamer,
+		Key:        key,
+		FailedAt:   time.Now(),
+		Attempts:   attempts,
+		LastError:  sendErr.Error(),
+	}
+	if err := appendDeadLetter(deadLetter.path, entry, deadLetter.maxEntries); err != nil {
+		slog.Error("デッドレターファイルへの書き出しに失敗", "path", deadLetter.path, "error", err)
+		return
+	}
+	slog.Warn("送信できなかった通知をデッドレターファイルに書き出しました", "url", truncate(webhookURL, 50), "path", deadLetter.path)
+}
+
+// RecordDeadLetter は送信できなかったWebhook送信をデッドレターファイルに書き出す。SetDeadLetterで未設定なら何もしない。
+func RecordDeadLetter(webhookURL string, embed Embed, streamer StreamerInfo, key string, sendErr error) {
+	recordDeadLetter(webhookURL, embed, streamer, key, 1, sendErr)
+}
+
+// appendDeadLetter はentryをpathに追記し、maxEntries件を超えた古いエントリを削除する。
+func appendDeadLetter(path string, entry DeadLetterEntry, maxEntries int) error {
+	entries, err := ReadDeadLetters(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	entries = append(entries, entry)
+	if len(entries) > maxEntries {
+		entries = entries[len(entries)-maxEntries:]
+	}
+	return WriteDeadLetters(path, entries)
+}
+
+// ReadDeadLetters はデッドレターファイルのエントリを読み込む。
+func ReadDeadLetters(path string) ([]DeadLetterEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []DeadLetterEntry
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var entry DeadLetterEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("デッドレターファイルの解析に失敗 (%d行目): %w", i+1, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// WriteDeadLetters はデッドレターファイルをentriesで置き換える。entriesが空ならファイルを削除する。
+func WriteDeadLetters(path string, entries []DeadLetterEntry) error {
+	if len(entries) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return fmt.Errorf("デッドレターのJSON変換に失敗: %w", err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// 書き込み途中で落ちてもファイルが壊れないよう一時ファイル経由で置き換える
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,412 @@
+// Package discord はDiscord Webhook連携を提供する。
+package discord
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+	"github.com/yuu1111/StreamNotifier/pkg/monitor"
+)
+
+// EmbedField はDiscord Embedのフィールド。
+type EmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+// EmbedFooter はDiscord Embedのフッター。
+type EmbedFooter struct {
+	Text    string `json:"text"`
+	IconURL string `json:"icon_url,omitempty"`
+}
+
+// EmbedAuthor はDiscord Embedの作成者情報。
+type EmbedAuthor struct {
+	Name    string `json:"name"`
+	URL     string `json:"url,omitempty"`
+	IconURL string `json:"icon_url,omitempty"`
+}
+
+// EmbedImage はDiscord Embedの画像。
+type EmbedImage struct {
+	URL string `json:"url"`
+}
+
+// Embed はDiscord Embed構造。
+type Embed struct {
+	Title       string       `json:"title"`
+	Description string       `json:"description,omitempty"`
+	URL         string       `json:"url,omitempty"`
+	Color       int          `json:"color"`
+	Thumbnail   *EmbedImage  `json:"thumbnail,omitempty"`
+	Image       *EmbedImage  `json:"image,omitempty"`
+	Fields      []EmbedField `json:"fields,omitempty"`
+	Timestamp   string       `json:"timestamp,omitempty"`
+	Footer      *EmbedFooter `json:"footer,omitempty"`
+	Author      *EmbedAuthor `json:"author,omitempty"`
+}
+
+// EmbedOptions はEmbed構築時の表示オプション。
+type EmbedOptions struct {
+	// ShowPlatform はタイトルにプラットフォーム絵文字を付け、AuthorアイコンとEmbed色にプラットフォーム設定を反映するか。
+	ShowPlatform bool
+	// Platforms はプラットフォーム別の表示設定の上書き。
+	Platforms map[config.Platform]config.PlatformStyle
+	// Color は配信者/Webhook別のアクセントカラー。nilなら通知タイプ別の色を使う。
+	Color *int
+	// ExtractLinks はタイトル内のURLを「関連リンク」フィールドに抽出するか。
+	ExtractLinks bool
+	// StripLinks はExtractLinks有効時に本文からURLを取り除くか。
+	StripLinks bool
+	// Theme は通知タイプ別のデフォルト色の表示モード。空ならdark。
+	Theme config.Theme
+	// FooterText は全Embedのフッターに付ける固定テキスト。
+	FooterText string
+	// FooterIconURL はフッターのアイコン画像URL。
+	FooterIconURL string
+	// ThumbnailCacheBust は配信プレビュー画像のURLにタイムスタンプを付けてDiscordのキャッシュを回避するか。
+	ThumbnailCacheBust bool
+	// Language は表示言語。空ならja。Webhookごとに設定する。
+	Language config.Language
+	// OnlineFields は配信開始Embedに表示する項目。空ならconfig.DefaultOnlineFields。
+	OnlineFields []config.OnlineField
+	// OnlineFieldOrder は配信開始Embedのフィールドの並び順。空ならconfig.DefaultOnlineFieldOrder。
+	OnlineFieldOrder []config.OnlineField
+	// CategoryColors は小文字のゲーム名から色への対応。配信開始・ゲーム変更時に他の色より優先する。
+	CategoryColors map[string]int
+	// ShowStreamID は配信IDのある通知のフッターに配信IDを表示するか。
+	ShowStreamID bool
+	// IncludeContent はEmbedに加えて、BuildContentの要約を本文に入れるか。
+	IncludeContent bool
+	// Location は開始時刻などの時刻表示に使うタイムゾーン。nilならconfig.DefaultLocation(JST)。
+	Location *time.Location
+}
+
+// NewEmbedOptions は設定からEmbedOptionsを構築する。
+func NewEmbedOptions(cfg *config.Config) EmbedOptions {
+	return EmbedOptions{
+		ShowPlatform:       cfg.Notifications.ShowPlatform,
+		Platforms:          cfg.Notifications.Platforms,
+		ExtractLinks:       cfg.Notifications.ExtractLinks,
+		StripLinks:         cfg.Notifications.StripLinks,
+		Theme:              cfg.Notifications.Theme,
+		FooterText:         cfg.Notifications.FooterText,
+		FooterIconURL:      cfg.Notifications.FooterIconURL,
+		ThumbnailCacheBust: cfg.Notifications.ThumbnailCacheBust,
+		OnlineFields:       cfg.Notifications.OnlineFields,
+		OnlineFieldOrder:   cfg.Notifications.OnlineFieldOrder,
+		CategoryColors:     parseCategoryColors(cfg.Notifications.CategoryColors),
+		ShowStreamID:       cfg.Notifications.ShowStreamID,
+		IncludeContent:     cfg.Notifications.IncludeContent,
+		Location:           cfg.Location(),
+	}
+}
+
+// parseCategoryColors はゲーム名ごとの色を解析する。キーは照合用に小文字にする。
+// 設定はLoad時に検証済みのため、解析できない色は無視する。
+func parseCategoryColors(colors map[string]string) map[string]int {
+	if len(colors) == 0 {
+		return nil
+	}
+	parsed := make(map[string]int, len(colors))
+	for game, hex := range colors {
+		if color, err := config.ParseHexColor(hex); err == nil {
+			parsed[strings.ToLower(strings.TrimSpace(game))] = color
+		}
+	}
+	return parsed
+}
+
+// categoryColorTypes はゲーム別の色を適用するイベント種別。
+var categoryColorTypes = map[string]bool{
+	config.ChangeOnline:       true,
+	config.ChangeStillLive:    true,
+	config.ChangeGameChange:   true,
+	config.ChangeTitleAndGame: true,
+}
+
+// categoryColor は現在のゲームに設定された色を返す。対象外のイベントや未設定のゲームならokはfalse。
+func (o EmbedOptions) categoryColor(change monitor.DetectedChange) (color int, ok bool) {
+	if !categoryColorTypes[change.Type] || change.CurrentState.GameName == "" {
+		return 0, false
+	}
+	color, ok = o.CategoryColors[strings.ToLower(change.CurrentState.GameName)]
+	return color, ok
+}
+
+// showOnlineField は配信開始Embedに項目を表示するかを返す。
+func (o EmbedOptions) showOnlineField(field config.OnlineField) bool {
+	fields := o.OnlineFields
+	if len(fields) == 0 {
+		fields = config.DefaultOnlineFields
+	}
+	return slices.Contains(fields, field)
+}
+
+// titleLinks はオプションに応じてタイトルからURLを抽出し、表示用のタイトルと合わせて返す。
+func (o EmbedOptions) titleLinks(title string) (links []string, display string) {
+	if !o.ExtractLinks {
+		return nil, title
+	}
+	links, stripped := extractLinks(title)
+	if o.StripLinks {
+		return links, stripped
+	}
+	return links, title
+}
+
+// defaultPlatformStyles はプラットフォーム別のデフォルト表示設定。
+var defaultPlatformStyles = map[config.Platform]config.PlatformStyle{
+	config.PlatformTwitch:  {Emoji: "🟣"},
+	config.PlatformYouTube: {Emoji: "🔴"},
+}
+
+// platformStyle はデフォルトに設定の上書きを適用したプラットフォーム表示設定を返す。
+func (o EmbedOptions) platformStyle(platform config.Platform) config.PlatformStyle {
+	style := defaultPlatformStyles[platform]
+	override, ok := o.Platforms[platform]
+	if !ok {
+		return style
+	}
+	if override.Emoji != "" {
+		style.Emoji = override.Emoji
+	}
+	if override.IconURL != "" {
+		style.IconURL = override.IconURL
+	}
+	if override.Color != "" {
+		style.Color = override.Color
+	}
+	return style
+}
+
+// colorSets は表示モード別の通知タイプ色。どちらも背景色とのコントラストを確保した値にしている。
+var colorSets = map[config.Theme]map[string]int{
+	// ダークモード(背景 #313338)向け: 明るめの色
+	config.ThemeDark: {
+		config.ChangeOnline:            0x9146ff,
+		config.ChangeOffline:           0xa3a6aa,
+		config.ChangeTitleChange:       0x57f287,
+		config.ChangeGameChange:        0xff9900,
+		config.ChangeTitleAndGame:      0x00ccff,
+		config.ChangeScheduledReminder: 0xfee75c,
+		config.ChangeProfileUpdate:     0xeb459e,
+		config.ChangeReconnect:         0x5865f2,
+		config.ChangeStillLive:         0xb38cff,
+		config.ChangeVodPublished:      0x1abc9c,
+	},
+	// ライトモード(背景 #ffffff)向け: 暗めの色
+	config.ThemeLight: {
+		config.ChangeOnline:            0x772ce8,
+		config.ChangeOffline:           0x4e5058,
+		config.ChangeTitleChange:       0x1f8b4c,
+		config.ChangeGameChange:        0xc05c00,
+		config.ChangeTitleAndGame:      0x0070a8,
+		config.ChangeScheduledReminder: 0xa67c00,
+		config.ChangeProfileUpdate:     0xad1457,
+		config.ChangeReconnect:         0x3c45a5,
+		config.ChangeStillLive:         0x5c16c5,
+		config.ChangeVodPublished:      0x11806a,
+	},
+}
+
+// typeColor は表示モードに応じた通知タイプ別の色を返す。
+func typeColor(theme config.Theme, changeType config.ChangeType) int {
+	set, ok := colorSets[theme]
+	if !ok {
+		set = colorSets[config.ThemeDark]
+	}
+	return set[changeType]
+}
+
+// accentColorTypes はアクセントカラーの上書きを適用するイベント種別。
+var accentColorTypes = map[string]bool{
+	config.ChangeOnline:       true,
+	config.ChangeReconnect:    true,
+	config.ChangeStillLive:    true,
+	config.ChangeTitleChange:  true,
+	config.ChangeGameChange:   true,
+	config.ChangeTitleAndGame: true,
+}
+
+// changeEventTypes はタイトル/ゲーム変更系のイベント種別。
+var changeEventTypes = map[string]bool{
+	config.ChangeTitleChange:  true,
+	config.ChangeGameChange:   true,
+	config.ChangeTitleAndGame: true,
+}
+
+// now は現在時刻を返す。テストで経過時間やタイムスタンプを固定するために差し替える。
+var now = time.Now
+
+// clockSkewTolerance は開始時刻が現在より後でも、ローカルの時計の遅れとみなして開始直後として扱う範囲。
+const clockSkewTolerance = 5 * time.Minute
+
+// formatElapsedTime は配信開始からの経過時間を配信中のフッター用にフォーマットする。
+// 開始から1分未満なら開始直後と表示する。ローカルの時計が遅れていて開始時刻が少し先になる場合も同様。
+// 開始時刻が不正か、時計のずれでは説明できないほど先なら空文字列を返す。
+func formatElapsedTime(startedAt string, m *messages) string {
+	start, err := time.Parse(time.RFC3339, startedAt)
+	if err != nil {
+		return ""
+	}
+
+	diff := now().Sub(start)
+	if diff <= -clockSkewTolerance {
+		return ""
+	}
+	if diff < time.Minute {
+		return m.justStarted
+	}
+	return fmt.Sprintf(m.liveFor, formatMinutes(int(diff.Minutes()), m))
+}
+
+// formatDuration は配信時間をフォーマットする。
+func formatDuration(startedAt string, m *messages) string {
+	start, err := time.Parse(time.RFC3339, startedAt)
+	if err != nil {
+		return m.unknown
+	}
+	return formatMinutes(int(now().Sub(start).Minutes()), m)
+}
+
+// formatDowntime は配信が途切れていた時間をフォーマットする。1分未満は秒で表す。
+func formatDowntime(d time.Duration, m *messages) string {
+	if d < time.Minute {
+		return fmt.Sprintf(m.seconds, int(d.Seconds()))
+	}
+	return formatMinutes(int(d.Minutes()), m)
+}
+
+// formatMinutes は分数を「2時間3分」のような表示にフォーマットする。
+func formatMinutes(totalMinutes int, m *messages) string {
+	hours := totalMinutes / 60
+	mins := totalMinutes % 60
+
+	if hours == 0 {
+		return fmt.Sprintf(m.minutes, mins)
+	}
+	return fmt.Sprintf(m.hoursMinutes, hours, mins)
+}
+
+// formatTime は時刻を設定のタイムゾーンのHH:MM形式にフォーマットする。
+func (o EmbedOptions) formatTime(t time.Time) string {
+	loc := o.Location
+	if loc == nil {
+		loc = config.DefaultLocation
+	}
+	return t.In(loc).Format("15:04")
+}
+
+// orDefault は空文字列の場合にデフォルト値を返す。
+func orDefault(s, defaultVal string) string {
+	if s == "" {
+		return defaultVal
+	}
+	return s
+}
+
+// applyPlatformStyle はプラットフォーム別の絵文字・アイコン・色をEmbedに反映する。
+func applyPlatformStyle(embed *Embed, platform config.Platform, opts EmbedOptions) {
+	if !opts.ShowPlatform || platform == "" {
+		return
+	}
+
+	style := opts.platformStyle(platform)
+	if style.Emoji != "" {
+		embed.Title = style.Emoji + " " + embed.Title
+	}
+	if style.IconURL != "" && embed.Author != nil {
+		embed.Author.IconURL = style.IconURL
+	}
+	if style.Color != "" {
+		// Validate済みのためエラーは発生しない
+		if color, err := config.ParseHexColor(style.Color); err == nil {
+			embed.Color = color
+		}
+	}
+}
+
+// applyStreamID はShowStreamIDが有効なら配信IDをフッターに付ける。動的なフッターがあれば「 • 」で連結する。
+func applyStreamID(embed *Embed, change monitor.DetectedChange, opts EmbedOptions) {
+	if !opts.ShowStreamID || change.StreamID == "" {
+		return
+	}
+	text := "Stream ID: " + change.StreamID
+	if embed.Footer == nil {
+		embed.Footer = &EmbedFooter{Text: text}
+		return
+	}
+	embed.Footer.Text += " • " + text
+}
+
+// applyFooterBranding は固定のフッターテキストとアイコンをEmbedに反映する。
+// 経過時間などの動的なフッターがあれば「2時間3分前から配信中 • MyServer」のように連結する。
+func applyFooterBranding(embed *Embed, opts EmbedOptions) {
+	if opts.FooterText != "" {
+		if embed.Footer == nil {
+			embed.Footer = &EmbedFooter{Text: opts.FooterText}
+		} else {
+			embed.Footer.Text += " • " + opts.FooterText
+		}
+	}
+	// Discordはテキストのないフッターを表示しないため、アイコンはフッターがある場合のみ付ける
+	if opts.FooterIconURL != "" && embed.Footer != nil {
+		embed.Footer.IconURL = opts.FooterIconURL
+	}
+}
+
+// eventTime はEmbedのタイムスタンプに使うイベント発生時刻を返す。
+// 配信開始は実際の開始時刻、それ以外は検出時刻(現在時刻)とする。
+func eventTime(change monitor.DetectedChange) time.Time {
+	if change.Type == config.ChangeOnline {
+		if start, err := time.Parse(time.RFC3339, change.CurrentState.StartedAt); err == nil {
+			return start
+		}
+	}
+	return now()
+}
+
+// BuildEmbed は変更情報からDiscord Embedを構築する。
+func BuildEmbed(change monitor.DetectedChange, opts EmbedOptions) Embed {
+	state := change.CurrentState
+	channelURL := "https://twitch.tv/" + state.Username
+	m := opts.messages()
+
+	embed := Embed{
+		Title:     m.titles[change.Type],
+		URL:       channelURL,
+		Color:     typeColor(opts.Theme, change.Type),
+		Timestamp: eventTime(change).UTC().Format(time.RFC3339),
+		Author: &EmbedAuthor{
+			Name:    state.DisplayName,
+			URL:     channelURL,
+			IconURL: state.ProfileImageURL,
+		},
+	}
+
+	if build, ok := lookupEmbedBuilder(change.Type); ok {
+		build(&embed, change, opts)
+	}
+
+	// タイトル/ゲーム変更時は配信中であればfooterを設定
+	if changeEventTypes[change.Type] && state.IsLive && embed.Footer == nil {
+		embed.Footer = &EmbedFooter{Text: m.live}
+	}
+
+	applyPlatformStyle(&embed, change.Platform, opts)
+	applyStreamID(&embed, change, opts)
+	applyFooterBranding(&embed, opts)
+
+	if color, ok := opts.categoryColor(change); ok {
+		embed.Color = color
+	} else if opts.Color != nil && accentColorTypes[change.Type] {
+		embed.Color = *opts.Color
+	}
+
+	return embed
+}
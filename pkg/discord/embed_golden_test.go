@@ -0,0 +1,177 @@
+package discord
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+	"github.com/yuu1111/StreamNotifier/pkg/monitor"
+)
+
+var update = flag.Bool("update", false, "testdata/*.golden を現在の出力で更新する")
+
+// goldenNow はゴールデンテストの現在時刻。経過時間・終了時刻・タイムスタンプを固定する。
+var goldenNow = time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+
+// goldenCase はゴールデンファイル1件分の入力。ファイル名は<name>_<theme>.golden。
+type goldenCase struct {
+	name   string
+	change monitor.DetectedChange
+}
+
+func goldenState() monitor.StreamerState {
+	return monitor.StreamerState{
+		UserID:          "1234",
+		Username:        "streamer",
+		DisplayName:     "Streamer",
+		ProfileImageURL: "https://static-cdn.jtvnw.net/jtv_user_pictures/streamer.png",
+		IsLive:          true,
+		Title:           "Ranked grind https://example.com/schedule",
+		GameID:          "509658",
+		GameName:        "Just Chatting",
+		StartedAt:       "2026-01-02T09:30:00Z",
+		ThumbnailURL:    "https://static-cdn.jtvnw.net/previews-ttv/live_user_streamer-{width}x{height}.jpg",
+		ViewerCount:     321,
+		Language:        "ja",
+		StreamID:        "40001",
+	}
+}
+
+func goldenCases() []goldenCase {
+	followers := 4567
+	live := goldenState()
+
+	noPreview := goldenState()
+	noPreview.ThumbnailURL = ""
+	noPreview.StartedAt = ""
+
+	offline := goldenState()
+	offline.IsLive = false
+	offline.ThumbnailURL = ""
+	offline.ViewerCount = 0
+	offline.StreamID = ""
+
+	return []goldenCase{
+		{"online", monitor.DetectedChange{
+			Type: config.ChangeOnline, Platform: config.PlatformTwitch, Streamer: "streamer",
+			StreamID: live.StreamID, FollowerCount: &followers, CurrentState: live,
+		}},
+		{"online_no_preview", monitor.DetectedChange{
+			Type: config.ChangeOnline, Platform: config.PlatformTwitch, Streamer: "streamer",
+			StreamID: noPreview.StreamID, CurrentState: noPreview,
+		}},
+		{"offline", monitor.DetectedChange{
+			Type: config.ChangeOffline, Platform: config.PlatformTwitch, Streamer: "streamer",
+			StreamID: "40001", StreamStartedAt: "2026-01-02T09:30:00Z",
+			VodURL:          "https://www.twitch.tv/videos/777",
+			VodThumbnailURL: "https://static-cdn.jtvnw.net/cf_vods/777/thumb.jpg",
+			CurrentState:    offline,
+		}},
+		{"offline_no_vod", monitor.DetectedChange{
+			Type: config.ChangeOffline, Platform: config.PlatformTwitch, Streamer: "streamer",
+			CurrentState: offline,
+		}},
+		{"title_change", monitor.DetectedChange{
+			Type: config.ChangeTitleChange, Platform: config.PlatformTwitch, Streamer: "streamer",
+			OldValue: "Morning stream", NewValue: live.Title, CurrentState: live,
+		}},
+		{"game_change", monitor.DetectedChange{
+			Type: config.ChangeGameChange, Platform: config.PlatformTwitch, Streamer: "streamer",
+			OldValue: "", NewValue: "Just Chatting", CurrentState: live,
+		}},
+		{"title_and_game", monitor.DetectedChange{
+			Type: config.ChangeTitleAndGame, Platform: config.PlatformTwitch, Streamer: "streamer",
+			OldTitle: "Morning stream", NewTitle: live.Title, OldGame: "Minecraft", NewGame: "Just Chatting",
+			CurrentState: live,
+		}},
+		{"scheduled_reminder", monitor.DetectedChange{
+			Type: config.ChangeScheduledReminder, Platform: config.PlatformTwitch, Streamer: "streamer",
+			NewTitle: "Weekly collab", NewGame: "Minecraft", ScheduledStartAt: "2026-01-02T12:30:00Z",
+			CurrentState: offline,
+		}},
+		{"profile_update", monitor.DetectedChange{
+			Type: config.ChangeProfileUpdate, Platform: config.PlatformTwitch, Streamer: "streamer",
+			OldValue: "OldName", NewValue: "Streamer",
+			OldProfileImageURL: "https://static-cdn.jtvnw.net/jtv_user_pictures/old.png",
+			CurrentState:       offline,
+		}},
+		{"reconnect", monitor.DetectedChange{
+			Type: config.ChangeReconnect, Platform: config.PlatformTwitch, Streamer: "streamer",
+			StreamID: live.StreamID, StreamStartedAt: live.StartedAt, OfflineDuration: 95 * time.Second,
+			CurrentState: live,
+		}},
+		{"still_live", monitor.DetectedChange{
+			Type: config.ChangeStillLive, Platform: config.PlatformTwitch, Streamer: "streamer",
+			StreamID: live.StreamID, CurrentState: live,
+		}},
+		{"vod_published", monitor.DetectedChange{
+			Type: config.ChangeVodPublished, Platform: config.PlatformTwitch, Streamer: "streamer",
+			NewValue: "777", NewTitle: "Ranked grind", VodURL: "https://www.twitch.tv/videos/777",
+			VodThumbnailURL: "https://static-cdn.jtvnw.net/cf_vods/777/thumb.jpg", VodDuration: 150 * time.Minute,
+			CurrentState: offline,
+		}},
+	}
+}
+
+// goldenOptions はゴールデンテストのEmbedOptions。時刻表示はUTCに固定する。
+func goldenOptions(theme config.Theme) EmbedOptions {
+	return EmbedOptions{
+		Theme:        theme,
+		ExtractLinks: true,
+		Location:     time.UTC,
+	}
+}
+
+// useGoldenClock はテスト中の現在時刻をgoldenNowに固定する。
+func useGoldenClock(t *testing.T) {
+	t.Helper()
+	orig := now
+	now = func() time.Time { return goldenNow }
+	t.Cleanup(func() { now = orig })
+}
+
+func marshalEmbed(t *testing.T, embed Embed) []byte {
+	t.Helper()
+	data, err := json.MarshalIndent(embed, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(data, '\n')
+}
+
+func TestBuildEmbedGolden(t *testing.T) {
+	useGoldenClock(t)
+
+	for _, theme := range []config.Theme{config.ThemeDark, config.ThemeLight} {
+		for _, tc := range goldenCases() {
+			name := tc.name + "_" + theme
+			t.Run(name, func(t *testing.T) {
+				got := marshalEmbed(t, BuildEmbed(tc.change, goldenOptions(theme)))
+				path := filepath.Join("testdata", name+".golden")
+
+				if *update {
+					if err := os.MkdirAll("testdata", 0755); err != nil {
+						t.Fatal(err)
+					}
+					if err := os.WriteFile(path, got, 0644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+
+				want, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatalf("ゴールデンファイルがありません (-update で作成): %v", err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("%s と一致しません (-update で更新)\ngot:\n%s\nwant:\n%s", path, got, want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package discord
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/monitor"
+)
+
+const (
+	// idempotencyBucket はDetectedAtのない変更の冪等キーに含める時刻の丸め単位。
+	idempotencyBucket = 10 * time.Minute
+	// deliveryRetention は送信済みの記録を保持する時間。リトライキューの再送やデッドレターの再送より長くする。
+	deliveryRetention = 24 * time.Hour
+)
+
+// ErrAlreadySent は同じ冪等キーの通知を同じWebhookへ送信済みのため、送信しなかったことを表す。
+var ErrAlreadySent = errors.New("同じ通知をこのWebhookへ送信済みです")
+
+// IdempotencyKey は変更の内容と検出時刻から決まる冪等キーを返す。
+// 同じ変更は送信先や送信時刻によらず同じキーになり、時間をおいて再び検出した変更(配信の再開など)は別のキーになる。
+// DetectedAtが未設定の変更(Poller以外で作った変更)は、呼び出した時刻をidempotencyBucket単位に丸めて使う。
+func IdempotencyKey(change monitor.DetectedChange) string {
+	detectedAt := change.DetectedAt
+	if detectedAt.IsZero() {
+		detectedAt = time.Now().Truncate(idempotencyBucket)
+	}
+	parts := []string{
+		change.Type,
+		strings.ToLower(change.Streamer),
+		change.OldValue,
+		change.NewValue,
+		change.StreamStartedAt,
+		change.CurrentState.StartedAt,
+		change.ScheduledStartAt,
+		strconv.FormatInt(detectedAt.UnixNano(), 10),
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// deliveries は冪等キーと送信先ごとの送信済みの時刻。
+// Discordの「Webhookの実行」はメッセージ作成と異なりnonceを扱わないため、同じ通知の重複送信はローカルで防ぐ。
+// プロセス内の記録のため、タイムアウトしたが実際には届いていた送信の再送や、再起動をまたいだ再送は防げない。
+var deliveries = struct {
+	mu   sync.Mutex
+	sent map[string]time.Time
+}{sent: make(map[string]time.Time)}
+
+// deliveryID は冪等キーと送信先URLから送信済みの記録のキーを作る。
+func deliveryID(key, webhookURL string) string {
+	sum := sha256.Sum256([]byte(key + "\x00" + webhookURL))
+	return hex.EncodeToString(sum[:])
+}
+
+// alreadySent はkeyの通知をwebhookURLへ送信済みかを返す。keyが空なら常にfalse。
+func alreadySent(key, webhookURL string, now time.Time) bool {
+	if key == "" {
+		return false
+	}
+	deliveries.mu.Lock()
+	defer deliveries.mu.Unlock()
+	sentAt, ok := deliveries.sent[deliveryID(key, webhookURL)]
+	return ok && now.Sub(sentAt) < deliveryRetention
+}
+
+// markSent はkeyの通知をwebhookURLへ送信したことを記録し、保持期間を過ぎた記録を削除する。keyが空なら何もしない。
+func markSent(key, webhookURL string, now time.Time) {
+	if key == "" {
+		return
+	}
+	deliveries.mu.Lock()
+	defer deliveries.mu.Unlock()
+	for id, sentAt := range deliveries.sent {
+		if now.Sub(sentAt) >= deliveryRetention {
+			delete(deliveries.sent, id)
+		}
+	}
+	deliveries.sent[deliveryID(key, webhookURL)] = now
+}
@@ -0,0 +1,72 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+	"github.com/yuu1111/StreamNotifier/pkg/monitor"
+)
+
+func TestIdempotencyKeyUsesDetectedAt(t *testing.T) {
+	// 送信時刻の区間の境界をまたいでも、同じ検出の変更は同じキーになる
+	detectedAt := time.Date(2026, 1, 2, 11, 59, 59, 0, time.UTC)
+	change := monitor.DetectedChange{Type: config.ChangeOnline, Streamer: "Streamer", DetectedAt: detectedAt}
+	key := IdempotencyKey(change)
+	if again := IdempotencyKey(change); again != key {
+		t.Errorf("IdempotencyKey not stable: %s != %s", again, key)
+	}
+
+	lower := change
+	lower.Streamer = "streamer"
+	if IdempotencyKey(lower) != key {
+		t.Error("IdempotencyKey depends on the streamer name case")
+	}
+
+	later := change
+	later.DetectedAt = detectedAt.Add(time.Second)
+	if IdempotencyKey(later) == key {
+		t.Error("changes detected at different times share a key")
+	}
+}
+
+func TestSendWebhookSkipsAlreadySent(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	embed := Embed{Title: "test"}
+	key := IdempotencyKey(monitor.DetectedChange{Type: config.ChangeOnline, Streamer: "dedup", DetectedAt: time.Now()})
+	first, second := srv.URL+"/first", srv.URL+"/second"
+
+	for range 2 {
+		if err := SendWebhook(ctx, first, embed, StreamerInfo{}, key); err != nil {
+			t.Fatalf("SendWebhook: %v", err)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("requests = %d, want 1 for two sends with the same key", got)
+	}
+
+	if _, err := SendWebhookMessage(ctx, first, embed, StreamerInfo{}, key); !errors.Is(err, ErrAlreadySent) {
+		t.Errorf("SendWebhookMessage err = %v, want ErrAlreadySent", err)
+	}
+	if err := SendWebhook(ctx, second, embed, StreamerInfo{}, key); err != nil {
+		t.Fatalf("SendWebhook: %v", err)
+	}
+	if err := SendWebhook(ctx, first, embed, StreamerInfo{}, ""); err != nil {
+		t.Fatalf("SendWebhook: %v", err)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("requests = %d, want 3 (another URL and an empty key are sent)", got)
+	}
+}
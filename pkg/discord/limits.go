@@ -0,0 +1,80 @@
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Discordが1回のWebhook送信に課す上限。
+// https://discord.com/developers/docs/resources/message#embed-object-embed-limits
+const (
+	maxEmbeds           = 10
+	maxFieldsPerEmbed   = 25
+	maxTotalEmbedChars  = 6000
+	maxTitleChars       = 256
+	maxDescriptionChars = 4096
+	maxFieldNameChars   = 256
+	maxFieldValueChars  = 1024
+	maxFooterChars      = 2048
+	maxAuthorNameChars  = 256
+	maxContentChars     = 2000
+)
+
+// ErrInvalidPayload はペイロードがDiscordの上限を超えていることを表す。再送しても成功しない。
+var ErrInvalidPayload = errors.New("Webhookペイロードが Discord の上限を超えています")
+
+// textLimit は文字数上限のある項目。
+type textLimit struct {
+	name  string
+	text  string
+	limit int
+}
+
+// Validate はペイロードがDiscordの上限(本文・Embed数・フィールド数・文字数)に収まっているか検証する。
+// 合計文字数は全Embedのtitle/description/field/footer/author nameの合計で数える。
+func (p WebhookPayload) Validate() error {
+	if n := utf8.RuneCountInString(p.Content); n > maxContentChars {
+		return fmt.Errorf("%w: contentの文字数 %d/%d", ErrInvalidPayload, n, maxContentChars)
+	}
+	if len(p.Embeds) > maxEmbeds {
+		return fmt.Errorf("%w: Embed数 %d/%d", ErrInvalidPayload, len(p.Embeds), maxEmbeds)
+	}
+
+	total := 0
+	for i, e := range p.Embeds {
+		if len(e.Fields) > maxFieldsPerEmbed {
+			return fmt.Errorf("%w: embeds[%d] のフィールド数 %d/%d", ErrInvalidPayload, i, len(e.Fields), maxFieldsPerEmbed)
+		}
+
+		checks := []textLimit{
+			{"title", e.Title, maxTitleChars},
+			{"description", e.Description, maxDescriptionChars},
+		}
+		if e.Footer != nil {
+			checks = append(checks, textLimit{"footer.text", e.Footer.Text, maxFooterChars})
+		}
+		if e.Author != nil {
+			checks = append(checks, textLimit{"author.name", e.Author.Name, maxAuthorNameChars})
+		}
+		for j, f := range e.Fields {
+			checks = append(checks,
+				textLimit{fmt.Sprintf("fields[%d].name", j), f.Name, maxFieldNameChars},
+				textLimit{fmt.Sprintf("fields[%d].value", j), f.Value, maxFieldValueChars},
+			)
+		}
+
+		for _, c := range checks {
+			n := utf8.RuneCountInString(c.text)
+			if n > c.limit {
+				return fmt.Errorf("%w: embeds[%d].%s の文字数 %d/%d", ErrInvalidPayload, i, c.name, n, c.limit)
+			}
+			total += n
+		}
+	}
+
+	if total > maxTotalEmbedChars {
+		return fmt.Errorf("%w: Embedの合計文字数 %d/%d", ErrInvalidPayload, total, maxTotalEmbedChars)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package discord
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// embedsWithDescriptions はdescriptionの文字数がそれぞれnsのEmbedを作る。
+func embedsWithDescriptions(ns ...int) []Embed {
+	embeds := make([]Embed, len(ns))
+	for i, n := range ns {
+		embeds[i] = Embed{Description: strings.Repeat("a", n)}
+	}
+	return embeds
+}
+
+// embedWithFields はn個のフィールドを持つEmbedを作る。
+func embedWithFields(n int) Embed {
+	fields := make([]EmbedField, n)
+	for i := range fields {
+		fields[i] = EmbedField{Name: "n", Value: "v"}
+	}
+	return Embed{Fields: fields}
+}
+
+func TestWebhookPayloadValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload WebhookPayload
+		// wantErr は期待するエラーメッセージの一部。空なら成功を期待する。
+		wantErr string
+	}{
+		{"empty", WebhookPayload{}, ""},
+
+		// 文字数はバイト数ではなく文字(rune)で数える
+		{"content at limit", WebhookPayload{Content: strings.Repeat("あ", maxContentChars)}, ""},
+		{"content over limit", WebhookPayload{Content: strings.Repeat("あ", maxContentChars+1)}, "contentの文字数 2001/2000"},
+
+		{"embeds at limit", WebhookPayload{Embeds: make([]Embed, maxEmbeds)}, ""},
+		{"embeds over limit", WebhookPayload{Embeds: make([]Embed, maxEmbeds+1)}, "Embed数 11/10"},
+
+		{"fields at limit", WebhookPayload{Embeds: []Embed{embedWithFields(maxFieldsPerEmbed)}}, ""},
+		{"fields over limit", WebhookPayload{Embeds: []Embed{{}, embedWithFields(maxFieldsPerEmbed + 1)}}, "embeds[1] のフィールド数 26/25"},
+
+		{"total at limit", WebhookPayload{Embeds: embedsWithDescriptions(3000, 3000)}, ""},
+		{"total over limit", WebhookPayload{Embeds: embedsWithDescriptions(3000, 3001)}, "Embedの合計文字数 6001/6000"},
+
+		{"title at limit", WebhookPayload{Embeds: []Embed{{Title: strings.Repeat("a", maxTitleChars)}}}, ""},
+		{"title over limit", WebhookPayload{Embeds: []Embed{{Title: strings.Repeat("a", maxTitleChars+1)}}}, "embeds[0].title の文字数 257/256"},
+		{"description at limit", WebhookPayload{Embeds: embedsWithDescriptions(maxDescriptionChars)}, ""},
+		{"description over limit", WebhookPayload{Embeds: embedsWithDescriptions(maxDescriptionChars + 1)}, "embeds[0].description の文字数 4097/4096"},
+		{"field name at limit", WebhookPayload{Embeds: []Embed{{Fields: []EmbedField{{Name: strings.Repeat("a", maxFieldNameChars)}}}}}, ""},
+		{"field name over limit", WebhookPayload{Embeds: []Embed{{Fields: []EmbedField{{Name: strings.Repeat("a", maxFieldNameChars+1)}}}}}, "embeds[0].fields[0].name の文字数 257/256"},
+		{"field value at limit", WebhookPayload{Embeds: []Embed{{Fields: []EmbedField{{Value: strings.Repeat("a", maxFieldValueChars)}}}}}, ""},
+		{"field value over limit", WebhookPayload{Embeds: []Embed{{Fields: []EmbedField{{}, {Value: strings.Repeat("a", maxFieldValueChars+1)}}}}}, "embeds[0].fields[1].value の文字数 1025/1024"},
+		{"footer at limit", WebhookPayload{Embeds: []Embed{{Footer: &EmbedFooter{Text: strings.Repeat("a", maxFooterChars)}}}}, ""},
+		{"footer over limit", WebhookPayload{Embeds: []Embed{{Footer: &EmbedFooter{Text: strings.Repeat("a", maxFooterChars+1)}}}}, "embeds[0].footer.text の文字数 2049/2048"},
+		{"author at limit", WebhookPayload{Embeds: []Embed{{Author: &EmbedAuthor{Name: strings.Repeat("a", maxAuthorNameChars)}}}}, ""},
+		{"author over limit", WebhookPayload{Embeds: []Embed{{Author: &EmbedAuthor{Name: strings.Repeat("a", maxAuthorNameChars+1)}}}}, "embeds[0].author.name の文字数 257/256"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.payload.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidPayload) {
+				t.Fatalf("Validate() = %v, want ErrInvalidPayload", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %q, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
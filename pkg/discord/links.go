@@ -0,0 +1,75 @@
+package discord
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// maxExtractedLinks は関連リンクフィールドに列挙するURLの上限数。
+const maxExtractedLinks = 5
+
+// urlPattern はテキスト中のURL候補にマッチする。空白や全角括弧・句読点で区切る。
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"` + "`" + `「」『』【】（）、。]+`)
+
+// trailingPunctuation はURL末尾に付いていてもURLの一部とみなさない記号。
+const trailingPunctuation = `.,;:!?'"*_~`
+
+// trimURL はURL候補の末尾から句読点と対応の取れない閉じ括弧を取り除く。
+func trimURL(s string) string {
+	for s != "" {
+		last := s[len(s)-1]
+		switch {
+		case strings.ContainsRune(trailingPunctuation, rune(last)):
+			s = s[:len(s)-1]
+		case last == ')' && strings.Count(s, "(") < strings.Count(s, ")"):
+			s = s[:len(s)-1]
+		case last == ']' && strings.Count(s, "[") < strings.Count(s, "]"):
+			s = s[:len(s)-1]
+		default:
+			// 全角の句読点はマルチバイトのため別途判定する
+			trimmed := strings.TrimRight(s, "！？。、")
+			if trimmed == s {
+				return s
+			}
+			s = trimmed
+		}
+	}
+	return s
+}
+
+// extractLinks はテキストから重複を除いたURLを抽出し、URLを除去した本文と合わせて返す。
+func extractLinks(text string) (links []string, stripped string) {
+	seen := make(map[string]bool)
+	stripped = urlPattern.ReplaceAllStringFunc(text, func(match string) string {
+		link := trimURL(match)
+		u, err := url.Parse(link)
+		if err != nil || u.Host == "" {
+			return match
+		}
+		if !seen[link] {
+			seen[link] = true
+			links = append(links, link)
+		}
+		// URLの後ろに付いていた句読点は本文に残す
+		return strings.TrimPrefix(match, link)
+	})
+	return links, strings.Join(strings.Fields(stripped), " ")
+}
+
+// buildLinksField は抽出したURLを「関連リンク」フィールドに整形する。
+func buildLinksField(links []string, m *messages) EmbedField {
+	if len(links) > maxExtractedLinks {
+		links = links[:maxExtractedLinks]
+	}
+	lines := make([]string, len(links))
+	for i, link := range links {
+		label := link
+		if u, err := url.Parse(link); err == nil {
+			label = truncate(u.Host+u.EscapedPath(), 50)
+		}
+		lines[i] = fmt.Sprintf("[%s](%s)", label, link)
+	}
+	return EmbedField{Name: m.fieldLinks, Value: strings.Join(lines, "\n")}
+}
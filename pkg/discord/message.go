@@ -0,0 +1,49 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Message はWebhookで作成されたDiscordメッセージ。
+type Message struct {
+	ID        string `json:"id"`
+	ChannelID string `json:"channel_id"`
+}
+
+// messageURL はWebhookで送信したメッセージの操作用URLを返す。
+func messageURL(webhookURL, messageID string) (string, error) {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return "", fmt.Errorf("Webhook URLの解析に失敗: %w", err)
+	}
+	u.Path += "/messages/" + url.PathEscape(messageID)
+	return u.String(), nil
+}
+
+// EditWebhookMessage はWebhookで送信したメッセージのEmbedを置き換える。
+func EditWebhookMessage(ctx context.Context, webhookURL, messageID string, embed Embed) error {
+	reqURL, err := messageURL(webhookURL, messageID)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(WebhookPayload{Embeds: []Embed{embed}})
+	if err != nil {
+		return fmt.Errorf("WebhookペイロードのJSON変換に失敗: %w", err)
+	}
+	_, _, err = doWebhookRequest(ctx, http.MethodPatch, reqURL, body)
+	return err
+}
+
+// DeleteWebhookMessage はWebhookで送信したメッセージを削除する。
+func DeleteWebhookMessage(ctx context.Context, webhookURL, messageID string) error {
+	reqURL, err := messageURL(webhookURL, messageID)
+	if err != nil {
+		return err
+	}
+	_, _, err = doWebhookRequest(ctx, http.MethodDelete, reqURL, nil)
+	return err
+}
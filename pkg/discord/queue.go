@@ -0,0 +1,185 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// retryBaseDelay は初回リトライまでの待機時間。以降は倍々で延びる。
+	retryBaseDelay = 30 * time.Second
+	// retryMaxDelay はリトライ間隔の上限。
+	retryMaxDelay = 30 * time.Minute
+	// queueCheckInterval はキューの再送判定間隔。
+	queueCheckInterval = 15 * time.Second
+)
+
+// QueueItem はリトライ待ちのWebhook送信。
+type QueueItem struct {
+	WebhookURL string       `json:"webhookUrl"`
+	Embed      Embed        `json:"embed"`
+	Streamer   StreamerInfo `json:"streamer"`
+	// Key は最初の送信時の冪等キー。再送でも同じキーを使い、最初の送信が届いていた場合の重複投稿を防ぐ。
+	Key           string    `json:"key,omitempty"`
+	EnqueuedAt    time.Time `json:"enqueuedAt"`
+	Attempts      int       `json:"attempts"`
+	NextAttemptAt time.Time `json:"nextAttemptAt"`
+	LastError     string    `json:"lastError,omitempty"`
+}
+
+// RetryQueue は送信失敗したWebhookをディスクに保持し、バックオフ付きで再送する。
+type RetryQueue struct {
+	path   string
+	maxAge time.Duration
+
+	mu    sync.Mutex
+	items []QueueItem
+}
+
+// NewRetryQueue はRetryQueueを作成し、前回終了時に残ったキューを読み込む。
+func NewRetryQueue(path string, maxAge time.Duration) (*RetryQueue, error) {
+	q := &RetryQueue{path: path, maxAge: maxAge}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("リトライキューの読み込みに失敗: %w", err)
+	}
+	if err := json.Unmarshal(data, &q.items); err != nil {
+		return nil, fmt.Errorf("リトライキューの解析に失敗: %w", err)
+	}
+	if len(q.items) > 0 {
+		slog.Info("未送信の通知を読み込みました", "count", len(q.items))
+	}
+	return q, nil
+}
+
+// Enqueue は送信失敗したWebhookをキューに追加する。keyは失敗した送信で使った冪等キー。
+func (q *RetryQueue) Enqueue(webhookURL string, embed Embed, streamer StreamerInfo, key string, sendErr error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	q.items = append(q.items, QueueItem{
+		WebhookURL:    webhookURL,
+		Embed:         embed,
+		Streamer:      streamer,
+		Key:           key,
+		EnqueuedAt:    now,
+		Attempts:      1,
+		NextAttemptAt: now.Add(retryBaseDelay),
+		LastError:     sendErr.Error(),
+	})
+	q.save()
+	slog.Warn("Webhook送信をリトライキューに追加", "url", truncate(webhookURL, 50), "pending", len(q.items))
+}
+
+// Run は起動時に未送信分を送信し、以降ctxがキャンセルされるまで定期的に再送する。
+func (q *RetryQueue) Run(ctx context.Context) {
+	q.drain(ctx, true)
+
+	ticker := time.NewTicker(queueCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			q.drain(ctx, false)
+		}
+	}
+}
+
+// drain は再送時刻を迎えたアイテムを送信する。forceがtrueなら待機時刻を無視する。
+func (q *RetryQueue) drain(ctx context.Context, force bool) {
+	q.mu.Lock()
+	pending := q.items
+	q.items = nil
+	q.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	now := time.Now()
+	var remaining []QueueItem
+	for _, item := range pending {
+		if ctx.Err() != nil {
+			remaining = append(remaining, item)
+			continue
+		}
+		if now.Sub(item.EnqueuedAt) > q.maxAge {
+			slog.Error("リトライ期限切れのため通知を破棄",
+				"url", truncate(item.WebhookURL, 50), "attempts", item.Attempts, "lastError", item.LastError)
+			recordDeadLetter(item.WebhookURL, item.Embed, item.Streamer, item.Key, item.Attempts, errors.New(item.LastError))
+			continue
+		}
+		if !force && now.Before(item.NextAttemptAt) {
+			remaining = append(remaining, item)
+			continue
+		}
+
+		err := SendWebhook(ctx, item.WebhookURL, item.Embed, item.Streamer, item.Key)
+		if err == nil {
+			slog.Info("リトライ送信成功", "url", truncate(item.WebhookURL, 50), "attempts", item.Attempts+1)
+			continue
+		}
+		if !IsRetryable(err) {
+			slog.Error("リトライ不可能なエラーのため通知を破棄", "error", err)
+			recordDeadLetter(item.WebhookURL, item.Embed, item.Streamer, item.Key, item.Attempts+1, err)
+			continue
+		}
+
+		item.Attempts++
+		item.LastError = err.Error()
+		item.NextAttemptAt = now.Add(backoff(item.Attempts))
+		remaining = append(remaining, item)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.items = append(remaining, q.items...)
+	q.save()
+}
+
+// backoff は試行回数に応じた次回リトライまでの待機時間を返す。
+func backoff(attempts int) time.Duration {
+	d := retryBaseDelay
+	for i := 1; i < attempts && d < retryMaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, retryMaxDelay)
+}
+
+// save はキューをディスクに書き出す。呼び出し側でロックを保持すること。
+func (q *RetryQueue) save() {
+	data, err := json.MarshalIndent(q.items, "", "  ")
+	if err != nil {
+		slog.Error("リトライキューのJSON変換に失敗", "error", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(q.path), 0755); err != nil {
+		slog.Error("リトライキューの保存先作成に失敗", "error", err)
+		return
+	}
+
+	// 書き込み途中で落ちてもキューが壊れないよう一時ファイル経由で置き換える
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		slog.Error("リトライキューの保存に失敗", "error", err)
+		return
+	}
+	if err := os.Rename(tmp, q.path); err != nil {
+		slog.Error("リトライキューの保存に失敗", "error", err)
+	}
+}
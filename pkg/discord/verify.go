@@ -0,0 +1,105 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/internal/httpclient"
+)
+
+// WebhookInfo はDiscordから取得したWebhookの情報。
+type WebhookInfo struct {
+	Name      string `json:"name"`
+	ChannelID string `json:"channel_id"`
+	GuildID   string `json:"guild_id"`
+}
+
+// VerifyResult はWebhook疎通確認の結果。
+type VerifyResult struct {
+	URL  string
+	Info *WebhookInfo
+	// RateLimitRemaining はレスポンスヘッダーから取得した残りリクエスト数。不明なら-1。
+	RateLimitRemaining int
+	Err                error
+}
+
+// Valid はWebhookが存在し利用可能かを返す。
+func (r VerifyResult) Valid() bool {
+	return r.Err == nil && r.Info != nil
+}
+
+// VerifyWebhook はWebhook URLにGETしてWebhookの実在と情報を確認する。
+func VerifyWebhook(ctx context.Context, webhookURL string, timeout time.Duration) VerifyResult {
+	result := VerifyResult{URL: webhookURL, RateLimitRemaining: -1}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, webhookURL, nil)
+	if err != nil {
+		result.Err = fmt.Errorf("リクエスト作成に失敗: %w", err)
+		return result
+	}
+
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		result.Err = fmt.Errorf("接続に失敗: %w", err)
+		return result
+	}
+	defer resp.Body.Close()
+
+	if v := resp.Header.Get("X-RateLimit-Remaining"); v != "" {
+		_, _ = fmt.Sscanf(v, "%d", &result.RateLimitRemaining)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		result.Err = fmt.Errorf("レスポンスの読み込みに失敗: %w", err)
+		return result
+	}
+	if resp.StatusCode != http.StatusOK {
+		result.Err = &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+		return result
+	}
+
+	var info WebhookInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		result.Err = fmt.Errorf("レスポンスの解析に失敗: %w", err)
+		return result
+	}
+	result.Info = &info
+	return result
+}
+
+// VerifyWebhooks は複数のWebhookを並列数を制限したワーカープールで確認する。結果は入力順に並ぶ。
+func VerifyWebhooks(ctx context.Context, webhookURLs []string, concurrency int, timeout time.Duration) []VerifyResult {
+	results := make([]VerifyResult, len(webhookURLs))
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(concurrency, len(webhookURLs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = VerifyWebhook(ctx, webhookURLs[i], timeout)
+			}
+		}()
+	}
+
+	for i := range webhookURLs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,232 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/internal/httpclient"
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+)
+
+// WebhookPayload はDiscord Webhookのペイロード。
+type WebhookPayload struct {
+	// Content はEmbedと一緒に投稿する本文。
+	Content   string  `json:"content,omitempty"`
+	Embeds    []Embed `json:"embeds"`
+	Username  string  `json:"username,omitempty"`
+	AvatarURL string  `json:"avatar_url,omitempty"`
+}
+
+// StreamerInfo は配信者情報(Webhook表示用)。
+type StreamerInfo struct {
+	DisplayName     string `json:"displayName"`
+	ProfileImageURL string `json:"profileImageUrl"`
+	// Content はEmbedに添える本文。空なら本文なしで送る。
+	Content string `json:"content,omitempty"`
+}
+
+// StatusError はDiscordが2xx以外を返したことを表す。
+type StatusError struct {
+	StatusCode int
+	Body       string
+	// Latency はリクエスト送信からレスポンス受信までの時間。不明な場合はゼロ値。
+	Latency time.Duration
+}
+
+func (e *StatusError) Error() string {
+	if e.Latency == 0 {
+		return fmt.Sprintf("Webhook送信失敗: %d %s", e.StatusCode, e.Body)
+	}
+	return fmt.Sprintf("Webhook送信失敗: %d %s (%s)", e.StatusCode, e.Body, e.Latency.Round(time.Millisecond))
+}
+
+// IsRetryable は送信エラーが一時的なもので再送で成功し得るかを判定する。
+// ネットワークエラーと429/5xxを一時的とみなす。送信を停止したWebhookは再送しない。
+func IsRetryable(err error) bool {
+	if errors.Is(err, ErrInvalidPayload) || errors.Is(err, ErrWebhookDisabled) || errors.Is(err, ErrAlreadySent) {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+	return err != nil
+}
+
+// SendWebhook は単一のWebhookにEmbedを送信する。
+// keyはIdempotencyKeyで作った冪等キーで、同じkeyの通知をこのプロセスで同じWebhookへ送信済みなら送らずにnilを返す。
+// 空なら重複を防がない。
+func SendWebhook(ctx context.Context, webhookURL string, embed Embed, streamer StreamerInfo, key string) error {
+	_, err := sendWebhook(ctx, webhookURL, embed, streamer, key, false)
+	if errors.Is(err, ErrAlreadySent) {
+		return nil
+	}
+	return err
+}
+
+// SendWebhookMessage はSendWebhookと同様に送信し、作成されたメッセージの情報を返す(wait=true)。
+// 送信後にメッセージを編集・削除する場合に使う。送信済みのkeyならErrAlreadySentを返す。
+func SendWebhookMessage(ctx context.Context, webhookURL string, embed Embed, streamer StreamerInfo, key string) (*Message, error) {
+	body, err := sendWebhook(ctx, webhookURL, embed, streamer, key, true)
+	if err != nil {
+		return nil, err
+	}
+	var msg Message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fmt.Errorf("Webhookレスポンスの解析に失敗: %w", err)
+	}
+	return &msg, nil
+}
+
+// sendWebhook はEmbedを送信してレスポンスボディを返す。waitがtrueならDiscordは作成したメッセージを返す。
+// 404/401が続いて送信を停止したWebhookにはリクエストを送らずErrWebhookDisabledを返す。
+func sendWebhook(ctx context.Context, webhookURL string, embed Embed, streamer StreamerInfo, key string, wait bool) ([]byte, error) {
+	if IsWebhookDisabled(webhookURL) {
+		return nil, ErrWebhookDisabled
+	}
+	if alreadySent(key, webhookURL, time.Now()) {
+		slog.Info("同じ通知を送信済みのためスキップします", "url", truncate(webhookURL, 50))
+		return nil, ErrAlreadySent
+	}
+	payload := WebhookPayload{
+		Content:   streamer.Content,
+		Embeds:    []Embed{embed},
+		Username:  streamer.DisplayName,
+		AvatarURL: streamer.ProfileImageURL,
+	}
+	// 上限超過はDiscordが400を返すだけなので、送信前に原因の分かるエラーにする
+	if err := payload.Validate(); err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("WebhookペイロードのJSON変換に失敗: %w", err)
+	}
+
+	reqURL := webhookURL
+	if wait {
+		reqURL += "?wait=true"
+	}
+	start := time.Now()
+	respBody, status, err := doWebhookRequest(ctx, http.MethodPost, reqURL, body)
+	observeWebhookResult(webhookURL, err)
+	latency := time.Since(start).Round(time.Millisecond).String()
+	if err != nil {
+		slog.Debug("Webhook送信失敗", "url", truncate(webhookURL, 50), "status", status, "latency", latency)
+		return nil, err
+	}
+
+	slog.Debug("Webhook送信成功", "url", truncate(webhookURL, 50), "status", status, "latency", latency)
+	markSent(key, webhookURL, time.Now())
+	return respBody, nil
+}
+
+// doWebhookRequest はWebhook APIへリクエストを送り、レスポンスボディとステータスコードを返す。
+// 2xx以外なら*StatusErrorを返す。レスポンスを受け取れなかった場合のステータスコードは0。
+func doWebhookRequest(ctx context.Context, method, reqURL string, body []byte) ([]byte, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reader)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Webhookリクエスト作成に失敗: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	start := time.Now()
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Webhook送信に失敗 (%s): %w", time.Since(start).Round(time.Millisecond), err)
+	}
+	defer resp.Body.Close()
+
+	// レスポンスボディを消費してリソースを解放
+	respBody, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, resp.StatusCode, &StatusError{StatusCode: resp.StatusCode, Body: string(respBody), Latency: time.Since(start)}
+	}
+	return respBody, resp.StatusCode, nil
+}
+
+// sendConcurrency はsendToMultipleで同時に送信する最大数。
+var sendConcurrency = struct {
+	mu sync.Mutex
+	n  int
+}{n: config.DefaultSendConcurrency}
+
+// SetSendConcurrency は複数のWebhookへ同時に送信する最大数を設定する。1未満は1とみなす。
+func SetSendConcurrency(n int) {
+	sendConcurrency.mu.Lock()
+	defer sendConcurrency.mu.Unlock()
+	sendConcurrency.n = max(n, 1)
+}
+
+// SendToMultipleWebhooks は複数のWebhookにEmbedを並列送信する。返り値はwebhookURLsと同じ順の送信結果(成功はnil)。
+// 同時に送信するのはSetSendConcurrencyで設定した数までで、全URLへの送信が終わるまで待つ。
+// keyは各URLに共通の冪等キーで、送信済みの記録はURLごとに持つ。
+func SendToMultipleWebhooks(ctx context.Context, webhookURLs []string, embed Embed, streamer StreamerInfo, key string) []error {
+	_, errs := sendToMultiple(ctx, webhookURLs, embed, streamer, key, false)
+	return errs
+}
+
+// SendToMultipleWebhookMessages はSendToMultipleWebhooksと同様に送信し、作成されたメッセージも返す。
+func SendToMultipleWebhookMessages(ctx context.Context, webhookURLs []string, embed Embed, streamer StreamerInfo, key string) ([]*Message, []error) {
+	return sendToMultiple(ctx, webhookURLs, embed, streamer, key, true)
+}
+
+func sendToMultiple(ctx context.Context, webhookURLs []string, embed Embed, streamer StreamerInfo, key string, wait bool) ([]*Message, []error) {
+	msgs := make([]*Message, len(webhookURLs))
+	errs := make([]error, len(webhookURLs))
+	sendConcurrency.mu.Lock()
+	sem := make(chan struct{}, sendConcurrency.n)
+	sendConcurrency.mu.Unlock()
+	var wg sync.WaitGroup
+	for i, url := range webhookURLs {
+		wg.Add(1)
+		go func(idx int, u string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			var err error
+			if wait {
+				msgs[idx], err = SendWebhookMessage(ctx, u, embed, streamer, key)
+			} else {
+				err = SendWebhook(ctx, u, embed, streamer, key)
+			}
+			if err != nil {
+				slog.Error("Webhook送信エラー",
+					"index", idx+1,
+					"total", len(webhookURLs),
+					"error", err)
+				errs[idx] = err
+			}
+		}(i, url)
+	}
+	wg.Wait()
+	return msgs, errs
+}
+
+// truncate は文字列を指定長で切り詰める。
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen] + "..."
+}
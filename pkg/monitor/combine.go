@@ -0,0 +1,103 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+)
+
+// pendingCombine は別のポーリングで来る変更との統合を待っているタイトル変更またはゲーム変更。
+type pendingCombine struct {
+	change   DetectedChange
+	deadline time.Time
+}
+
+// combineAcrossPolls はタイトル変更とゲーム変更をnotifications.combineWindowSecondsの間保留し、
+// 期間内にもう一方の変更が来たらChangeTitleAndGameに統合する。
+// 期限判定はポーリング毎に行うため、実際の保留時間はポーリング間隔単位で切り上がる。
+func (p *Poller) combineAcrossPolls(key string, changes []DetectedChange, newState StreamerState, now time.Time) []DetectedChange {
+	window := time.Duration(p.cfg.Notifications.CombineWindowSeconds) * time.Second
+	if window <= 0 {
+		return changes
+	}
+
+	pending := p.pendingCombines[key]
+	var result []DetectedChange
+	for _, c := range changes {
+		switch c.Type {
+		case config.ChangeTitleChange, config.ChangeGameChange:
+			switch {
+			case pending == nil:
+				pending = &pendingCombine{change: c, deadline: now.Add(window)}
+			case pending.change.Type == c.Type:
+				// 同じ種類の再変更は最初の変更前の値を保ったまま最新値にまとめる
+				pending.change.NewValue = c.NewValue
+			default:
+				result = append(result, mergeTitleAndGame(pending.change, c))
+				pending = nil
+			}
+			continue
+		case config.ChangeTitleAndGame:
+			if pending != nil {
+				result = append(result, mergePendingInto(pending.change, c))
+				pending = nil
+				continue
+			}
+		case config.ChangeOnline, config.ChangeOffline:
+			// 配信の開始・終了をまたいで統合しないよう、保留分を先に通知する
+			if pending != nil {
+				result = appendPendingCombine(result, pending.change, newState)
+				pending = nil
+			}
+		}
+		result = append(result, c)
+	}
+
+	if pending != nil && !now.Before(pending.deadline) {
+		result = appendPendingCombine(result, pending.change, newState)
+		pending = nil
+	}
+	if pending == nil {
+		delete(p.pendingCombines, key)
+	} else {
+		p.pendingCombines[key] = pending
+	}
+	return result
+}
+
+// mergeTitleAndGame は別々に検出したタイトル変更とゲーム変更を1つの変更にまとめる。
+func mergeTitleAndGame(a, b DetectedChange) DetectedChange {
+	title, game := a, b
+	if a.Type == config.ChangeGameChange {
+		title, game = b, a
+	}
+	return DetectedChange{
+		Type:         config.ChangeTitleAndGame,
+		Streamer:     b.Streamer,
+		OldTitle:     title.OldValue,
+		NewTitle:     title.NewValue,
+		OldGame:      game.OldValue,
+		NewGame:      game.NewValue,
+		CurrentState: b.CurrentState,
+	}
+}
+
+// mergePendingInto は保留中の変更の変更前の値をChangeTitleAndGameに引き継ぐ。
+func mergePendingInto(pending, combined DetectedChange) DetectedChange {
+	if pending.Type == config.ChangeTitleChange {
+		combined.OldTitle = pending.OldValue
+	} else {
+		combined.OldGame = pending.OldValue
+	}
+	return combined
+}
+
+// appendPendingCombine は保留していた変更を最新の状態で通知対象に加える。
+// 保留中に元の値へ戻された場合は通知しない。
+func appendPendingCombine(result []DetectedChange, change DetectedChange, newState StreamerState) []DetectedChange {
+	if change.OldValue == change.NewValue {
+		return result
+	}
+	change.CurrentState = newState
+	return append(result, change)
+}
@@ -0,0 +1,163 @@
+package monitor
+
+import (
+	"strings"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+)
+
+// DetectedChange は検出された変更イベントを表す。ChangeHandlerに渡す公開の契約で、項目の意味は変えずに追加のみ行う。
+// 通知タイプごとに設定される項目はパッケージのドキュメントを参照。
+type DetectedChange struct {
+	// Type は通知タイプ(config.Change*)。AddDetectorで追加した検出器は独自のタイプを使える。
+	Type config.ChangeType
+	// Platform は配信プラットフォーム。Pollerが検出した変更では常にconfig.PlatformTwitch。
+	Platform config.Platform
+	// Streamer は配信者のログイン名。
+	Streamer string
+	// OldValue・NewValue は変更前後の値(タイトル変更ならタイトル、ゲーム変更ならゲーム名、プロフィール更新なら表示名)。
+	// アーカイブ公開ではNewValueにVODのIDを設定する。
+	OldValue string
+	NewValue string
+	// OldTitle・NewTitle・OldGame・NewGame はタイトル・ゲームの同時変更とスケジュールリマインダーの値。
+	// アーカイブ公開ではNewTitleにVODのタイトルを設定する。
+	OldTitle string
+	NewTitle string
+	OldGame  string
+	NewGame  string
+	// StreamStartedAt は対象の配信の開始時刻(RFC3339)。
+	StreamStartedAt string
+	// StreamID は配信開始・終了・再接続・再告知の対象の配信のID。配信開始と配信終了の対応付けに使う。
+	StreamID string
+	// ScheduledStartAt はスケジュールリマインダーの配信予定時刻(RFC3339)。
+	ScheduledStartAt string
+	// OldProfileImageURL はプロフィール更新時の変更前のプロフィール画像。
+	OldProfileImageURL string
+	// VodURL・VodThumbnailURL は配信終了時またはアーカイブ公開時に取得できたアーカイブのURLとサムネイル。
+	VodURL          string
+	VodThumbnailURL string
+	// VodDuration はアーカイブ公開時のVODの長さ。取得できなければ0。
+	VodDuration time.Duration
+	// FollowerCount は配信開始時のフォロワー数。取得していなければnil。
+	FollowerCount *int
+	// OfflineDuration は再接続までに配信が途切れていた時間。
+	OfflineDuration time.Duration
+	// CurrentState は変更を検出した時点の配信者の状態。
+	CurrentState StreamerState
+	// DetectedAt はPollerが変更を通知した時刻。送信の冪等キーに使い、同じ変更の再送を同じ通知として扱う。
+	DetectedAt time.Time
+	// Deferred は視聴者数の閾値待ちやVOD待ちで保留していた通知の遅延送信であることを表す。
+	// 検出時点で記録・イベント配信は済んでいるため、通知の送信のみ行う。
+	Deferred bool
+}
+
+// Detector は新旧状態を比較して1種類の変更を検出する関数。変更がなければokはfalse。
+type Detector func(oldState, newState StreamerState) (change DetectedChange, ok bool)
+
+// DefaultDetectors は標準の検出器(配信開始・終了、タイトル変更、ゲーム変更)を返す。
+func DefaultDetectors() []Detector {
+	return []Detector{DetectOnline, DetectOffline, DetectTitleChange, DetectGameChange}
+}
+
+// DetectChanges は新旧状態を検出器で比較して変更を検出する。detectorsを省略した場合はDefaultDetectorsを使う。
+// oldStateがnilの場合は初回ポーリングとして空スライスを返す。
+func DetectChanges(oldState *StreamerState, newState StreamerState, detectors ...Detector) []DetectedChange {
+	if oldState == nil {
+		return nil
+	}
+	if len(detectors) == 0 {
+		detectors = DefaultDetectors()
+	}
+
+	var changes []DetectedChange
+	for _, detect := range detectors {
+		if c, ok := detect(*oldState, newState); ok {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+// DetectOnline は配信開始を検出する。
+func DetectOnline(oldState, newState StreamerState) (DetectedChange, bool) {
+	if oldState.IsLive || !newState.IsLive {
+		return DetectedChange{}, false
+	}
+	return DetectedChange{
+		Type:         config.ChangeOnline,
+		Streamer:     newState.Username,
+		StreamID:     newState.StreamID,
+		CurrentState: newState,
+	}, true
+}
+
+// DetectOffline は配信終了を検出する。
+func DetectOffline(oldState, newState StreamerState) (DetectedChange, bool) {
+	if !oldState.IsLive || newState.IsLive {
+		return DetectedChange{}, false
+	}
+	return DetectedChange{
+		Type:            config.ChangeOffline,
+		Streamer:        newState.Username,
+		StreamStartedAt: oldState.StartedAt,
+		StreamID:        oldState.StreamID,
+		CurrentState:    newState,
+	}, true
+}
+
+// DetectTitleChange はタイトル変更を検出する。空タイトルへの変更は無視する。
+func DetectTitleChange(oldState, newState StreamerState) (DetectedChange, bool) {
+	if oldState.Title == newState.Title || newState.Title == "" || staleOfflineSource(oldState, newState) {
+		return DetectedChange{}, false
+	}
+	return DetectedChange{
+		Type:         config.ChangeTitleChange,
+		Streamer:     newState.Username,
+		OldValue:     oldState.Title,
+		NewValue:     newState.Title,
+		CurrentState: newState,
+	}, true
+}
+
+// DetectSignificantTitleChange はDetectTitleChangeと同様にタイトル変更を検出するが、
+// 空白(前後・連続)と大文字小文字の違いのみの変更は無視する。通知には正規化前の新しいタイトルを使う。
+func DetectSignificantTitleChange(oldState, newState StreamerState) (DetectedChange, bool) {
+	if strings.EqualFold(NormalizeTitle(oldState.Title), NormalizeTitle(newState.Title)) {
+		return DetectedChange{}, false
+	}
+	return DetectTitleChange(oldState, newState)
+}
+
+// NormalizeTitle は前後の空白を除き、連続する空白を1つにまとめたタイトルを返す。
+func NormalizeTitle(title string) string {
+	return strings.Join(strings.Fields(title), " ")
+}
+
+// DetectorsFor は設定に応じた標準の検出器を返す。Pollerはこの検出器で変更を検出する。
+func DetectorsFor(cfg *config.Config) []Detector {
+	if cfg.Notifications.IgnoreCosmeticTitleChanges {
+		return []Detector{DetectOnline, DetectOffline, DetectSignificantTitleChange, DetectGameChange}
+	}
+	return DefaultDetectors()
+}
+
+// DetectGameChange はゲーム変更を検出する。
+func DetectGameChange(oldState, newState StreamerState) (DetectedChange, bool) {
+	if oldState.GameID == newState.GameID || staleOfflineSource(oldState, newState) {
+		return DetectedChange{}, false
+	}
+	return DetectedChange{
+		Type:         config.ChangeGameChange,
+		Streamer:     newState.Username,
+		OldValue:     oldState.GameName,
+		NewValue:     newState.GameName,
+		CurrentState: newState,
+	}, true
+}
+
+// staleOfflineSource は再起動後に/channelsから構築した状態から初めて配信開始したかを判定する。
+// この場合のタイトル・ゲームの差分は古いチャンネル情報との比較によるもので、変更通知の対象外とする。
+func staleOfflineSource(oldState, newState StreamerState) bool {
+	return oldState.OfflineSourced && newState.IsLive
+}
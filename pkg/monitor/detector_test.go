@@ -0,0 +1,286 @@
+package monitor
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+)
+
+// testLiveState は検出器のテストで使う配信中の状態。
+func testLiveState() StreamerState {
+	return StreamerState{
+		UserID:      "1",
+		Username:    "streamer",
+		DisplayName: "Streamer",
+		IsLive:      true,
+		Title:       "Ranked grind",
+		GameID:      "100",
+		GameName:    "Minecraft",
+		StartedAt:   "2026-01-02T09:30:00Z",
+		StreamID:    "40001",
+	}
+}
+
+// testOfflineState は検出器のテストで使う配信外の状態。
+func testOfflineState() StreamerState {
+	s := testLiveState()
+	s.IsLive = false
+	s.StartedAt = ""
+	s.StreamID = ""
+	return s
+}
+
+// changeTypes は検出した変更の通知タイプを順に返す。
+func changeTypes(changes []DetectedChange) []config.ChangeType {
+	types := make([]config.ChangeType, len(changes))
+	for i, c := range changes {
+		types[i] = c.Type
+	}
+	return types
+}
+
+func TestDetectChangesFirstPoll(t *testing.T) {
+	if changes := DetectChanges(nil, testLiveState()); changes != nil {
+		t.Errorf("DetectChanges(nil, live) = %v, want nil on the first poll", changeTypes(changes))
+	}
+}
+
+func TestDetectChangesDefaultDetectors(t *testing.T) {
+	old := testOfflineState()
+	newState := testLiveState()
+	newState.Title = "New title"
+	newState.GameID = "200"
+	newState.GameName = "Just Chatting"
+
+	got := changeTypes(DetectChanges(&old, newState))
+	want := []config.ChangeType{config.ChangeOnline, config.ChangeTitleChange, config.ChangeGameChange}
+	if !slices.Equal(got, want) {
+		t.Errorf("DetectChanges = %v, want %v", got, want)
+	}
+}
+
+func TestDetectChangesViewerOnlyChange(t *testing.T) {
+	old := testLiveState()
+	newState := old
+	newState.ViewerCount = old.ViewerCount + 500
+	if changes := DetectChanges(&old, newState); len(changes) != 0 {
+		t.Errorf("DetectChanges = %v, want no changes for a viewer-only change", changeTypes(changes))
+	}
+}
+
+func TestDetectChangesCustomDetectors(t *testing.T) {
+	const changeViewerSurge config.ChangeType = "viewerSurge"
+	surge := func(oldState, newState StreamerState) (DetectedChange, bool) {
+		if newState.ViewerCount < oldState.ViewerCount*2 {
+			return DetectedChange{}, false
+		}
+		return DetectedChange{Type: changeViewerSurge, Streamer: newState.Username, CurrentState: newState}, true
+	}
+
+	old := testLiveState()
+	old.ViewerCount = 100
+	newState := old
+	newState.ViewerCount = 250
+	newState.Title = "New title"
+
+	// 検出器を指定した場合は標準の検出器を使わない
+	got := changeTypes(DetectChanges(&old, newState, surge))
+	if want := []config.ChangeType{changeViewerSurge}; !slices.Equal(got, want) {
+		t.Errorf("DetectChanges = %v, want %v", got, want)
+	}
+}
+
+func TestDetectOnline(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new StreamerState
+		want     bool
+	}{
+		{"offline to live", testOfflineState(), testLiveState(), true},
+		{"still live", testLiveState(), testLiveState(), false},
+		{"still offline", testOfflineState(), testOfflineState(), false},
+		{"live to offline", testLiveState(), testOfflineState(), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change, ok := DetectOnline(tt.old, tt.new)
+			if ok != tt.want {
+				t.Fatalf("DetectOnline ok = %v, want %v", ok, tt.want)
+			}
+			if !ok {
+				return
+			}
+			if change.Type != config.ChangeOnline || change.Streamer != "streamer" || change.StreamID != "40001" {
+				t.Errorf("DetectOnline = %+v, want online for stream 40001", change)
+			}
+			if change.CurrentState != tt.new {
+				t.Errorf("CurrentState = %+v, want the new state", change.CurrentState)
+			}
+		})
+	}
+}
+
+func TestDetectOffline(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new StreamerState
+		want     bool
+	}{
+		{"live to offline", testLiveState(), testOfflineState(), true},
+		{"still live", testLiveState(), testLiveState(), false},
+		{"still offline", testOfflineState(), testOfflineState(), false},
+		{"offline to live", testOfflineState(), testLiveState(), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change, ok := DetectOffline(tt.old, tt.new)
+			if ok != tt.want {
+				t.Fatalf("DetectOffline ok = %v, want %v", ok, tt.want)
+			}
+			if !ok {
+				return
+			}
+			if change.Type != config.ChangeOffline || change.Streamer != "streamer" {
+				t.Errorf("DetectOffline = %+v, want offline for streamer", change)
+			}
+			// 終了した配信の情報は新しい状態には残らないため、直前の状態から引き継ぐ
+			if change.StreamStartedAt != tt.old.StartedAt || change.StreamID != tt.old.StreamID {
+				t.Errorf("StreamStartedAt/StreamID = %q/%q, want %q/%q from the old state",
+					change.StreamStartedAt, change.StreamID, tt.old.StartedAt, tt.old.StreamID)
+			}
+		})
+	}
+}
+
+func TestDetectTitleChange(t *testing.T) {
+	staleOld := testOfflineState()
+	staleOld.OfflineSourced = true
+
+	tests := []struct {
+		name     string
+		old      StreamerState
+		newTitle string
+		want     bool
+	}{
+		{"changed", testLiveState(), "New title", true},
+		{"unchanged", testLiveState(), "Ranked grind", false},
+		{"cleared", testLiveState(), "", false},
+		{"whitespace only is a change", testLiveState(), "Ranked grind ", true},
+		{"stale offline source", staleOld, "New title", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newState := testLiveState()
+			newState.Title = tt.newTitle
+			change, ok := DetectTitleChange(tt.old, newState)
+			if ok != tt.want {
+				t.Fatalf("DetectTitleChange ok = %v, want %v", ok, tt.want)
+			}
+			if !ok {
+				return
+			}
+			if change.Type != config.ChangeTitleChange || change.OldValue != tt.old.Title || change.NewValue != tt.newTitle {
+				t.Errorf("DetectTitleChange = %+v, want %q → %q", change, tt.old.Title, tt.newTitle)
+			}
+		})
+	}
+}
+
+func TestDetectGameChange(t *testing.T) {
+	staleOld := testOfflineState()
+	staleOld.OfflineSourced = true
+
+	tests := []struct {
+		name     string
+		old      StreamerState
+		gameID   string
+		gameName string
+		want     bool
+	}{
+		{"changed", testLiveState(), "200", "Just Chatting", true},
+		{"unchanged", testLiveState(), "100", "Minecraft", false},
+		// ゲームはIDで比較するため、表示名だけの変化は変更としない
+		{"renamed only", testLiveState(), "100", "Minecraft: Java Edition", false},
+		{"cleared", testLiveState(), "", "", true},
+		{"stale offline source", staleOld, "200", "Just Chatting", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newState := testLiveState()
+			newState.GameID = tt.gameID
+			newState.GameName = tt.gameName
+			change, ok := DetectGameChange(tt.old, newState)
+			if ok != tt.want {
+				t.Fatalf("DetectGameChange ok = %v, want %v", ok, tt.want)
+			}
+			if !ok {
+				return
+			}
+			if change.Type != config.ChangeGameChange || change.OldValue != tt.old.GameName || change.NewValue != tt.gameName {
+				t.Errorf("DetectGameChange = %+v, want %q → %q", change, tt.old.GameName, tt.gameName)
+			}
+		})
+	}
+}
+
+func TestDetectSignificantTitleChange(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldTitle string
+		newTitle string
+		want     bool
+	}{
+		{"trailing space", "Ranked grind", "Ranked grind ", false},
+		{"leading space", "Ranked grind", "  Ranked grind", false},
+		{"collapsed spaces", "Ranked  grind", "Ranked grind", false},
+		{"tab and newline", "Ranked grind", "Ranked\tgrind\n", false},
+		{"case only", "Ranked grind", "RANKED Grind", false},
+		{"case and whitespace", "Ranked grind", " ranked   GRIND ", false},
+		{"different words", "Ranked grind", "Casual grind", true},
+		{"added word", "Ranked grind", "Ranked grind day 2", true},
+		{"cleared", "Ranked grind", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := testLiveState()
+			old.Title = tt.oldTitle
+			newState := testLiveState()
+			newState.Title = tt.newTitle
+
+			change, ok := DetectSignificantTitleChange(old, newState)
+			if ok != tt.want {
+				t.Fatalf("DetectSignificantTitleChange(%q, %q) ok = %v, want %v", tt.oldTitle, tt.newTitle, ok, tt.want)
+			}
+			if !ok {
+				return
+			}
+			// 通知には正規化前のタイトルを使う
+			if change.Type != config.ChangeTitleChange || change.OldValue != tt.oldTitle || change.NewValue != tt.newTitle {
+				t.Errorf("DetectSignificantTitleChange = %+v, want %q → %q unnormalized", change, tt.oldTitle, tt.newTitle)
+			}
+		})
+	}
+}
+
+func TestNormalizeTitle(t *testing.T) {
+	if got := NormalizeTitle("  Ranked \t grind\n "); got != "Ranked grind" {
+		t.Errorf("NormalizeTitle = %q, want %q", got, "Ranked grind")
+	}
+}
+
+func TestDetectorsFor(t *testing.T) {
+	old := testLiveState()
+	newState := old
+	newState.Title = "ranked GRIND "
+
+	var cfg config.Config
+	if got := DetectChanges(&old, newState, DetectorsFor(&cfg)...); len(got) != 1 {
+		t.Errorf("default detectors: got %v, want the cosmetic title change", changeTypes(got))
+	}
+
+	cfg.Notifications.IgnoreCosmeticTitleChanges = true
+	if got := DetectChanges(&old, newState, DetectorsFor(&cfg)...); len(got) != 0 {
+		t.Errorf("ignoreCosmeticTitleChanges: got %v, want no changes", changeTypes(got))
+	}
+}
@@ -0,0 +1,23 @@
+// Package monitor は配信者の状態監視と変化検出を提供する。
+//
+// Pollerは設定の配信者を定期的にポーリングしてStreamerStateを構築し、前回の状態との差分を
+// DetectedChangeとしてChangeHandlerに渡す。DetectedChange・StreamerState・config.Change*の通知タイプは
+// 独自の通知先を実装するための公開の契約で、既存の項目の意味は変更しない。
+//
+// 通知タイプごとに設定されるDetectedChangeの項目:
+//
+//   - online: StreamID, FollowerCount(notifications.onlineFieldsでfollowersを表示する場合)。開始時刻はCurrentState.StartedAt
+//   - offline: StreamStartedAt, StreamID, VodURL・VodThumbnailURL(取得できた場合)
+//   - titleChange: OldValue, NewValue(タイトル)
+//   - gameChange: OldValue, NewValue(ゲーム名)
+//   - titleAndGameChange: OldTitle, NewTitle, OldGame, NewGame
+//   - scheduledReminder: NewTitle, NewGame, ScheduledStartAt
+//   - profileUpdate: OldValue, NewValue(表示名), OldProfileImageURL
+//   - reconnect: StreamStartedAt, StreamID, OfflineDuration
+//   - stillLive: StreamStartedAt, StreamID
+//   - vodPublished: StreamStartedAt, StreamID, NewValue(VODのID), NewTitle(VODのタイトル), VodURL, VodThumbnailURL, VodDuration
+//
+// どの通知タイプでもType・Streamer・CurrentStateは設定される。
+// DeferredがtrueのDetectedChangeは保留していた通知を一部のWebhookへ送るためのもので、同じ変更は検出時に一度渡している。
+// 独自の検出器はDetector型の関数として実装し、Poller.AddDetectorで追加する。
+package monitor
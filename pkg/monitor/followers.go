@@ -0,0 +1,45 @@
+package monitor
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+)
+
+// followerCacheTTL はフォロワー数を再取得するまでの間隔。表示用の目安のため、配信のたびには取得しない。
+const followerCacheTTL = time.Hour
+
+// cachedFollowers は取得済みのフォロワー数。
+type cachedFollowers struct {
+	count     int
+	fetchedAt time.Time
+}
+
+// attachFollowerCount はnotifications.onlineFieldsでフォロワー数を表示する場合に、配信開始の変更へフォロワー数を設定する。
+// ユーザーアクセストークンが未設定なら何もしない。取得に失敗した場合はフォロワー数なしで通知する。
+func (p *Poller) attachFollowerCount(ctx context.Context, key string, changes []DetectedChange, userID string, now time.Time) {
+	if !p.api.HasUserToken() || !slices.Contains(p.cfg.Notifications.OnlineFields, config.OnlineFieldFollowers) {
+		return
+	}
+	for i := range changes {
+		if changes[i].Type != config.ChangeOnline {
+			continue
+		}
+		cached, ok := p.followers[key]
+		if !ok || now.Sub(cached.fetchedAt) >= followerCacheTTL {
+			count, err := p.api.GetFollowerCount(ctx, userID)
+			if err != nil {
+				p.stats.recordAPIError()
+				slog.Warn("フォロワー数取得失敗", "streamer", changes[i].Streamer, "error", err)
+				return
+			}
+			cached = cachedFollowers{count: count, fetchedAt: now}
+			p.followers[key] = cached
+		}
+		count := cached.count
+		changes[i].FollowerCount = &count
+	}
+}
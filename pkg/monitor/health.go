@@ -0,0 +1,162 @@
+package monitor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// healthWarnAfter は配信者のポーリング失敗がこの時間続いたら警告する。
+const healthWarnAfter = 10 * time.Minute
+
+// StreamerHealth は配信者ごとのポーリングのヘルス状態。
+type StreamerHealth struct {
+	Username          string    `json:"username"`
+	LastSuccessAt     time.Time `json:"lastSuccessAt,omitzero"`
+	LastErrorAt       time.Time `json:"lastErrorAt,omitzero"`
+	LastError         string    `json:"lastError,omitempty"`
+	ConsecutiveErrors int       `json:"consecutiveErrors"`
+	// FailingSince は連続エラーが始まった時刻。正常時はゼロ値。
+	FailingSince time.Time `json:"failingSince,omitzero"`
+}
+
+// Healthy は直近のポーリングが成功しているかを返す。
+func (h StreamerHealth) Healthy() bool {
+	return h.ConsecutiveErrors == 0
+}
+
+// healthTracker は配信者ごとのヘルス状態を記録し、ファイルに書き出す。
+type healthTracker struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]*StreamerHealth
+	// warned は長期間失敗の警告を出力済みの配信者(キー: login名小文字)。
+	warned map[string]bool
+}
+
+func newHealthTracker(path string) *healthTracker {
+	return &healthTracker{
+		path:    path,
+		entries: make(map[string]*StreamerHealth),
+		warned:  make(map[string]bool),
+	}
+}
+
+// entry は配信者のヘルス状態を返す。未登録なら作成する。呼び出し側でmuを保持すること。
+func (h *healthTracker) entry(username string) *StreamerHealth {
+	key := strings.ToLower(username)
+	e, ok := h.entries[key]
+	if !ok {
+		e = &StreamerHealth{Username: username}
+		h.entries[key] = e
+	}
+	return e
+}
+
+// success はポーリング成功を記録する。失敗が続いていた場合は回復をログ出力する。
+func (h *healthTracker) success(username string, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	e := h.entry(username)
+	if e.ConsecutiveErrors > 0 {
+		slog.Info("配信者のポーリングが回復しました",
+			"streamer", username,
+			"errors", e.ConsecutiveErrors,
+			"downtime", now.Sub(e.FailingSince).Round(time.Second).String())
+	}
+	e.LastSuccessAt = now
+	e.ConsecutiveErrors = 0
+	e.FailingSince = time.Time{}
+	delete(h.warned, strings.ToLower(username))
+}
+
+// failure はポーリング失敗を記録する。失敗がhealthWarnAfter以上続いたら一度だけ警告する。
+func (h *healthTracker) failure(username string, err error, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	e := h.entry(username)
+	if e.ConsecutiveErrors == 0 {
+		e.FailingSince = now
+	}
+	e.ConsecutiveErrors++
+	e.LastErrorAt = now
+	e.LastError = err.Error()
+
+	key := strings.ToLower(username)
+	if now.Sub(e.FailingSince) >= healthWarnAfter && !h.warned[key] {
+		h.warned[key] = true
+		lastSuccess := "なし"
+		if !e.LastSuccessAt.IsZero() {
+			lastSuccess = e.LastSuccessAt.Format(time.DateTime)
+		}
+		slog.Warn("配信者のポーリングが長時間失敗しています",
+			"streamer", username,
+			"errors", e.ConsecutiveErrors,
+			"lastSuccess", lastSuccess,
+			"error", err)
+	}
+}
+
+// snapshot はユーザー名順のヘルス状態一覧を返す。
+func (h *healthTracker) snapshot() []StreamerHealth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	list := make([]StreamerHealth, 0, len(h.entries))
+	for _, e := range h.entries {
+		list = append(list, *e)
+	}
+	slices.SortFunc(list, func(a, b StreamerHealth) int {
+		return strings.Compare(strings.ToLower(a.Username), strings.ToLower(b.Username))
+	})
+	return list
+}
+
+// save はヘルス状態を一時ファイル経由でアトミックに書き出す。
+// 監視プロセスとは別プロセスのinfoコマンドから参照するために使う。
+func (h *healthTracker) save() {
+	data, err := json.MarshalIndent(h.snapshot(), "", "  ")
+	if err != nil {
+		slog.Error("ヘルス状態のJSON変換に失敗", "error", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		slog.Error("ヘルス状態の保存先作成に失敗", "error", err)
+		return
+	}
+
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		slog.Error("ヘルス状態の保存に失敗", "error", err)
+		return
+	}
+	if err := os.Rename(tmp, h.path); err != nil {
+		slog.Error("ヘルス状態の保存に失敗", "error", err)
+	}
+}
+
+// LoadHealth は監視プロセスが書き出したヘルス状態を読み込む。ファイルがなければos.ErrNotExistを返す。
+func LoadHealth(path string) ([]StreamerHealth, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ヘルス状態の読み込みに失敗: %w", err)
+	}
+	var list []StreamerHealth
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("ヘルス状態の解析に失敗: %w", err)
+	}
+	return list, nil
+}
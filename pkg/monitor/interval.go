@@ -0,0 +1,177 @@
+package monitor
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+)
+
+const (
+	// autoIntervalRecalc は自動調整した間隔を再計算する周期。
+	autoIntervalRecalc = 6 * time.Hour
+	// frequencyWindow は配信頻度の算出に使う履歴の期間。
+	frequencyWindow = 28 * 24 * time.Hour
+	// frequentStreamsPerWeek はこの回数以上配信する配信者を最短間隔にする。
+	frequentStreamsPerWeek = 7.0
+)
+
+// FrequencyProvider は配信者の週あたり配信回数を返す。判断できるだけの履歴がなければokはfalse。
+type FrequencyProvider func(username string, now time.Time, window time.Duration) (perWeek float64, ok bool)
+
+// intervalScheduler は配信者ごとのポーリング間隔と次回ポーリング時刻を管理する。
+// Runのポーリングループからのみ使う。
+type intervalScheduler struct {
+	cfg       *config.Config
+	frequency FrequencyProvider
+
+	intervals      map[string]time.Duration
+	nextPoll       map[string]time.Time
+	recalculatedAt time.Time
+	// offlineStreak は連続してオフラインだったポーリング回数。配信中なら0(キー: login名小文字)。
+	// polling.adaptiveが有効な場合のみ記録する。
+	offlineStreak map[string]int
+}
+
+func newIntervalScheduler(cfg *config.Config) *intervalScheduler {
+	return &intervalScheduler{
+		cfg:           cfg,
+		intervals:     make(map[string]time.Duration),
+		nextPoll:      make(map[string]time.Time),
+		offlineStreak: make(map[string]int),
+	}
+}
+
+// tick はポーリングループの刻み幅を返す。全配信者の間隔のうち最短のもの。
+func (s *intervalScheduler) tick() time.Duration {
+	tick := time.Duration(s.cfg.Polling.IntervalSeconds) * time.Second
+	if s.cfg.Polling.Auto.Enabled {
+		tick = min(tick, time.Duration(s.cfg.Polling.Auto.MinSeconds)*time.Second)
+	}
+	if adaptive := s.cfg.Polling.Adaptive; adaptive.Enabled && adaptive.LiveSeconds > 0 {
+		tick = min(tick, time.Duration(adaptive.LiveSeconds)*time.Second)
+	}
+	for _, sc := range s.cfg.Streamers {
+		if sc.IntervalSeconds > 0 {
+			tick = min(tick, time.Duration(sc.IntervalSeconds)*time.Second)
+		}
+	}
+	return tick
+}
+
+// recalculate は配信者ごとのポーリング間隔を決定する。
+// 優先順位は 配信者個別の指定 → 自動調整 → polling.intervalSeconds。
+func (s *intervalScheduler) recalculate(now time.Time) {
+	base := time.Duration(s.cfg.Polling.IntervalSeconds) * time.Second
+	auto := s.cfg.Polling.Auto
+
+	for _, sc := range s.cfg.Streamers {
+		key := strings.ToLower(sc.Username)
+		interval := base
+		switch {
+		case sc.IntervalSeconds > 0:
+			interval = time.Duration(sc.IntervalSeconds) * time.Second
+		case auto.Enabled && s.frequency != nil:
+			if perWeek, ok := s.frequency(sc.Username, now, frequencyWindow); ok {
+				interval = autoInterval(perWeek, auto)
+				slog.Debug("ポーリング間隔を自動調整",
+					"streamer", sc.Username,
+					"streamsPerWeek", perWeek,
+					"interval", interval.String())
+			}
+		}
+		s.intervals[key] = interval
+	}
+	s.recalculatedAt = now
+}
+
+// autoInterval は週あたりの配信回数からポーリング間隔を線形に決定する。
+func autoInterval(perWeek float64, auto config.AutoIntervalConfig) time.Duration {
+	ratio := min(perWeek/frequentStreamsPerWeek, 1)
+	seconds := float64(auto.MaxSeconds) - float64(auto.MaxSeconds-auto.MinSeconds)*ratio
+	return time.Duration(seconds) * time.Second
+}
+
+// due は今回ポーリングすべき配信者を返し、それぞれの次回ポーリング時刻を進める。
+func (s *intervalScheduler) due(now time.Time) []config.StreamerConfig {
+	if s.cfg.Polling.Auto.Enabled && now.Sub(s.recalculatedAt) >= autoIntervalRecalc {
+		s.recalculate(now)
+	}
+
+	// ティッカーの揺らぎで1周期遅れないよう、刻み幅の半分を許容する
+	slack := s.tick() / 2
+
+	var due []config.StreamerConfig
+	for _, sc := range s.cfg.Streamers {
+		key := strings.ToLower(sc.Username)
+		if next, ok := s.nextPoll[key]; ok && now.Add(slack).Before(next) {
+			continue
+		}
+		s.nextPoll[key] = now.Add(s.interval(sc))
+		due = append(due, sc)
+	}
+	return due
+}
+
+// interval は配信者の次回ポーリングまでの間隔を返す。
+func (s *intervalScheduler) interval(sc config.StreamerConfig) time.Duration {
+	key := strings.ToLower(sc.Username)
+	base, ok := s.intervals[key]
+	if !ok {
+		base = time.Duration(s.cfg.Polling.IntervalSeconds) * time.Second
+	}
+	adaptive := s.cfg.Polling.Adaptive
+	if !adaptive.Enabled || sc.IntervalSeconds > 0 {
+		return base
+	}
+	streak, ok := s.offlineStreak[key]
+	if !ok {
+		return base
+	}
+	return adaptiveInterval(base, streak, adaptive)
+}
+
+// adaptiveInterval は配信状態に応じて基本の間隔を調整する。
+// 配信中(streakが0)はLiveSeconds、オフラインはstreak回だけBackoffFactor倍してMaxSecondsで頭打ちにする。
+func adaptiveInterval(base time.Duration, streak int, adaptive config.AdaptiveIntervalConfig) time.Duration {
+	if streak == 0 {
+		if adaptive.LiveSeconds > 0 {
+			return min(base, time.Duration(adaptive.LiveSeconds)*time.Second)
+		}
+		return base
+	}
+	limit := time.Duration(adaptive.MaxSeconds) * time.Second
+	if limit <= base {
+		return base
+	}
+	// 倍率の累乗がオーバーフローしないよう上限に達した時点で打ち切る
+	interval := float64(base)
+	for range streak {
+		interval *= adaptive.Backoff()
+		if interval >= float64(limit) {
+			return limit
+		}
+	}
+	return time.Duration(interval)
+}
+
+// observe はポーリング結果の配信状態を記録する。polling.adaptiveが有効な場合のみ使う。
+// 配信開始などで間隔が短くなった場合は、次回ポーリング時刻を前倒しする。
+func (s *intervalScheduler) observe(sc config.StreamerConfig, isLive bool, polledAt time.Time) {
+	if !s.cfg.Polling.Adaptive.Enabled || sc.IntervalSeconds > 0 {
+		return
+	}
+	key := strings.ToLower(sc.Username)
+	if isLive {
+		s.offlineStreak[key] = 0
+	} else {
+		s.offlineStreak[key]++
+	}
+
+	next := polledAt.Add(s.interval(sc))
+	if current, ok := s.nextPoll[key]; ok && next.Before(current) {
+		slog.Debug("配信状態に応じて次回ポーリングを前倒し", "streamer", sc.Username, "next", next.Format(time.RFC3339))
+		s.nextPoll[key] = next
+	}
+}
@@ -0,0 +1,174 @@
+package monitor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LifetimeStats は配信者ごとの累計の配信記録。再起動後も引き継ぐためファイルに保存する。
+type LifetimeStats struct {
+	Username string `json:"username"`
+	// Streams は検出した配信の回数。
+	Streams int `json:"streams"`
+	// LiveSeconds は終了を確認した配信の合計時間(秒)。
+	LiveSeconds int64 `json:"liveSeconds"`
+	// LastLiveAt は最後に配信中を確認した時刻。
+	LastLiveAt time.Time `json:"lastLiveAt,omitzero"`
+	// CurrentStartedAt は配信中の配信の開始時刻(ISO 8601)。配信外なら空。
+	// 再起動をまたいだ同じ配信を二重に数えないために使う。
+	CurrentStartedAt string `json:"currentStartedAt,omitempty"`
+}
+
+// LiveDuration は累計の配信時間を返す。
+func (s LifetimeStats) LiveDuration() time.Duration {
+	return time.Duration(s.LiveSeconds) * time.Second
+}
+
+// lifetimeTracker は配信者ごとの累計の配信記録を更新し、ファイルに書き出す。
+type lifetimeTracker struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]*LifetimeStats
+	// dirty は前回の書き出し以降に更新があったか。
+	dirty bool
+}
+
+// newLifetimeTracker は保存済みの記録を読み込んでlifetimeTrackerを作成する。読み込めなければ空の記録から始める。
+func newLifetimeTracker(path string) *lifetimeTracker {
+	t := &lifetimeTracker{path: path, entries: make(map[string]*LifetimeStats)}
+	list, err := LoadLifetimeStats(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("累計の配信記録を読み込めないため、記録をやり直します", "error", err)
+	}
+	for i := range list {
+		t.entries[strings.ToLower(list[i].Username)] = &list[i]
+	}
+	return t
+}
+
+// observe はポーリングした配信者の状態から累計を更新する。
+// 配信の開始時刻が変わったら新しい配信として数え、配信外になったら開始から最後に配信中を確認した時刻までを加算する。
+// 監視の停止中に終わった配信も、再開後の最初のポーリングで最後に確認した時刻までを加算する。
+func (t *lifetimeTracker) observe(username string, state StreamerState, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := strings.ToLower(username)
+	e, ok := t.entries[key]
+	if !ok {
+		e = &LifetimeStats{Username: username}
+		t.entries[key] = e
+	}
+
+	if state.IsLive {
+		if e.CurrentStartedAt != state.StartedAt {
+			t.closeStream(e)
+			e.Streams++
+			e.CurrentStartedAt = state.StartedAt
+		}
+		e.LastLiveAt = now
+		t.dirty = true
+		return
+	}
+	if e.CurrentStartedAt != "" {
+		t.closeStream(e)
+		t.dirty = true
+	}
+}
+
+// closeStream は配信中の配信の時間を累計に加算する。呼び出し側でmuを保持すること。
+func (t *lifetimeTracker) closeStream(e *LifetimeStats) {
+	if e.CurrentStartedAt == "" {
+		return
+	}
+	if start, err := time.Parse(time.RFC3339, e.CurrentStartedAt); err == nil && e.LastLiveAt.After(start) {
+		e.LiveSeconds += int64(e.LastLiveAt.Sub(start).Seconds())
+	}
+	e.CurrentStartedAt = ""
+}
+
+// save は更新があれば記録を一時ファイル経由でアトミックに書き出す。
+func (t *lifetimeTracker) save() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.dirty {
+		return
+	}
+
+	list := make([]LifetimeStats, 0, len(t.entries))
+	for _, e := range t.entries {
+		list = append(list, *e)
+	}
+	if err := writeLifetimeStats(t.path, list); err != nil {
+		slog.Error("累計の配信記録の保存に失敗", "error", err)
+		return
+	}
+	t.dirty = false
+}
+
+// writeLifetimeStats はユーザー名順に並べた記録を一時ファイル経由でアトミックに書き出す。
+func writeLifetimeStats(path string, list []LifetimeStats) error {
+	slices.SortFunc(list, func(a, b LifetimeStats) int {
+		return strings.Compare(strings.ToLower(a.Username), strings.ToLower(b.Username))
+	})
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("累計の配信記録のJSON変換に失敗: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("累計の配信記録の保存先作成に失敗: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadLifetimeStats は保存された累計の配信記録を読み込む。ファイルがなければos.ErrNotExistを返す。
+func LoadLifetimeStats(path string) ([]LifetimeStats, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("累計の配信記録の読み込みに失敗: %w", err)
+	}
+	var list []LifetimeStats
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("累計の配信記録の解析に失敗: %w", err)
+	}
+	return list, nil
+}
+
+// ResetLifetimeStats は累計の配信記録を消去し、消去した配信者の数を返す。usernameが空なら全配信者を消去する。
+// 監視中のプロセスは読み込み済みの記録で上書きするため、監視を停止してから呼ぶこと。
+func ResetLifetimeStats(path, username string) (int, error) {
+	list, err := LoadLifetimeStats(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	kept := slices.DeleteFunc(slices.Clone(list), func(s LifetimeStats) bool {
+		return username == "" || strings.EqualFold(s.Username, username)
+	})
+	removed := len(list) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+	if err := writeLifetimeStats(path, kept); err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
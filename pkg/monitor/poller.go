@@ -0,0 +1,659 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+	"github.com/yuu1111/StreamNotifier/pkg/twitch"
+)
+
+// errUserNotFound はTwitchでユーザーが見つからない(凍結・改名・削除等)ことを表す。
+var errUserNotFound = errors.New("Twitchでユーザーが見つかりません")
+
+// ChangeHandler は変更検出時に呼び出されるコールバック型。
+type ChangeHandler func(changes []DetectedChange, streamerConfig config.StreamerConfig)
+
+// ResolvedHandler は見つからなかった配信者がユーザー情報の再取得で見つかったときに呼び出されるコールバック型。
+type ResolvedHandler func(streamerConfig config.StreamerConfig, user twitch.User)
+
+// Poller は配信者の状態を定期的にポーリングし変更を検出する。
+type Poller struct {
+	api          *twitch.API
+	cfg          *config.Config
+	onChanges    ChangeHandler
+	stateManager *StateManager
+	userCache    *userCache
+	// usersRefreshedAt はuserCacheを最後に取得した時刻。
+	usersRefreshedAt time.Time
+	// pendingTitles はデバウンス中のタイトル変更(キー: login名小文字)。
+	pendingTitles map[string]*pendingTitleChange
+	// pendingOnlines は視聴者数の閾値待ちで保留中の配信開始通知(キー: login名小文字)。
+	pendingOnlines map[string]*pendingOnline
+	// pendingUptimes は最低配信時間の確認待ちの配信開始通知(キー: login名小文字)。
+	pendingUptimes map[string]*pendingUptime
+	// pendingVods はVODの公開待ちで保留中の配信終了通知(キー: login名小文字)。
+	pendingVods map[string]*pendingVod
+	// pendingCombines は別ポーリングの変更との統合待ちのタイトル/ゲーム変更(キー: login名小文字)。
+	pendingCombines map[string]*pendingCombine
+	// pendingReconnects は再接続を待って保留中の配信終了通知(キー: login名小文字)。
+	pendingReconnects map[string]*pendingReconnect
+	// followers は配信者ごとのフォロワー数のキャッシュ(キー: login名小文字)。
+	followers map[string]cachedFollowers
+	// offlineSince は配信中の配信者がオフラインに見え始めた時刻(キー: login名小文字)。polling.offlineGraceSeconds用。
+	offlineSince map[string]time.Time
+	schedule     *scheduleTracker
+	vodPublish   *vodPublishTracker
+	stats        Stats
+	// detectors は状態変化の検出器。
+	detectors []Detector
+	// health は配信者ごとのポーリング成否。
+	health *healthTracker
+	// lifetime は配信者ごとの累計の配信記録。
+	lifetime *lifetimeTracker
+	// startTimes は配信中の配信者の開始時刻の記録。再起動後に開始時刻を補うために使う。
+	startTimes *startTimeStore
+	// intervals は配信者ごとのポーリング間隔の管理。
+	intervals *intervalScheduler
+	// onResolved は見つからなかった配信者が見つかったときに呼び出す。未設定なら呼び出さない。
+	onResolved ResolvedHandler
+	// manual はTriggerPollによる手動ポーリングの要求。実行前の重複した要求は1回にまとめる。
+	manual chan struct{}
+}
+
+// initialSummary は初回ポーリングした配信者の状態集計。配信者ごとのログの代わりに1行で出力する。
+type initialSummary struct {
+	online  int
+	offline int
+}
+
+func (s *initialSummary) add(isLive bool) {
+	if isLive {
+		s.online++
+	} else {
+		s.offline++
+	}
+}
+
+// log は初回ポーリングした配信者がいればサマリーを出力する。
+func (s *initialSummary) log() {
+	if s.online+s.offline == 0 {
+		return
+	}
+	slog.Info(fmt.Sprintf("初期状態: %d人オンライン / %d人オフライン", s.online, s.offline))
+}
+
+// pendingTitleChange は通知を保留中のタイトル変更。
+type pendingTitleChange struct {
+	change   DetectedChange
+	deadline time.Time
+}
+
+// NewPoller はPollerインスタンスを作成する。
+func NewPoller(api *twitch.API, cfg *config.Config, onChanges ChangeHandler) *Poller {
+	return &Poller{
+		api:               api,
+		cfg:               cfg,
+		onChanges:         onChanges,
+		stateManager:      NewStateManager(),
+		userCache:         newUserCache(),
+		pendingTitles:     make(map[string]*pendingTitleChange),
+		pendingOnlines:    make(map[string]*pendingOnline),
+		pendingUptimes:    make(map[string]*pendingUptime),
+		pendingVods:       make(map[string]*pendingVod),
+		pendingCombines:   make(map[string]*pendingCombine),
+		pendingReconnects: make(map[string]*pendingReconnect),
+		offlineSince:      make(map[string]time.Time),
+		followers:         make(map[string]cachedFollowers),
+		schedule:          newScheduleTracker(),
+		vodPublish:        newVodPublishTracker(),
+		intervals:         newIntervalScheduler(cfg),
+		detectors:         DetectorsFor(cfg),
+		health:            newHealthTracker(cfg.HealthPath()),
+		lifetime:          newLifetimeTracker(cfg.LifetimeStatsPath()),
+		startTimes:        newStartTimeStore(cfg.StreamStartsPath()),
+		manual:            make(chan struct{}, 1),
+	}
+}
+
+// SetFrequencyProvider は配信者の週あたり配信回数を返す関数を設定する。
+// polling.autoが有効な場合、この値からポーリング間隔を自動調整する。
+func (p *Poller) SetFrequencyProvider(fn FrequencyProvider) {
+	p.intervals.frequency = fn
+}
+
+// SetResolvedHandler は見つからなかった配信者がユーザー情報の再取得で見つかったときに呼び出す関数を設定する。Run前に呼ぶこと。
+func (p *Poller) SetResolvedHandler(fn ResolvedHandler) {
+	p.onResolved = fn
+}
+
+// AddDetector は標準の検出器に加えて使う検出器を追加する。Run前に呼ぶこと。
+func (p *Poller) AddDetector(d Detector) {
+	p.detectors = append(p.detectors, d)
+}
+
+// Stats は現在の実行統計を返す。
+func (p *Poller) Stats() StatsSnapshot {
+	snapshot := p.stats.snapshot()
+	snapshot.Streamers = p.health.snapshot()
+	return snapshot
+}
+
+// Run はポーリングループを開始する。ctxがキャンセルされるまで実行する。
+func (p *Poller) Run(ctx context.Context) error {
+	if err := p.initializeWithRetry(ctx); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	p.warnClockSkew()
+
+	p.stats.start(time.Now())
+	p.intervals.recalculate(time.Now())
+	tick := p.intervals.tick()
+	p.runPoll(ctx, tick)
+
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	slog.Info("ポーリング開始",
+		"interval", p.cfg.Polling.IntervalSeconds,
+		"tick", tick.String(),
+		"streamers", len(p.cfg.Streamers))
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.logSummary()
+			return nil
+		case <-ticker.C:
+			p.runPoll(ctx, tick)
+		case <-p.manual:
+			slog.Info("手動ポーリングが要求されました")
+			p.manualPoll(ctx)
+		}
+	}
+}
+
+// logSummary は監視終了時に実行時間・ポーリング回数・通知タイプ別の変更数・APIエラー数を1行で出力する。
+func (p *Poller) logSummary() {
+	s := p.stats.snapshot()
+	slog.Info("ポーリング停止",
+		"uptime", (time.Duration(s.UptimeSeconds) * time.Second).String(),
+		"polls", s.Polls,
+		"changes", s.Changes,
+		"apiErrors", s.APIErrors)
+}
+
+// emit は変更に検出時刻を付け、統計に記録してハンドラーに渡す。
+func (p *Poller) emit(changes []DetectedChange, sc config.StreamerConfig) {
+	now := time.Now()
+	for i := range changes {
+		if changes[i].DetectedAt.IsZero() {
+			changes[i].DetectedAt = now
+		}
+	}
+	p.stats.recordChanges(changes)
+	p.onChanges(changes, sc)
+}
+
+// runPoll はpollを実行し、所要時間がintervalを超えた場合は間隔が短すぎる旨を警告する。
+// ポーリングはRunのループ内で順に実行するため重複しない。超過中に発火したtickはtime.Tickerが間引く。
+func (p *Poller) runPoll(ctx context.Context, interval time.Duration) {
+	start := time.Now()
+	p.poll(ctx, p.intervals.due(start))
+
+	if elapsed := time.Since(start); elapsed > interval {
+		slog.Warn("ポーリングが間隔を超過しました。配信者数に対して間隔が短すぎる可能性があります",
+			"elapsed", elapsed.Round(time.Millisecond).String(),
+			"interval", interval.String(),
+			"streamers", len(p.cfg.Streamers))
+	}
+}
+
+// TriggerPoll は全配信者の臨時のポーリングを要求する。どのgoroutineからでも呼べる。
+// ポーリングはRunのループ内で実行するため、実行中のポーリングがあればその終了後に行う。
+func (p *Poller) TriggerPoll() {
+	select {
+	case p.manual <- struct{}{}:
+	default:
+		// 未処理の要求があれば、その1回で全配信者をポーリングする
+	}
+}
+
+// manualPoll は全配信者を臨時にポーリングする。通常の次回ポーリング時刻には影響しない。
+func (p *Poller) manualPoll(ctx context.Context) {
+	p.poll(ctx, p.cfg.Streamers)
+}
+
+// initializeUserCache はユーザー情報をキャッシュに読み込む。
+func (p *Poller) initializeUserCache(ctx context.Context) error {
+	usernames := make([]string, len(p.cfg.Streamers))
+	for i, s := range p.cfg.Streamers {
+		usernames[i] = s.Username
+	}
+
+	users, err := p.api.GetUsers(ctx, usernames)
+	if err != nil {
+		return err
+	}
+
+	p.userCache.SetAll(users)
+	p.usersRefreshedAt = time.Now()
+
+	for _, s := range p.cfg.Streamers {
+		key := strings.ToLower(s.Username)
+		if _, ok := p.userCache.Get(key); !ok {
+			slog.Warn("ユーザーが見つかりません", "username", s.Username)
+		}
+	}
+
+	return nil
+}
+
+// logAPIError はTwitch APIのエラーを記録してログ出力する。認証エラーはレスポンスの内容ではなく設定の確認を促す。
+func (p *Poller) logAPIError(msg string, err error) {
+	p.stats.recordAPIError()
+	var twErr *twitch.TwitchError
+	if errors.As(err, &twErr) && twErr.IsAuth() {
+		reason := "Twitchの認証に失敗しました。twitch.clientId / clientSecretを確認してください"
+		if twErr.IsInvalidClientSecret() {
+			reason = "クライアントシークレットが無効です"
+		}
+		slog.Error(msg+": "+reason, "status", twErr.StatusCode, "message", twErr.Message)
+		return
+	}
+	slog.Error(msg, "error", err)
+}
+
+// combineChanges はタイトル変更とゲーム変更を同時検出した場合に統合する。
+func combineChanges(changes []DetectedChange) []DetectedChange {
+	var titleChange, gameChange *DetectedChange
+	for i := range changes {
+		switch changes[i].Type {
+		case config.ChangeTitleChange:
+			titleChange = &changes[i]
+		case config.ChangeGameChange:
+			gameChange = &changes[i]
+		}
+	}
+
+	if titleChange == nil || gameChange == nil {
+		return changes
+	}
+
+	combined := DetectedChange{
+		Type:         config.ChangeTitleAndGame,
+		Streamer:     titleChange.Streamer,
+		OldTitle:     titleChange.OldValue,
+		NewTitle:     titleChange.NewValue,
+		OldGame:      gameChange.OldValue,
+		NewGame:      gameChange.NewValue,
+		CurrentState: titleChange.CurrentState,
+	}
+
+	var result []DetectedChange
+	for _, c := range changes {
+		if c.Type != config.ChangeTitleChange && c.Type != config.ChangeGameChange {
+			result = append(result, c)
+		}
+	}
+	return append(result, combined)
+}
+
+// debounceTitleChange はタイトル変更通知を保留し、保留期間内に再変更がなければ最終タイトルで通知する。
+// 期限判定はポーリング毎に行うため、実際の保留時間はポーリング間隔単位で切り上がる。
+func (p *Poller) debounceTitleChange(key string, changes []DetectedChange, newState StreamerState) []DetectedChange {
+	window := time.Duration(p.cfg.Notifications.TitleDebounceSeconds) * time.Second
+	if window <= 0 {
+		return changes
+	}
+
+	now := time.Now()
+	var result []DetectedChange
+	for _, c := range changes {
+		pending, hasPending := p.pendingTitles[key]
+
+		switch c.Type {
+		case config.ChangeTitleChange:
+			if hasPending {
+				pending.change.NewValue = c.NewValue
+				pending.deadline = now.Add(window)
+			} else {
+				p.pendingTitles[key] = &pendingTitleChange{change: c, deadline: now.Add(window)}
+			}
+			continue
+		case config.ChangeTitleAndGame:
+			// ゲーム変更と同時に来た場合は保留分を統合して即時通知する
+			if hasPending {
+				c.OldTitle = pending.change.OldValue
+				delete(p.pendingTitles, key)
+			}
+		}
+		result = append(result, c)
+	}
+
+	pending, ok := p.pendingTitles[key]
+	if !ok || now.Before(pending.deadline) {
+		return result
+	}
+
+	delete(p.pendingTitles, key)
+	// 保留中に元のタイトルへ戻された場合は通知しない
+	if pending.change.OldValue == pending.change.NewValue {
+		return result
+	}
+	pending.change.CurrentState = newState
+	return append(result, pending.change)
+}
+
+// recordOnlineLatency は配信開始(started_at)から検知までの遅延をログと統計に記録する。
+func (p *Poller) recordOnlineLatency(state StreamerState) {
+	startedAt, err := time.Parse(time.RFC3339, state.StartedAt)
+	if err != nil {
+		return
+	}
+
+	latency := time.Since(startedAt)
+	if latency < 0 {
+		latency = 0
+	}
+	p.stats.recordOnlineLatency(latency)
+
+	seconds := int(latency.Seconds())
+	threshold := p.cfg.Polling.LatencyWarnSeconds
+	if threshold > 0 && seconds > threshold {
+		slog.Warn("配信開始の検知が遅延しています",
+			"streamer", state.DisplayName, "latency", seconds, "threshold", threshold)
+		return
+	}
+	slog.Info("配信開始検知", "streamer", state.DisplayName, "latency", seconds)
+}
+
+// BuildStreamerState はAPIレスポンスから配信者状態を構築する。
+func BuildStreamerState(user twitch.User, stream *twitch.Stream, channel *twitch.Channel) StreamerState {
+	state := StreamerState{
+		UserID:          user.ID,
+		Username:        user.Login,
+		DisplayName:     user.DisplayName,
+		ProfileImageURL: user.ProfileImageURL,
+	}
+
+	if stream != nil {
+		state.IsLive = true
+		state.Title = stream.Title
+		state.GameID = stream.GameID
+		state.GameName = stream.GameName
+		state.StartedAt = stream.StartedAt
+		state.ThumbnailURL = stream.ThumbnailURL
+		state.ViewerCount = stream.ViewerCount
+		state.Language = stream.Language
+		state.StreamID = stream.ID
+	} else if channel != nil {
+		state.Title = channel.Title
+		state.GameID = channel.GameID
+		state.GameName = channel.GameName
+	}
+
+	return state
+}
+
+// trackStartedAt は配信中なのに開始時刻が取得できなかった場合に、前回観測した開始時刻で補う。
+// 再起動直後で前回の状態がなければ、ファイルに記録していた同じ配信の開始時刻(restored)を使う。
+// いずれも不明なら初めて配信中を観測した時刻を開始時刻とみなし、Offline時の配信時間計算に使えるようにする。
+func trackStartedAt(oldState *StreamerState, newState *StreamerState, restored string, now time.Time) {
+	if !newState.IsLive || newState.StartedAt != "" {
+		return
+	}
+	if oldState != nil && oldState.IsLive && oldState.StartedAt != "" {
+		newState.StartedAt = oldState.StartedAt
+		return
+	}
+	if oldState == nil && restored != "" {
+		newState.StartedAt = restored
+		return
+	}
+	newState.StartedAt = now.UTC().Format(time.RFC3339)
+}
+
+// applyOfflineGrace は配信中の配信者がオフラインに見えても、polling.offlineGraceSeconds秒続くまでは配信中の状態を維持する。
+func (p *Poller) applyOfflineGrace(key string, oldState *StreamerState, newState *StreamerState, now time.Time) {
+	grace := time.Duration(p.cfg.Polling.OfflineGraceSeconds) * time.Second
+	if grace <= 0 || newState.IsLive || oldState == nil || !oldState.IsLive {
+		delete(p.offlineSince, key)
+		return
+	}
+
+	since, ok := p.offlineSince[key]
+	if !ok {
+		since = now
+		p.offlineSince[key] = now
+	}
+	if now.Sub(since) >= grace {
+		delete(p.offlineSince, key)
+		return
+	}
+	slog.Debug("オフライン猶予中のため配信中として扱います",
+		"streamer", oldState.DisplayName,
+		"remaining", (grace - now.Sub(since)).Round(time.Second).String())
+	*newState = *oldState
+}
+
+// trackOfflineSourced は再起動後の初回ポーリングで配信外だった状態に印を付け、配信開始まで引き継ぐ。
+// withoutChannelInfoがtrueなら配信外の状態はチャンネル情報を持たないため、常に次の配信開始時の比較に使わない。
+func trackOfflineSourced(oldState *StreamerState, newState *StreamerState, withoutChannelInfo bool) {
+	if newState.IsLive {
+		return
+	}
+	newState.OfflineSourced = withoutChannelInfo || oldState == nil || oldState.OfflineSourced
+}
+
+// collectOfflineUserIDs はオフライン配信者のユーザーIDを収集する。
+func (p *Poller) collectOfflineUserIDs(streamers []config.StreamerConfig, streams map[string]twitch.Stream) []string {
+	var ids []string
+	for _, s := range streamers {
+		key := strings.ToLower(s.Username)
+		user, ok := p.userCache.Get(key)
+		if ok {
+			if _, live := streams[key]; !live {
+				ids = append(ids, user.ID)
+			}
+		}
+	}
+	return ids
+}
+
+// attachVodInfo はOffline変更にVOD情報を付与する。
+func (p *Poller) attachVodInfo(ctx context.Context, changes []DetectedChange, userID string) {
+	for i := range changes {
+		if changes[i].Type != config.ChangeOffline {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		vod, err := p.api.GetLatestVod(ctx, userID)
+		if err != nil {
+			p.stats.recordAPIError()
+			slog.Warn("VOD取得失敗", "error", err)
+			continue
+		}
+		if vod == nil {
+			continue
+		}
+		setVodInfo(&changes[i], vod)
+	}
+}
+
+// setVodInfo はVODのURLとサムネイルを配信終了の変更に設定する。
+func setVodInfo(change *DetectedChange, vod *twitch.Video) {
+	change.VodURL = vod.URL
+	thumbnailURL := vod.ThumbnailURL
+	thumbnailURL = strings.ReplaceAll(thumbnailURL, "%{width}", config.ThumbnailWidth)
+	thumbnailURL = strings.ReplaceAll(thumbnailURL, "%{height}", config.ThumbnailHeight)
+	change.VodThumbnailURL = thumbnailURL
+}
+
+// processStreamer は単一配信者の変更を処理する。
+func (p *Poller) processStreamer(
+	ctx context.Context,
+	sc config.StreamerConfig,
+	streams map[string]twitch.Stream,
+	channels map[string]twitch.Channel,
+	summary *initialSummary,
+) {
+	key := strings.ToLower(sc.Username)
+	user, ok := p.userCache.Get(key)
+	if !ok {
+		p.health.failure(sc.Username, errUserNotFound, time.Now())
+		return
+	}
+
+	var streamPtr *twitch.Stream
+	if s, ok := streams[key]; ok {
+		streamPtr = &s
+	}
+
+	var channelPtr *twitch.Channel
+	if ch, ok := channels[key]; ok {
+		channelPtr = &ch
+	}
+
+	newState := BuildStreamerState(user, streamPtr, channelPtr)
+	oldState := p.stateManager.GetState(key)
+	isInitialPoll := oldState == nil
+	p.applyOfflineGrace(key, oldState, &newState, time.Now())
+	restored, _ := p.startTimes.lookup(key, newState.StreamID)
+	trackStartedAt(oldState, &newState, restored, time.Now())
+	withoutChannelInfo := !p.cfg.Polling.OfflineChannelInfoEnabled()
+	trackOfflineSourced(oldState, &newState, withoutChannelInfo)
+
+	if isInitialPoll {
+		status := "オフライン"
+		if newState.IsLive {
+			game := newState.GameName
+			if game == "" {
+				game = "ゲーム未設定"
+			}
+			status = "配信中 - " + game
+		}
+		slog.Debug("初期状態", "streamer", newState.DisplayName, "status", status)
+		summary.add(newState.IsLive)
+	}
+
+	detectedChanges := DetectChanges(oldState, newState, p.detectors...)
+	if withoutChannelInfo && !newState.IsLive {
+		// 配信終了でタイトル・ゲームが空になるのはチャンネル情報を取得しないためで、変更ではない
+		detectedChanges = slices.DeleteFunc(detectedChanges, func(c DetectedChange) bool {
+			return c.Type == config.ChangeTitleChange || c.Type == config.ChangeGameChange
+		})
+	}
+
+	// 初回ポーリングの配信中は起動前に始まっているため遅延計測の対象外
+	for _, c := range detectedChanges {
+		if c.Type == config.ChangeOnline {
+			p.recordOnlineLatency(newState)
+		}
+	}
+
+	// 初回ポーリング時に配信中であればOnline通知を追加
+	if isInitialPoll && newState.IsLive {
+		detectedChanges = append(detectedChanges, DetectedChange{
+			Type:         config.ChangeOnline,
+			Streamer:     newState.Username,
+			StreamID:     newState.StreamID,
+			CurrentState: newState,
+		})
+	}
+
+	combined := combineChanges(detectedChanges)
+	combined = p.combineAcrossPolls(key, combined, newState, time.Now())
+	combined = p.debounceTitleChange(key, combined, newState)
+	combined = p.detectReconnect(key, combined, &newState, time.Now())
+	combined = p.confirmUptime(key, combined, newState, time.Now())
+	combined = p.announceStillLive(key, combined, oldState, &newState, time.Now())
+	for i := range combined {
+		combined[i].Platform = config.PlatformTwitch
+	}
+	p.attachVodInfo(ctx, combined, user.ID)
+	p.attachFollowerCount(ctx, key, combined, user.ID, time.Now())
+
+	now := time.Now()
+	excluded := p.holdOnline(key, combined, newState, sc)
+	excluded = append(excluded, p.holdOfflineForVod(ctx, key, user.ID, combined, sc, now)...)
+	p.watchVodPublished(key, user.ID, combined, sc, now)
+	target := withoutWebhooks(sc, excluded)
+	if len(combined) > 0 {
+		p.emit(combined, target)
+	}
+
+	p.stateManager.UpdateState(key, newState)
+	p.lifetime.observe(sc.Username, newState, now)
+	p.startTimes.observe(sc.Username, newState)
+	p.intervals.observe(sc, newState.IsLive, now)
+	p.health.success(sc.Username, time.Now())
+}
+
+// poll は指定された配信者の状態をポーリングして変更を検出する。
+func (p *Poller) poll(ctx context.Context, streamers []config.StreamerConfig) {
+	defer p.health.save()
+	defer p.lifetime.save()
+	defer p.startTimes.save()
+	p.stats.recordPoll()
+	p.refreshUsers(ctx, time.Now())
+
+	if len(streamers) == 0 {
+		p.checkSchedules(ctx, time.Now())
+		p.checkVodPublished(ctx, time.Now())
+		return
+	}
+
+	usernames := make([]string, len(streamers))
+	for i, s := range streamers {
+		usernames[i] = s.Username
+	}
+
+	streams, err := p.api.GetStreams(ctx, usernames)
+	if err != nil {
+		p.logAPIError("ポーリングエラー", err)
+		now := time.Now()
+		for _, s := range streamers {
+			p.health.failure(s.Username, err, now)
+		}
+		return
+	}
+
+	offlineIDs := p.collectOfflineUserIDs(streamers, streams)
+
+	var channels map[string]twitch.Channel
+	if len(offlineIDs) > 0 && p.cfg.Polling.OfflineChannelInfoEnabled() {
+		var chErr error
+		channels, chErr = p.api.GetChannels(ctx, offlineIDs)
+		if chErr != nil {
+			p.logAPIError("チャンネル情報取得エラー", chErr)
+			channels = make(map[string]twitch.Channel)
+		}
+	} else {
+		channels = make(map[string]twitch.Channel)
+	}
+
+	var summary initialSummary
+	for i, sc := range streamers {
+		if ctx.Err() != nil {
+			slog.Debug("終了処理中のため残りの配信者をスキップ", "skipped", len(streamers)-i)
+			return
+		}
+		p.processStreamer(ctx, sc, streams, channels, &summary)
+	}
+	summary.log()
+
+	p.checkSchedules(ctx, time.Now())
+	p.checkVodPublished(ctx, time.Now())
+}
@@ -0,0 +1,87 @@
+package monitor
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+)
+
+// userRefreshInterval はユーザー情報キャッシュを再取得する間隔。
+const userRefreshInterval = time.Hour
+
+// refreshUsers は一定間隔でユーザー情報を再取得し、表示名・プロフィール画像の変更を通知する。
+func (p *Poller) refreshUsers(ctx context.Context, now time.Time) {
+	if now.Sub(p.usersRefreshedAt) < userRefreshInterval {
+		return
+	}
+	p.usersRefreshedAt = now
+
+	usernames := make([]string, len(p.cfg.Streamers))
+	for i, s := range p.cfg.Streamers {
+		usernames[i] = s.Username
+	}
+
+	users, err := p.api.GetUsers(ctx, usernames)
+	if err != nil {
+		p.logAPIError("ユーザー情報の再取得エラー", err)
+		return
+	}
+
+	for _, sc := range p.cfg.Streamers {
+		key := strings.ToLower(sc.Username)
+		user, ok := users[key]
+		if !ok {
+			// 凍結等で取得できなくなった配信者は、再び取得できるまでポーリング失敗として扱う
+			if _, known := p.userCache.Get(key); known {
+				slog.Warn("ユーザーが見つかりません", "username", sc.Username)
+				p.userCache.Delete(key)
+			}
+			continue
+		}
+		old, known := p.userCache.Get(key)
+		p.userCache.Set(key, user)
+		if !known {
+			// 設定の修正や凍結解除で取得できるようになった配信者は、次回のポーリングから監視を始める
+			slog.Info("ユーザーが見つかりました。監視を開始します", "username", sc.Username, "userId", user.ID)
+			if p.onResolved != nil {
+				p.onResolved(sc, user)
+			}
+			continue
+		}
+		if old.DisplayName == user.DisplayName && old.ProfileImageURL == user.ProfileImageURL {
+			continue
+		}
+
+		state := StreamerState{
+			UserID:          user.ID,
+			Username:        user.Login,
+			DisplayName:     user.DisplayName,
+			ProfileImageURL: user.ProfileImageURL,
+		}
+		if current := p.stateManager.GetState(key); current != nil {
+			state = *current
+			state.DisplayName = user.DisplayName
+			state.ProfileImageURL = user.ProfileImageURL
+			p.stateManager.UpdateState(key, state)
+		}
+
+		slog.Info("プロフィール更新",
+			"streamer", user.Login,
+			"oldDisplayName", old.DisplayName,
+			"newDisplayName", user.DisplayName,
+			"imageChanged", old.ProfileImageURL != user.ProfileImageURL)
+
+		p.emit([]DetectedChange{{
+			Type:               config.ChangeProfileUpdate,
+			Platform:           config.PlatformTwitch,
+			Streamer:           user.Login,
+			OldValue:           old.DisplayName,
+			NewValue:           user.DisplayName,
+			OldProfileImageURL: old.ProfileImageURL,
+			CurrentState:       state,
+		}}, sc)
+	}
+}
@@ -0,0 +1,70 @@
+package monitor
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+)
+
+// pendingReconnect は再接続を待って保留している配信終了通知。
+type pendingReconnect struct {
+	change DetectedChange
+	// offlineAt は配信終了を検出した時刻。
+	offlineAt time.Time
+}
+
+// detectReconnect は配信終了をnotifications.reconnectWindowSeconds秒保留し、その間に配信が再開すれば
+// 配信終了・配信開始の代わりに1件の再接続(ChangeReconnect)にまとめる。待機時間を過ぎたら保留した配信終了を送る。
+// 再接続は同じ配信の続きとみなし、newStateの開始時刻を元の配信の開始時刻に戻す。
+func (p *Poller) detectReconnect(key string, changes []DetectedChange, newState *StreamerState, now time.Time) []DetectedChange {
+	window := time.Duration(p.cfg.Notifications.ReconnectWindowSeconds) * time.Second
+	if window <= 0 {
+		return changes
+	}
+
+	pending, hasPending := p.pendingReconnects[key]
+	var result []DetectedChange
+	for _, c := range changes {
+		switch {
+		case c.Type == config.ChangeOffline && !p.onlineHeld(key):
+			pending = &pendingReconnect{change: c, offlineAt: now}
+			p.pendingReconnects[key] = pending
+			hasPending = true
+			continue
+		case hasPending && c.Type == config.ChangeOnline:
+			delete(p.pendingReconnects, key)
+			hasPending = false
+			if pending.change.StreamStartedAt != "" {
+				newState.StartedAt = pending.change.StreamStartedAt
+			}
+			slog.Info("配信が短時間で再開したため再接続として通知",
+				"streamer", newState.DisplayName,
+				"offline", now.Sub(pending.offlineAt).Round(time.Second).String())
+			result = append(result, DetectedChange{
+				Type:            config.ChangeReconnect,
+				Streamer:        c.Streamer,
+				StreamStartedAt: pending.change.StreamStartedAt,
+				StreamID:        c.StreamID,
+				OfflineDuration: now.Sub(pending.offlineAt),
+				CurrentState:    *newState,
+			})
+			continue
+		}
+		result = append(result, c)
+	}
+
+	if hasPending && now.Sub(pending.offlineAt) >= window {
+		delete(p.pendingReconnects, key)
+		return append([]DetectedChange{pending.change}, result...)
+	}
+	return result
+}
+
+// onlineHeld は配信開始通知をまだ送らずに保留しているかを返す。
+// この場合の配信終了は保留中の配信開始と合わせて扱われるため、再接続の待機対象にしない。
+func (p *Poller) onlineHeld(key string) bool {
+	_, uptime := p.pendingUptimes[key]
+	_, viewers := p.pendingOnlines[key]
+	return uptime || viewers
+}
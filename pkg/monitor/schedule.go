@@ -0,0 +1,122 @@
+package monitor
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+	"github.com/yuu1111/StreamNotifier/pkg/twitch"
+)
+
+// scheduleRefreshInterval は配信スケジュールを再取得する間隔。
+const scheduleRefreshInterval = 15 * time.Minute
+
+// scheduleTracker は配信者ごとの配信スケジュールと送信済みリマインダーを保持する。
+type scheduleTracker struct {
+	// segments は取得済みのスケジュール(キー: login名小文字)。
+	segments  map[string][]twitch.ScheduleSegment
+	fetchedAt map[string]time.Time
+	// reminded は送信済みリマインダー(キー: セグメントID+開始時刻)と、その開始時刻。
+	reminded map[string]time.Time
+}
+
+func newScheduleTracker() *scheduleTracker {
+	return &scheduleTracker{
+		segments:  make(map[string][]twitch.ScheduleSegment),
+		fetchedAt: make(map[string]time.Time),
+		reminded:  make(map[string]time.Time),
+	}
+}
+
+// wantsSchedule はいずれかのWebhookでスケジュールリマインダーが有効か判定する。
+func wantsSchedule(sc config.StreamerConfig) bool {
+	return slices.ContainsFunc(sc.Webhooks, func(w config.WebhookConfig) bool {
+		return w.Notifications.Schedule
+	})
+}
+
+// checkSchedules は配信予定の開始前リマインダー時刻を迎えたセグメントを通知する。
+// 各セグメントは一度だけ通知する。
+func (p *Poller) checkSchedules(ctx context.Context, now time.Time) {
+	lead := p.cfg.ScheduleReminderLead()
+
+	for key, start := range p.schedule.reminded {
+		if now.After(start) {
+			delete(p.schedule.reminded, key)
+		}
+	}
+
+	for _, sc := range p.cfg.Streamers {
+		if !wantsSchedule(sc) {
+			continue
+		}
+
+		key := strings.ToLower(sc.Username)
+		user, ok := p.userCache.Get(key)
+		if !ok {
+			continue
+		}
+
+		if now.Sub(p.schedule.fetchedAt[key]) >= scheduleRefreshInterval {
+			segments, err := p.api.GetSchedule(ctx, user.ID)
+			if err != nil {
+				p.stats.recordAPIError()
+				slog.Error("配信スケジュール取得エラー", "streamer", sc.Username, "error", err)
+				continue
+			}
+			p.schedule.segments[key] = segments
+			p.schedule.fetchedAt[key] = now
+		}
+
+		state := p.stateManager.GetState(key)
+		if state == nil {
+			state = &StreamerState{
+				UserID:          user.ID,
+				Username:        user.Login,
+				DisplayName:     user.DisplayName,
+				ProfileImageURL: user.ProfileImageURL,
+			}
+		}
+		// 既に配信中ならリマインダーは不要
+		if state.IsLive {
+			continue
+		}
+
+		var changes []DetectedChange
+		for _, seg := range p.schedule.segments[key] {
+			if seg.CanceledUntil != nil {
+				continue
+			}
+			start, err := time.Parse(time.RFC3339, seg.StartTime)
+			if err != nil || now.Before(start.Add(-lead)) || !now.Before(start) {
+				continue
+			}
+			remindKey := seg.ID + "/" + seg.StartTime
+			if _, done := p.schedule.reminded[remindKey]; done {
+				continue
+			}
+			p.schedule.reminded[remindKey] = start
+
+			change := DetectedChange{
+				Type:             config.ChangeScheduledReminder,
+				Platform:         config.PlatformTwitch,
+				Streamer:         state.Username,
+				NewTitle:         seg.Title,
+				ScheduledStartAt: seg.StartTime,
+				CurrentState:     *state,
+			}
+			if seg.Category != nil {
+				change.NewGame = seg.Category.Name
+			}
+			changes = append(changes, change)
+			slog.Info("配信予定リマインダー", "streamer", state.DisplayName, "start", seg.StartTime, "title", seg.Title)
+		}
+
+		if len(changes) > 0 {
+			p.emit(changes, sc)
+		}
+	}
+}
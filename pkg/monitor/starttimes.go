@@ -0,0 +1,119 @@
+package monitor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// observedStart は配信中の配信者について記録した配信の開始時刻。
+type observedStart struct {
+	// StreamID は開始時刻を記録した配信のID。再起動後に同じ配信かの確認に使う。
+	StreamID string `json:"streamId"`
+	// StartedAt は配信の開始時刻(ISO 8601)。APIから取得できなければ初めて配信中を観測した時刻。
+	StartedAt string `json:"startedAt"`
+}
+
+// startTimeStore は配信中の配信者の開始時刻を記録し、再起動後も配信時間を計算できるようファイルに保存する。
+type startTimeStore struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]observedStart
+	// dirty は前回の書き出し以降に更新があったか。
+	dirty bool
+}
+
+func newStartTimeStore(path string) *startTimeStore {
+	return &startTimeStore{path: path, entries: make(map[string]observedStart)}
+}
+
+// load は保存済みの開始時刻を読み込む。読み込めなければ記録のない状態から始める。
+func (s *startTimeStore) load() {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	entries := make(map[string]observedStart)
+	if err == nil {
+		err = json.Unmarshal(data, &entries)
+	}
+	if err != nil {
+		slog.Warn("配信開始時刻の記録を読み込めないため、記録をやり直します", "error", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = entries
+}
+
+// lookup は配信IDが一致する配信の記録済みの開始時刻を返す。
+func (s *startTimeStore) lookup(username, streamID string) (string, bool) {
+	if streamID == "" {
+		return "", false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[strings.ToLower(username)]
+	if !ok || e.StreamID != streamID || e.StartedAt == "" {
+		return "", false
+	}
+	return e.StartedAt, true
+}
+
+// observe はポーリングした配信者の状態から記録を更新する。配信外になった配信者の記録は削除する。
+func (s *startTimeStore) observe(username string, state StreamerState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.ToLower(username)
+	if !state.IsLive || state.StartedAt == "" {
+		if _, ok := s.entries[key]; ok {
+			delete(s.entries, key)
+			s.dirty = true
+		}
+		return
+	}
+	e := observedStart{StreamID: state.StreamID, StartedAt: state.StartedAt}
+	if s.entries[key] != e {
+		s.entries[key] = e
+		s.dirty = true
+	}
+}
+
+// save は更新があれば記録を一時ファイル経由でアトミックに書き出す。
+func (s *startTimeStore) save() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return
+	}
+
+	if err := writeStartTimes(s.path, s.entries); err != nil {
+		slog.Error("配信開始時刻の記録の保存に失敗", "error", err)
+		return
+	}
+	s.dirty = false
+}
+
+// writeStartTimes は開始時刻の記録を一時ファイル経由でアトミックに書き出す。
+func writeStartTimes(path string, entries map[string]observedStart) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("配信開始時刻の記録のJSON変換に失敗: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("配信開始時刻の記録の保存先作成に失敗: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
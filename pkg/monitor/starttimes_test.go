@@ -0,0 +1,61 @@
+package monitor
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStartTimeStoreSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stream-starts.json")
+	live := testLiveState()
+	live.StartedAt = "2026-01-02T09:30:00Z"
+
+	before := newStartTimeStore(path)
+	before.observe("Streamer", live)
+	before.save()
+
+	after := newStartTimeStore(path)
+	after.load()
+	if got, ok := after.lookup("streamer", live.StreamID); !ok || got != live.StartedAt {
+		t.Errorf("lookup same stream = %q, %v; want %q", got, ok, live.StartedAt)
+	}
+	if _, ok := after.lookup("streamer", "other-stream"); ok {
+		t.Error("lookup returned the start time of a different stream")
+	}
+
+	after.observe("streamer", testOfflineState())
+	after.save()
+	reloaded := newStartTimeStore(path)
+	reloaded.load()
+	if _, ok := reloaded.lookup("streamer", live.StreamID); ok {
+		t.Error("start time kept after the stream went offline")
+	}
+}
+
+func TestTrackStartedAt(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	prev := testLiveState()
+	prev.StartedAt = "2026-01-02T10:00:00Z"
+
+	tests := []struct {
+		name     string
+		old      *StreamerState
+		restored string
+		want     string
+	}{
+		{"previous poll", &prev, "2026-01-02T09:30:00Z", "2026-01-02T10:00:00Z"},
+		{"restored after restart", nil, "2026-01-02T09:30:00Z", "2026-01-02T09:30:00Z"},
+		{"first observation", nil, "", "2026-01-02T12:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newState := testLiveState()
+			newState.StartedAt = ""
+			trackStartedAt(tt.old, &newState, tt.restored, now)
+			if newState.StartedAt != tt.want {
+				t.Errorf("StartedAt = %q, want %q", newState.StartedAt, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/twitch"
+)
+
+const (
+	// startupRetryBaseDelay は起動時の初回再試行までの待機時間。以降は倍々で延びる。
+	startupRetryBaseDelay = 2 * time.Second
+	// startupRetryMaxDelay は起動時の再試行間隔の上限。
+	startupRetryMaxDelay = time.Minute
+	// clockSkewWarnThreshold はTwitchのサーバー時刻とのずれを警告する大きさ。
+	clockSkewWarnThreshold = 30 * time.Second
+)
+
+// initializeWithRetry はユーザー情報を初回取得する。twitch.startupRetrySecondsが設定されていれば、
+// 認証サーバーの一時的な障害などで失敗してもその秒数まではバックオフしながら再試行する。
+// ctxがキャンセルされたら待機を打ち切ってctxのエラーを返す。
+func (p *Poller) initializeWithRetry(ctx context.Context) error {
+	limit := time.Duration(p.cfg.Twitch.StartupRetrySeconds) * time.Second
+	deadline := time.Now().Add(limit)
+	delay := startupRetryBaseDelay
+	for attempt := 1; ; attempt++ {
+		err := p.initializeUserCache(ctx)
+		if err == nil {
+			// 再起動前に観測した開始時刻を、初回ポーリングで開始時刻が取得できない配信に使う
+			p.startTimes.load()
+			return nil
+		}
+		if limit <= 0 || ctx.Err() != nil || !isTransientStartupError(err) {
+			return err
+		}
+		wait := min(delay, time.Until(deadline))
+		if wait <= 0 {
+			return fmt.Errorf("起動時のTwitch APIへの接続を%s再試行しましたが失敗しました: %w", limit, err)
+		}
+		slog.Warn("Twitch APIへの接続に失敗したため再試行します",
+			"attempt", attempt,
+			"retryIn", wait.Round(time.Second).String(),
+			"error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		delay = min(delay*2, startupRetryMaxDelay)
+	}
+}
+
+// isTransientStartupError は起動時のエラーが再試行で回復し得るかを判定する。
+// Twitchのエラーレスポンスはサーバーエラーとレート制限のみ、それ以外はネットワークエラーとみなして再試行する。
+func isTransientStartupError(err error) bool {
+	var twErr *twitch.TwitchError
+	if errors.As(err, &twErr) {
+		return twErr.IsServer() || twErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// warnClockSkew は起動時の通信で分かったローカルの時計のずれが大きければ警告する。
+// 時計が遅れていると配信開始からの経過時間が短く(または表示されなく)なるため、NTPの設定を促す。
+func (p *Poller) warnClockSkew() {
+	skew, ok := p.api.ClockSkew()
+	if !ok || (skew < clockSkewWarnThreshold && skew > -clockSkewWarnThreshold) {
+		return
+	}
+	direction := "進んで"
+	if skew < 0 {
+		direction = "遅れて"
+	}
+	slog.Warn("ローカルの時計がTwitchのサーバー時刻から"+direction+"います。経過時間の表示がずれるため、NTPによる時刻同期を確認してください",
+		"skew", skew.Round(time.Second).String())
+}
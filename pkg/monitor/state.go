@@ -1,4 +1,3 @@
-// Package monitor は配信者の状態監視と変化検出を提供する。
 package monitor
 
 import (
@@ -16,9 +15,18 @@ type StreamerState struct {
 	Title           string
 	GameID          string
 	GameName        string
-	StartedAt       string // ISO 8601 (配信中のみ)
+	StartedAt       string // ISO 8601 (配信中のみ。APIから取得できない場合は初回観測時刻)
 	ThumbnailURL    string // 配信中のみ
 	ViewerCount     int
+	Language        string // 配信中のみ (ISO 639-1)
+	StreamID        string // 配信中のみ。Twitchの配信ID (配信ごとに一意)
+	// AnnouncedAt は配信開始または配信中の再告知を最後に検出した時刻 (ISO 8601、配信中のみ)。
+	// notifications.stillLiveIntervalHoursの経過判定に使う。
+	AnnouncedAt string
+	// OfflineSourced は再起動後に配信外の状態を/channelsから構築したことを表す。
+	// /channelsのタイトル・ゲームは直前の配信時の値と異なり得るため、次の配信開始時の比較には使わない。
+	// polling.fetchOfflineChannelInfoが無効な場合は、タイトル・ゲームを持たない配信外の状態に常に付ける。
+	OfflineSourced bool
 }
 
 // StateManager は配信者状態をin-memoryで管理する。
@@ -0,0 +1,120 @@
+package monitor
+
+import (
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+)
+
+// Stats はPollerの実行統計を集計する。
+type Stats struct {
+	mu sync.Mutex
+
+	latencyCount int
+	latencyTotal time.Duration
+	latencyMax   time.Duration
+
+	// startedAt は監視を開始した時刻。
+	startedAt time.Time
+	polls     int
+	// changes は通知タイプ別のハンドラーに渡した変更数。
+	changes   map[config.ChangeType]int
+	apiErrors int
+}
+
+// LatencyStats は配信開始から検知までの遅延統計。
+type LatencyStats struct {
+	Count      int     `json:"count"`
+	AvgSeconds float64 `json:"avgSeconds"`
+	MaxSeconds float64 `json:"maxSeconds"`
+}
+
+// StatsSnapshot はある時点の統計値。
+type StatsSnapshot struct {
+	// UptimeSeconds は監視開始からの経過秒数。
+	UptimeSeconds float64 `json:"uptimeSeconds"`
+	// Polls はポーリングの実行回数(手動ポーリングを含む)。
+	Polls int `json:"polls"`
+	// Changes は通知タイプ別の検出した変更数。
+	Changes map[config.ChangeType]int `json:"changes"`
+	// APIErrors はTwitch APIのエラー回数。
+	APIErrors     int          `json:"apiErrors"`
+	OnlineLatency LatencyStats `json:"onlineLatency"`
+	// Streamers は配信者ごとのヘルス状態。
+	Streamers []StreamerHealth `json:"streamers"`
+}
+
+// recordOnlineLatency は配信開始検知の遅延を記録する。
+func (s *Stats) recordOnlineLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latencyCount++
+	s.latencyTotal += d
+	if d > s.latencyMax {
+		s.latencyMax = d
+	}
+}
+
+// start は監視の開始時刻を記録する。
+func (s *Stats) start(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.startedAt = now
+}
+
+// recordPoll はポーリングの実行を記録する。
+func (s *Stats) recordPoll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.polls++
+}
+
+// recordChanges はハンドラーに渡した変更を通知タイプ別に記録する。
+// 保留していた通知の遅延送信は検出時に記録済みのため数えない。
+func (s *Stats) recordChanges(changes []DetectedChange) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range changes {
+		if c.Deferred {
+			continue
+		}
+		if s.changes == nil {
+			s.changes = make(map[config.ChangeType]int)
+		}
+		s.changes[c.Type]++
+	}
+}
+
+// recordAPIError はTwitch APIのエラーを記録する。
+func (s *Stats) recordAPIError() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apiErrors++
+}
+
+// snapshot は現在の統計値を返す。
+func (s *Stats) snapshot() StatsSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	latency := LatencyStats{
+		Count:      s.latencyCount,
+		MaxSeconds: s.latencyMax.Seconds(),
+	}
+	if s.latencyCount > 0 {
+		latency.AvgSeconds = (s.latencyTotal / time.Duration(s.latencyCount)).Seconds()
+	}
+	snapshot := StatsSnapshot{
+		Polls:         s.polls,
+		Changes:       maps.Clone(s.changes),
+		APIErrors:     s.apiErrors,
+		OnlineLatency: latency,
+	}
+	if !s.startedAt.IsZero() {
+		snapshot.UptimeSeconds = time.Since(s.startedAt).Seconds()
+	}
+	return snapshot
+}
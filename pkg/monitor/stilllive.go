@@ -0,0 +1,42 @@
+package monitor
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+)
+
+// announceStillLive は配信が続いている間、配信開始(または前回の再告知)からnotifications.stillLiveIntervalHours時間ごとに
+// 配信中の再告知(ChangeStillLive)を追加する。最後に告知した時刻はnewState.AnnouncedAtで配信ごとに引き継ぐ。
+func (p *Poller) announceStillLive(key string, changes []DetectedChange, oldState *StreamerState, newState *StreamerState, now time.Time) []DetectedChange {
+	if !newState.IsLive {
+		return changes
+	}
+	if oldState != nil && oldState.IsLive && oldState.AnnouncedAt != "" {
+		newState.AnnouncedAt = oldState.AnnouncedAt
+	} else {
+		// 配信開始(起動時に配信中だった場合を含む)を最初の告知とする
+		newState.AnnouncedAt = now.UTC().Format(time.RFC3339)
+	}
+
+	interval := time.Duration(p.cfg.Notifications.StillLiveIntervalHours) * time.Hour
+	// 配信開始通知を保留中なら、まだ告知していないため再告知もしない
+	if interval <= 0 || p.onlineHeld(key) {
+		return changes
+	}
+	announcedAt, err := time.Parse(time.RFC3339, newState.AnnouncedAt)
+	if err != nil || now.Sub(announcedAt) < interval {
+		return changes
+	}
+
+	newState.AnnouncedAt = now.UTC().Format(time.RFC3339)
+	slog.Info("配信が続いているため再告知", "streamer", newState.DisplayName, "startedAt", newState.StartedAt)
+	return append(changes, DetectedChange{
+		Type:            config.ChangeStillLive,
+		Streamer:        newState.Username,
+		StreamStartedAt: newState.StartedAt,
+		StreamID:        newState.StreamID,
+		CurrentState:    *newState,
+	})
+}
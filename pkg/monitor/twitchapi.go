@@ -0,0 +1,19 @@
+package monitor
+
+import (
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+	"github.com/yuu1111/StreamNotifier/pkg/twitch"
+)
+
+// NewTwitchAPI はtwitchの設定からAPIクライアントを作成する。
+// twitch.modeがmockならTwitchへ接続せず、フィクスチャファイルの配信者の状態を返すAPIを使う。
+func NewTwitchAPI(cfg config.TwitchConfig) *twitch.API {
+	if cfg.IsMock() {
+		return twitch.NewMockAPI(cfg.MockFilePath())
+	}
+	api := twitch.NewAPI(twitch.NewAuth(cfg.ClientID, cfg.ClientSecret), cfg.ClientID)
+	api.SetRateLimit(cfg.RequestsPerMinute)
+	api.SetBatchSize(cfg.BatchSize)
+	api.SetUserToken(cfg.UserAccessToken)
+	return api
+}
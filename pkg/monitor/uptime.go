@@ -0,0 +1,62 @@
+package monitor
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+)
+
+// pendingUptime は配信継続時間の確認待ちの配信開始通知。
+type pendingUptime struct {
+	change DetectedChange
+	// observedAt は配信開始を検出した時刻。StartedAtが解析できない場合の起点にする。
+	observedAt time.Time
+}
+
+// confirmUptime は配信開始通知をnotifications.minUptimeSeconds以上配信が続くまで保留する。
+// 保留中に配信が終わった場合は開始・終了とも通知しない。保留中のタイトル/ゲーム変更は開始通知に含まれるため捨てる。
+func (p *Poller) confirmUptime(key string, changes []DetectedChange, newState StreamerState, now time.Time) []DetectedChange {
+	minUptime := time.Duration(p.cfg.Notifications.MinUptimeSeconds) * time.Second
+	if minUptime <= 0 {
+		return changes
+	}
+
+	pending, hasPending := p.pendingUptimes[key]
+	var result []DetectedChange
+	for _, c := range changes {
+		switch {
+		case c.Type == config.ChangeOnline:
+			pending = &pendingUptime{change: c, observedAt: now}
+			p.pendingUptimes[key] = pending
+			hasPending = true
+			continue
+		case hasPending && c.Type == config.ChangeOffline:
+			slog.Info("最低配信時間に達する前に配信が終了したため通知を抑制",
+				"streamer", newState.DisplayName,
+				"minUptime", minUptime.String())
+			delete(p.pendingUptimes, key)
+			hasPending = false
+			continue
+		case hasPending && (c.Type == config.ChangeTitleChange || c.Type == config.ChangeGameChange || c.Type == config.ChangeTitleAndGame):
+			continue
+		}
+		result = append(result, c)
+	}
+
+	if !hasPending || !newState.IsLive {
+		return result
+	}
+
+	pending.change.CurrentState = newState
+	start, err := time.Parse(time.RFC3339, newState.StartedAt)
+	if err != nil {
+		start = pending.observedAt
+	}
+	if now.Sub(start) < minUptime {
+		return result
+	}
+
+	delete(p.pendingUptimes, key)
+	return append([]DetectedChange{pending.change}, result...)
+}
@@ -0,0 +1,47 @@
+package monitor
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/yuu1111/StreamNotifier/pkg/twitch"
+)
+
+// userCache はlogin名(小文字)をキーとするユーザー情報のキャッシュ。複数goroutineから安全に使える。
+type userCache struct {
+	mu    sync.RWMutex
+	users map[string]twitch.User
+}
+
+func newUserCache() *userCache {
+	return &userCache{users: make(map[string]twitch.User)}
+}
+
+// Get は指定login名のユーザー情報を返す。
+func (c *userCache) Get(login string) (twitch.User, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	u, ok := c.users[strings.ToLower(login)]
+	return u, ok
+}
+
+// Set は指定login名のユーザー情報を更新する。
+func (c *userCache) Set(login string, user twitch.User) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.users[strings.ToLower(login)] = user
+}
+
+// SetAll はキャッシュ全体を置き換える。usersのキーはlogin名(小文字)であること。
+func (c *userCache) SetAll(users map[string]twitch.User) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.users = users
+}
+
+// Delete は指定login名のユーザー情報を削除する。
+func (c *userCache) Delete(login string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.users, strings.ToLower(login))
+}
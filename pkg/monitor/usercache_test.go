@@ -0,0 +1,116 @@
+package monitor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+	"github.com/yuu1111/StreamNotifier/pkg/twitch"
+)
+
+// go test -race で実行すると、ロックのないアクセスがあればデータ競合として検出される。
+func TestUserCacheConcurrentAccess(t *testing.T) {
+	c := newUserCache()
+	logins := []string{"alice", "Bob", "carol"}
+
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				login := logins[(w+i)%len(logins)]
+				switch i % 4 {
+				case 0:
+					c.Set(login, twitch.User{Login: login, DisplayName: strconv.Itoa(i)})
+				case 1:
+					c.Delete(login)
+				case 2:
+					users := make(map[string]twitch.User)
+					for _, l := range logins {
+						users[l] = twitch.User{Login: l}
+					}
+					c.SetAll(users)
+				default:
+					c.Get(login)
+				}
+			}
+		}()
+	}
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 500 {
+				c.Get(logins[i%len(logins)])
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestUserCacheCaseInsensitive(t *testing.T) {
+	c := newUserCache()
+	c.Set("Streamer", twitch.User{ID: "1"})
+	if u, ok := c.Get("STREAMER"); !ok || u.ID != "1" {
+		t.Errorf("Get(STREAMER) = %+v, %v; want the user set as Streamer", u, ok)
+	}
+	c.Delete("streamer")
+	if _, ok := c.Get("Streamer"); ok {
+		t.Error("Get after Delete found the user")
+	}
+}
+
+// refreshUsersと配信者ごとのポーリングのlookupが同時に走っても競合しないことを確認する。
+func TestPollerUserRefreshConcurrentLookups(t *testing.T) {
+	fixture := filepath.Join(t.TempDir(), "twitch-mock.json")
+	if err := os.WriteFile(fixture, []byte(`{"streamers": [
+  {"login": "alice", "displayName": "Alice"},
+  {"login": "bob", "displayName": "Bob"}
+]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		Polling:   config.PollingConfig{IntervalSeconds: 60},
+		Streamers: []config.StreamerConfig{{Username: "alice"}, {Username: "bob"}},
+	}
+	p := NewPoller(twitch.NewMockAPI(fixture), cfg, func([]DetectedChange, config.StreamerConfig) {})
+	ctx := context.Background()
+	if err := p.initializeUserCache(ctx); err != nil {
+		t.Fatalf("initializeUserCache: %v", err)
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				for _, sc := range cfg.Streamers {
+					p.userCache.Get(sc.Username)
+				}
+			}
+		}()
+	}
+
+	for range 20 {
+		// 間隔の判定を通すため、前回の取得時刻を十分過去にして再取得させる
+		p.refreshUsers(ctx, p.usersRefreshedAt.Add(userRefreshInterval))
+	}
+	close(done)
+	wg.Wait()
+
+	if u, ok := p.userCache.Get("alice"); !ok || u.DisplayName != "Alice" {
+		t.Errorf("userCache alice = %+v, %v; want the fixture user", u, ok)
+	}
+}
@@ -0,0 +1,112 @@
+package monitor
+
+import (
+	"log/slog"
+	"slices"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+)
+
+// pendingOnline は視聴者数が閾値に達するまで一部のWebhookへの送信を保留している配信開始通知。
+type pendingOnline struct {
+	change DetectedChange
+	// webhooks は送信を保留しているWebhookのインデックス(StreamerConfig.Webhooks)。
+	webhooks []int
+}
+
+// holdOnline は配信開始通知を視聴者数の閾値で保留・解除し、今回の変更の送信から除くWebhookのインデックスを返す。
+// 保留中のWebhookには配信開始通知を送るまで他の変更も送らない。
+func (p *Poller) holdOnline(key string, changes []DetectedChange, newState StreamerState, sc config.StreamerConfig) []int {
+	if i := slices.IndexFunc(changes, func(c DetectedChange) bool { return c.Type == config.ChangeOnline }); i >= 0 {
+		delete(p.pendingOnlines, key)
+		var held []int
+		for j, w := range sc.Webhooks {
+			if !config.IsNotificationEnabled(config.ChangeOnline, w.Notifications) ||
+				!w.AllowsGame(config.ChangeOnline, newState.GameID, newState.GameName) {
+				continue
+			}
+			if threshold := sc.OnlineViewerThreshold(w); threshold > 0 && newState.ViewerCount < threshold {
+				held = append(held, j)
+			}
+		}
+		if len(held) == 0 {
+			return nil
+		}
+		slog.Info("視聴者数が閾値未満のため配信開始通知を保留",
+			"streamer", newState.DisplayName,
+			"viewers", newState.ViewerCount,
+			"webhooks", len(held))
+		p.pendingOnlines[key] = &pendingOnline{change: changes[i], webhooks: held}
+		return held
+	}
+
+	pending, ok := p.pendingOnlines[key]
+	if !ok {
+		return nil
+	}
+
+	// 配信が続いている間は閾値に達したWebhookへ送信し、終了したら保留を解消する
+	ended := !newState.IsLive
+	if !ended {
+		pending.change.CurrentState = newState
+	}
+	var release, keep, drop []int
+	for _, j := range pending.webhooks {
+		w := sc.Webhooks[j]
+		switch {
+		case ended && w.DropOnlineIfEnded:
+			drop = append(drop, j)
+		case ended || newState.ViewerCount >= sc.OnlineViewerThreshold(w):
+			release = append(release, j)
+		default:
+			keep = append(keep, j)
+		}
+	}
+
+	if len(release) > 0 {
+		p.sendDeferred(pending.change, sc, release)
+	}
+	if len(drop) > 0 {
+		slog.Info("閾値に達しないまま配信が終了したため通知を破棄",
+			"streamer", pending.change.CurrentState.DisplayName,
+			"webhooks", len(drop))
+	}
+
+	if len(keep) == 0 {
+		delete(p.pendingOnlines, key)
+	} else {
+		pending.webhooks = keep
+	}
+	return append(keep, drop...)
+}
+
+// sendDeferred は保留していた通知を指定インデックスのWebhookにだけ送る。
+func (p *Poller) sendDeferred(change DetectedChange, sc config.StreamerConfig, webhooks []int) {
+	change.Deferred = true
+	p.emit([]DetectedChange{change}, onlyWebhooks(sc, webhooks))
+}
+
+// withoutWebhooks は指定インデックスのWebhookを除いた配信者設定を返す。
+func withoutWebhooks(sc config.StreamerConfig, excluded []int) config.StreamerConfig {
+	if len(excluded) == 0 {
+		return sc
+	}
+	webhooks := make([]config.WebhookConfig, 0, len(sc.Webhooks))
+	for j, w := range sc.Webhooks {
+		if !slices.Contains(excluded, j) {
+			webhooks = append(webhooks, w)
+		}
+	}
+	sc.Webhooks = webhooks
+	return sc
+}
+
+// onlyWebhooks は指定インデックスのWebhookだけに絞った配信者設定を返す。
+func onlyWebhooks(sc config.StreamerConfig, included []int) config.StreamerConfig {
+	webhooks := make([]config.WebhookConfig, 0, len(included))
+	for _, j := range included {
+		webhooks = append(webhooks, sc.Webhooks[j])
+	}
+	sc.Webhooks = webhooks
+	return sc
+}
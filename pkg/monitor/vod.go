@@ -0,0 +1,107 @@
+package monitor
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+	"github.com/yuu1111/StreamNotifier/pkg/twitch"
+)
+
+// vodMatchSlack はVODの作成時刻が配信開始時刻より前でも同じ配信のものとみなす許容幅。
+const vodMatchSlack = 10 * time.Minute
+
+// pendingVod はVODの公開待ちで一部のWebhookへの送信を保留している配信終了通知。
+type pendingVod struct {
+	change DetectedChange
+	userID string
+	// webhooks は送信を保留しているWebhookのインデックス(StreamerConfig.Webhooks)。
+	webhooks []int
+	deadline time.Time
+}
+
+// holdOfflineForVod はofflineRequireVodのWebhookへの配信終了通知をVODのリンクが取得できるまで保留し、
+// 今回の送信から除くWebhookのインデックスを返す。保留中の通知はポーリング毎にVODを確認して送る。
+func (p *Poller) holdOfflineForVod(ctx context.Context, key, userID string, changes []DetectedChange, sc config.StreamerConfig, now time.Time) []int {
+	if pending, ok := p.pendingVods[key]; ok {
+		p.checkPendingVod(ctx, key, pending, sc, now)
+	}
+
+	i := slices.IndexFunc(changes, func(c DetectedChange) bool { return c.Type == config.ChangeOffline })
+	if i < 0 || changes[i].VodURL != "" {
+		return nil
+	}
+
+	var held []int
+	for j, w := range sc.Webhooks {
+		if w.OfflineRequireVod && config.IsNotificationEnabled(config.ChangeOffline, w.Notifications) {
+			held = append(held, j)
+		}
+	}
+	if len(held) == 0 {
+		return nil
+	}
+
+	if _, ok := p.pendingVods[key]; ok {
+		slog.Warn("VOD待ちの配信終了通知を新しい配信終了で置き換えます", "streamer", changes[i].CurrentState.DisplayName)
+	}
+	slog.Info("VODの公開待ちのため配信終了通知を保留",
+		"streamer", changes[i].CurrentState.DisplayName,
+		"webhooks", len(held))
+	p.pendingVods[key] = &pendingVod{
+		change:   changes[i],
+		userID:   userID,
+		webhooks: held,
+		deadline: now.Add(p.cfg.VodWait()),
+	}
+	return held
+}
+
+// checkPendingVod は保留中の配信終了通知のVODを確認し、見つかればリンク付きで送る。
+// 待機時間を過ぎた場合はWebhookのofflineVodFallbackに従いリンクなしで送るか破棄する。
+func (p *Poller) checkPendingVod(ctx context.Context, key string, pending *pendingVod, sc config.StreamerConfig, now time.Time) {
+	vod, err := p.api.GetLatestVod(ctx, pending.userID)
+	if err != nil {
+		p.stats.recordAPIError()
+		slog.Warn("VOD取得失敗", "error", err)
+	} else if vod != nil && vodMatchesStream(vod, pending.change.StreamStartedAt) {
+		delete(p.pendingVods, key)
+		setVodInfo(&pending.change, vod)
+		p.sendDeferred(pending.change, sc, pending.webhooks)
+		return
+	}
+
+	if now.Before(pending.deadline) {
+		return
+	}
+	delete(p.pendingVods, key)
+
+	var send []int
+	for _, j := range pending.webhooks {
+		if sc.Webhooks[j].OfflineVodFallback != config.VodFallbackDrop {
+			send = append(send, j)
+		}
+	}
+	slog.Info("VODが見つからないまま待機時間を過ぎました",
+		"streamer", pending.change.CurrentState.DisplayName,
+		"send", len(send),
+		"drop", len(pending.webhooks)-len(send))
+	if len(send) > 0 {
+		p.sendDeferred(pending.change, sc, send)
+	}
+}
+
+// vodMatchesStream はVODが指定時刻に開始した配信のものか判定する。時刻が不明な場合は一致とみなす。
+func vodMatchesStream(vod *twitch.Video, streamStartedAt string) bool {
+	created, err := time.Parse(time.RFC3339, vod.CreatedAt)
+	if err != nil {
+		return true
+	}
+	started, err := time.Parse(time.RFC3339, streamStartedAt)
+	if err != nil {
+		return true
+	}
+	return !created.Before(started.Add(-vodMatchSlack))
+}
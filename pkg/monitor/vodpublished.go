@@ -0,0 +1,109 @@
+package monitor
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+)
+
+// vodWatch は配信終了後にVODの公開を待っている配信。
+type vodWatch struct {
+	userID string
+	// change は配信終了の変更。配信開始時刻・配信IDと配信終了時の状態を引き継ぐ。
+	change   DetectedChange
+	deadline time.Time
+}
+
+// vodPublishTracker は配信者ごとのVOD公開待ちと通知済みのVODを保持する。
+type vodPublishTracker struct {
+	// watches はVODの公開を待っている配信(キー: login名小文字)。
+	watches map[string]*vodWatch
+	// notified は最後に公開を通知したVODのID(キー: login名小文字)。
+	notified map[string]string
+}
+
+func newVodPublishTracker() *vodPublishTracker {
+	return &vodPublishTracker{
+		watches:  make(map[string]*vodWatch),
+		notified: make(map[string]string),
+	}
+}
+
+// wantsVodPublished はいずれかのWebhookでアーカイブ公開の通知が有効か判定する。
+func wantsVodPublished(sc config.StreamerConfig) bool {
+	return slices.ContainsFunc(sc.Webhooks, func(w config.WebhookConfig) bool {
+		return w.Notifications.VodPublished
+	})
+}
+
+// watchVodPublished は配信終了を検出した配信者について、notifications.vodWaitMinutesの間VODの公開を待つ。
+func (p *Poller) watchVodPublished(key, userID string, changes []DetectedChange, sc config.StreamerConfig, now time.Time) {
+	if !wantsVodPublished(sc) {
+		return
+	}
+	i := slices.IndexFunc(changes, func(c DetectedChange) bool { return c.Type == config.ChangeOffline })
+	if i < 0 {
+		return
+	}
+	p.vodPublish.watches[key] = &vodWatch{
+		userID:   userID,
+		change:   changes[i],
+		deadline: now.Add(p.cfg.VodWait()),
+	}
+}
+
+// checkVodPublished はVODの公開を待っている配信者の最新のVODを確認し、終了した配信のVODが
+// 新しく見つかればアーカイブ公開(ChangeVodPublished)を通知する。待機時間を過ぎたら待つのをやめる。
+// 待機中に次の配信が始まった場合は、新しい配信のVODと取り違えないよう待つのをやめる。
+func (p *Poller) checkVodPublished(ctx context.Context, now time.Time) {
+	for _, sc := range p.cfg.Streamers {
+		key := strings.ToLower(sc.Username)
+		watch, ok := p.vodPublish.watches[key]
+		if !ok {
+			continue
+		}
+		if state := p.stateManager.GetState(key); state != nil && state.IsLive {
+			delete(p.vodPublish.watches, key)
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		vod, err := p.api.GetLatestVod(ctx, watch.userID)
+		if err != nil {
+			p.stats.recordAPIError()
+			slog.Warn("VOD取得失敗", "streamer", sc.Username, "error", err)
+		} else if vod != nil && vod.ID != p.vodPublish.notified[key] && vodMatchesStream(vod, watch.change.StreamStartedAt) {
+			delete(p.vodPublish.watches, key)
+			p.vodPublish.notified[key] = vod.ID
+
+			change := DetectedChange{
+				Type:            config.ChangeVodPublished,
+				Platform:        config.PlatformTwitch,
+				Streamer:        watch.change.Streamer,
+				NewValue:        vod.ID,
+				NewTitle:        vod.Title,
+				StreamStartedAt: watch.change.StreamStartedAt,
+				StreamID:        watch.change.StreamID,
+				CurrentState:    watch.change.CurrentState,
+			}
+			if d, err := time.ParseDuration(vod.Duration); err == nil {
+				change.VodDuration = d
+			}
+			setVodInfo(&change, vod)
+			slog.Info("アーカイブが公開されました", "streamer", change.CurrentState.DisplayName, "vod", vod.ID)
+			p.emit([]DetectedChange{change}, sc)
+			continue
+		}
+
+		if !now.Before(watch.deadline) {
+			delete(p.vodPublish.watches, key)
+			slog.Info("待機時間内にアーカイブが見つかりませんでした", "streamer", watch.change.CurrentState.DisplayName)
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package twitch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/yuu1111/StreamNotifier/internal/httpclient"
+)
+
+const helixBaseURL = "https://api.twitch.tv/helix"
+
+// MaxBatchSize はHelixの1リクエストで指定できるユーザー・チャンネル数の上限。
+const MaxBatchSize = 100
+
+// API はTwitch Helix APIクライアント。
+type API struct {
+	auth     *Auth
+	clientID string
+	limiter  *rateLimiter
+	// batchSize は1リクエストで指定するユーザー・チャンネル数。
+	batchSize int
+	// userToken はユーザーアクセストークンが必要なエンドポイントで使うトークン。空なら呼び出さない。
+	userToken string
+	// mock はモックモードでリクエストの代わりに応答を作る。nilならHelixへリクエストする。
+	mock *mockBackend
+
+	// clockSkew は直近のレスポンスのDateヘッダーから求めたローカルの時計のずれ(ナノ秒)。
+	clockSkew      atomic.Int64
+	clockSkewKnown atomic.Bool
+}
+
+// NewAPI はAPIインスタンスを作成する。リクエストはDefaultRequestsPerMinuteに制限される。
+func NewAPI(auth *Auth, clientID string) *API {
+	return &API{auth: auth, clientID: clientID, limiter: newRateLimiter(DefaultRequestsPerMinute), batchSize: MaxBatchSize}
+}
+
+// SetBatchSize は1リクエストで指定するユーザー・チャンネル数を変更する。
+// 長いクエリ文字列を扱えないプロキシを経由する場合に小さくする。範囲外ならMaxBatchSizeを使う。
+func (a *API) SetBatchSize(size int) {
+	if size <= 0 || size > MaxBatchSize {
+		size = MaxBatchSize
+	}
+	a.batchSize = size
+}
+
+// SetUserToken はユーザーアクセストークンが必要なエンドポイント(フォロワー数)で使うトークンを設定する。
+func (a *API) SetUserToken(token string) {
+	a.userToken = token
+}
+
+// HasUserToken はユーザーアクセストークンが設定されているかを返す。
+func (a *API) HasUserToken() bool {
+	return a.userToken != ""
+}
+
+// SetRateLimit は1分あたりのリクエスト数の上限を変更する。0以下ならDefaultRequestsPerMinuteを使う。
+// 最初のリクエストより前に呼ぶこと。
+func (a *API) SetRateLimit(perMinute int) {
+	if perMinute <= 0 {
+		perMinute = DefaultRequestsPerMinute
+	}
+	a.limiter = newRateLimiter(perMinute)
+}
+
+// request はAPIリクエストを実行しレスポンスデータを返す。
+func request[T any](ctx context.Context, a *API, endpoint string, params url.Values) ([]T, error) {
+	var apiResp apiResponse[T]
+	if err := a.get(ctx, endpoint, params, &apiResp); err != nil {
+		return nil, err
+	}
+	return apiResp.Data, nil
+}
+
+// requestBatched はvaluesをバッチサイズごとに分割してリクエストし、全バッチのデータを連結して返す。
+// paramsはバッチごとのクエリパラメータを作る。
+func requestBatched[T any](ctx context.Context, a *API, endpoint string, values []string, params func(batch []string) url.Values) ([]T, error) {
+	var all []T
+	for batch := range slices.Chunk(values, a.batchSize) {
+		data, err := request[T](ctx, a, endpoint, params(batch))
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, data...)
+	}
+	return all, nil
+}
+
+// defaultMaxPages はrequestPaginatedでページ数の上限を指定しなかった場合の上限。
+const defaultMaxPages = 10
+
+// requestPaginated はカーソルを辿って複数ページを取得し、全ページのデータを連結して返す。
+// カーソルが空になるかmaxPagesに達したら終了する。maxPagesが0以下ならdefaultMaxPagesを上限とする。
+func requestPaginated[T any](ctx context.Context, a *API, endpoint string, params url.Values, maxPages int) ([]T, error) {
+	if maxPages <= 0 {
+		maxPages = defaultMaxPages
+	}
+
+	params = maps.Clone(params)
+	if params == nil {
+		params = url.Values{}
+	}
+
+	var all []T
+	for page := 0; page < maxPages; page++ {
+		var apiResp apiResponse[T]
+		if err := a.get(ctx, endpoint, params, &apiResp); err != nil {
+			return nil, err
+		}
+		all = append(all, apiResp.Data...)
+
+		if apiResp.Pagination.Cursor == "" {
+			break
+		}
+		params.Set("after", apiResp.Pagination.Cursor)
+	}
+	return all, nil
+}
+
+// errNotFound はAPIが404を返したことを表す。404の*TwitchErrorはerrors.Isでこれと一致する。
+var errNotFound = errors.New("Twitch API: not found")
+
+// ErrNoUserToken はユーザーアクセストークンが必要なエンドポイントをトークン未設定で呼んだことを表す。
+var ErrNoUserToken = errors.New("ユーザーアクセストークンが設定されていません")
+
+// get はアプリのアクセストークンでGETリクエストを実行し、レスポンスJSONをoutにデコードする。
+func (a *API) get(ctx context.Context, endpoint string, params url.Values, out any) error {
+	if a.mock != nil {
+		return a.mock.get(endpoint, params, out)
+	}
+	token, err := a.auth.GetToken(ctx)
+	if err != nil {
+		return err
+	}
+	return a.getWithToken(ctx, token, endpoint, params, out)
+}
+
+// getWithToken は指定したトークンでGETリクエストを実行し、レスポンスJSONをoutにデコードする。
+func (a *API) getWithToken(ctx context.Context, token, endpoint string, params url.Values, out any) error {
+	if a.mock != nil {
+		return a.mock.get(endpoint, params, out)
+	}
+	if err := a.limiter.wait(ctx); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	reqURL := helixBaseURL + endpoint + "?" + params.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("APIリクエスト作成に失敗: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Client-Id", a.clientID)
+
+	resp, err := httpclient.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("APIリクエストに失敗: %w", err)
+	}
+	defer resp.Body.Close()
+	a.observeClock(resp.Header.Get("Date"), time.Now())
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("APIレスポンスの読み込みに失敗: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return newTwitchError(endpoint, resp.StatusCode, body)
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("APIレスポンスの解析に失敗: %w", err)
+	}
+	return nil
+}
+
+// observeClock はレスポンスのDateヘッダーとローカルの受信時刻からローカルの時計のずれを記録する。
+func (a *API) observeClock(date string, now time.Time) {
+	serverTime, err := http.ParseTime(date)
+	if err != nil {
+		return
+	}
+	// Dateヘッダーは秒単位で切り捨てられているため、区間の中央と比べる
+	a.clockSkew.Store(int64(now.Sub(serverTime.Add(500 * time.Millisecond))))
+	a.clockSkewKnown.Store(true)
+}
+
+// ClockSkew はTwitchのサーバー時刻に対するローカルの時計のずれを返す。正ならローカルの時計が進んでいる。
+// まだレスポンスを受け取っていなければokがfalse。
+func (a *API) ClockSkew() (skew time.Duration, ok bool) {
+	if !a.clockSkewKnown.Load() {
+		return 0, false
+	}
+	return time.Duration(a.clockSkew.Load()), true
+}
+
+// GetUsers はユーザー情報を取得する。返り値はlogin名(小文字)をキーとするmap。
+func (a *API) GetUsers(ctx context.Context, logins []string) (map[string]User, error) {
+	if len(logins) == 0 {
+		return make(map[string]User), nil
+	}
+
+	users, err := requestBatched[User](ctx, a, "/users", logins, func(batch []string) url.Values {
+		return url.Values{"login": batch}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]User, len(users))
+	for _, u := range users {
+		result[strings.ToLower(u.Login)] = u
+	}
+
+	slog.Debug("ユーザー情報取得", "count", len(users))
+	return result, nil
+}
+
+// GetStreams は配信中のストリーム情報を取得する。返り値はlogin名(小文字)をキーとするmap。
+func (a *API) GetStreams(ctx context.Context, userLogins []string) (map[string]Stream, error) {
+	if len(userLogins) == 0 {
+		return make(map[string]Stream), nil
+	}
+
+	streams, err := requestBatched[Stream](ctx, a, "/streams", userLogins, func(batch []string) url.Values {
+		// firstの既定値は20件のため、バッチ内の全員が配信中でも取りこぼさないよう指定する
+		return url.Values{"user_login": batch, "first": {strconv.Itoa(len(batch))}}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]Stream, len(streams))
+	for _, s := range streams {
+		result[strings.ToLower(s.UserLogin)] = s
+	}
+
+	slog.Debug("配信中", "count", len(streams))
+	return result, nil
+}
+
+// GetChannels はチャンネル情報を取得する。返り値はlogin名(小文字)をキーとするmap。
+func (a *API) GetChannels(ctx context.Context, broadcasterIDs []string) (map[string]Channel, error) {
+	if len(broadcasterIDs) == 0 {
+		return make(map[string]Channel), nil
+	}
+
+	channels, err := requestBatched[Channel](ctx, a, "/channels", broadcasterIDs, func(batch []string) url.Values {
+		return url.Values{"broadcaster_id": batch}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]Channel, len(channels))
+	for _, ch := range channels {
+		result[strings.ToLower(ch.BroadcasterLogin)] = ch
+	}
+
+	slog.Debug("チャンネル情報取得", "count", len(channels))
+	return result, nil
+}
+
+// GetLatestVod は最新のアーカイブVODを取得する。存在しない場合はnilを返す。
+func (a *API) GetLatestVod(ctx context.Context, userID string) (*Video, error) {
+	params := url.Values{
+		"user_id": {userID},
+		"type":    {"archive"},
+		"first":   {"1"},
+	}
+
+	videos, err := request[Video](ctx, a, "/videos", params)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(videos) == 0 {
+		return nil, nil
+	}
+	return &videos[0], nil
+}
+
+// GetVods は配信者のアーカイブVODを新しい順に取得する。maxPagesページ(1ページ100件)まで辿り、
+// 0以下ならdefaultMaxPagesを上限とする。
+func (a *API) GetVods(ctx context.Context, userID string, maxPages int) ([]Video, error) {
+	params := url.Values{
+		"user_id": {userID},
+		"type":    {"archive"},
+		"first":   {"100"},
+	}
+
+	videos, err := requestPaginated[Video](ctx, a, "/videos", params, maxPages)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("VOD一覧取得", "userId", userID, "count", len(videos))
+	return videos, nil
+}
+
+// GetFollowerCount は配信者のフォロワー数を取得する。ユーザーアクセストークンが未設定ならErrNoUserTokenを返す。
+func (a *API) GetFollowerCount(ctx context.Context, broadcasterID string) (int, error) {
+	if a.userToken == "" {
+		return 0, ErrNoUserToken
+	}
+	params := url.Values{
+		"broadcaster_id": {broadcasterID},
+		"first":          {"1"},
+	}
+
+	var resp followersResponse
+	if err := a.getWithToken(ctx, a.userToken, "/channels/followers", params, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Total, nil
+}
+
+// GetSchedule は配信スケジュールの今後のセグメントを取得する。スケジュール未設定の場合は空スライスを返す。
+func (a *API) GetSchedule(ctx context.Context, broadcasterID string) ([]ScheduleSegment, error) {
+	params := url.Values{
+		"broadcaster_id": {broadcasterID},
+		"first":          {"10"},
+	}
+
+	var resp scheduleResponse
+	err := a.get(ctx, "/schedule", params, &resp)
+	if errors.Is(err, errNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("配信スケジュール取得", "broadcasterId", broadcasterID, "count", len(resp.Data.Segments))
+	return resp.Data.Segments, nil
+}
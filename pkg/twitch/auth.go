@@ -11,6 +11,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/yuu1111/StreamNotifier/internal/httpclient"
 )
 
 // Auth はTwitch Client Credentials認証を管理する。
@@ -58,14 +60,14 @@ func (a *Auth) refreshToken(ctx context.Context) (string, error) {
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
-		"https://id.twitch.tv/oauth2/token",
+		tokenEndpoint,
 		strings.NewReader(form.Encode()))
 	if err != nil {
 		return "", fmt.Errorf("トークンリクエスト作成に失敗: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.Client.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("トークン取得に失敗: %w", err)
 	}
@@ -77,7 +79,7 @@ func (a *Auth) refreshToken(ctx context.Context) (string, error) {
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("Twitch認証失敗: %d %s", resp.StatusCode, string(body))
+		return "", fmt.Errorf("Twitch認証失敗: %w", newTwitchError(tokenEndpoint, resp.StatusCode, body))
 	}
 
 	var tokenResp tokenResponse
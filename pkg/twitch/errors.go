@@ -0,0 +1,77 @@
+package twitch
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// tokenEndpoint はClient Credentials Flowのトークン取得先。
+const tokenEndpoint = "https://id.twitch.tv/oauth2/token"
+
+// TwitchError はTwitch APIが返したエラーレスポンス({status, error, message})。
+// errors.Asで取り出し、認証エラー・未検出・サーバーエラーを区別できる。
+type TwitchError struct {
+	StatusCode int
+	// ErrorName はステータスの名前(例: "Unauthorized")。
+	ErrorName string
+	Message   string
+	// Endpoint はエラーを返したエンドポイント(Helixのパスまたはトークン取得URL)。
+	Endpoint string
+}
+
+func (e *TwitchError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("Twitch API エラー: %d %s", e.StatusCode, e.ErrorName)
+	}
+	return fmt.Sprintf("Twitch API エラー: %d %s", e.StatusCode, e.Message)
+}
+
+// Is はerrors.Is(err, errNotFound)で404を判定できるようにする。
+func (e *TwitchError) Is(target error) bool {
+	return target == errNotFound && e.IsNotFound()
+}
+
+// IsAuth はクライアントID・シークレットやアクセストークンが原因の認証エラーかを返す。
+// トークン取得で無効なクライアントIDは400、無効なシークレットは403で返される。
+func (e *TwitchError) IsAuth() bool {
+	switch e.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return true
+	case http.StatusBadRequest:
+		return e.Endpoint == tokenEndpoint
+	}
+	return false
+}
+
+// IsInvalidClientSecret はトークン取得でクライアントシークレットが無効と判定されたかを返す。
+func (e *TwitchError) IsInvalidClientSecret() bool {
+	return e.Endpoint == tokenEndpoint && strings.Contains(strings.ToLower(e.Message), "client secret")
+}
+
+// IsNotFound は404かを返す。
+func (e *TwitchError) IsNotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// IsServer はTwitch側の一時的な障害(5xx)かを返す。
+func (e *TwitchError) IsServer() bool {
+	return e.StatusCode >= 500
+}
+
+// newTwitchError はエラーレスポンスのボディを解析してTwitchErrorを作る。JSONでなければボディをそのままMessageにする。
+func newTwitchError(endpoint string, statusCode int, body []byte) *TwitchError {
+	e := &TwitchError{StatusCode: statusCode, Endpoint: endpoint}
+	var parsed struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		e.Message = strings.TrimSpace(string(body))
+		return e
+	}
+	e.ErrorName = parsed.Error
+	e.Message = parsed.Message
+	return e
+}
@@ -0,0 +1,233 @@
+package twitch
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MockFixture はモックモードで配信者の状態を記述するフィクスチャファイルの形式。
+type MockFixture struct {
+	Streamers []MockStreamer `json:"streamers"`
+}
+
+// MockStreamer はフィクスチャの配信者1人分の状態。liveやtitleを書き換えて保存すると、
+// 次のポーリングで配信開始・終了やタイトル変更として検出される。
+type MockStreamer struct {
+	// ID はユーザーID。省略時はloginを使う。
+	ID              string `json:"id,omitempty"`
+	Login           string `json:"login"`
+	DisplayName     string `json:"displayName,omitempty"`
+	ProfileImageURL string `json:"profileImageUrl,omitempty"`
+	Live            bool   `json:"live"`
+	// StreamID は配信ID。省略時はloginと配信開始時刻から作る。
+	StreamID    string `json:"streamId,omitempty"`
+	Title       string `json:"title,omitempty"`
+	GameID      string `json:"gameId,omitempty"`
+	GameName    string `json:"gameName,omitempty"`
+	ViewerCount int    `json:"viewerCount,omitempty"`
+	// StartedAt は配信開始時刻(RFC3339)。省略時はliveがtrueになったのを最初に読み込んだ時刻を使う。
+	StartedAt string `json:"startedAt,omitempty"`
+	Language  string `json:"language,omitempty"`
+	Followers int    `json:"followers,omitempty"`
+	// Vod は最新のアーカイブ。Helixの/videosと同じ形式で書く。
+	Vod *Video `json:"vod,omitempty"`
+	// Schedule は配信スケジュール。Helixの/scheduleと同じ形式で書く。
+	Schedule []ScheduleSegment `json:"schedule,omitempty"`
+}
+
+// userID は省略時の値を補ったユーザーIDを返す。
+func (s MockStreamer) userID() string {
+	if s.ID != "" {
+		return s.ID
+	}
+	return strings.ToLower(s.Login)
+}
+
+// displayName は省略時の値を補った表示名を返す。
+func (s MockStreamer) displayName() string {
+	if s.DisplayName != "" {
+		return s.DisplayName
+	}
+	return s.Login
+}
+
+// mockBackend はHelixへのリクエストの代わりにフィクスチャファイルから応答を作る。
+// ファイルはリクエストのたびに読み直すため、実行中に編集すれば状態を切り替えられる。
+type mockBackend struct {
+	path string
+
+	mu sync.Mutex
+	// liveSince はstartedAtを省略した配信者が配信中になった時刻(キー: login名小文字)。
+	liveSince map[string]time.Time
+}
+
+// NewMockAPI はpathのフィクスチャファイルを配信者の状態として返すAPIを作成する。
+// Twitchへの認証・リクエストは行わない。ローカルでの動作確認用。
+func NewMockAPI(path string) *API {
+	slog.Warn("Twitch APIのモックモードで動作しています", "fixture", path)
+	return &API{
+		limiter:   newRateLimiter(DefaultRequestsPerMinute),
+		batchSize: MaxBatchSize,
+		// フォロワー数もフィクスチャから返すため、ユーザーアクセストークンがあるものとして扱う
+		userToken: "mock",
+		mock:      &mockBackend{path: path, liveSince: make(map[string]time.Time)},
+	}
+}
+
+// load はフィクスチャファイルを読み込む。startedAtを省略した配信中の配信者には配信開始時刻を補う。
+func (m *mockBackend) load() (*MockFixture, error) {
+	data, err := os.ReadFile(m.path)
+	if err != nil {
+		return nil, fmt.Errorf("モックのフィクスチャの読み込みに失敗: %w", err)
+	}
+	var fixture MockFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("モックのフィクスチャの解析に失敗: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now().UTC()
+	for i := range fixture.Streamers {
+		s := &fixture.Streamers[i]
+		key := strings.ToLower(s.Login)
+		if !s.Live {
+			delete(m.liveSince, key)
+			continue
+		}
+		if s.StartedAt == "" {
+			since, ok := m.liveSince[key]
+			if !ok {
+				since = now
+				m.liveSince[key] = since
+			}
+			s.StartedAt = since.Format(time.RFC3339)
+		}
+	}
+	return &fixture, nil
+}
+
+// get はHelixのendpointへのGETリクエストに相当する応答をフィクスチャから作り、outにデコードする。
+// 対応していないエンドポイントは404を返す。
+func (m *mockBackend) get(endpoint string, params url.Values, out any) error {
+	fixture, err := m.load()
+	if err != nil {
+		return err
+	}
+
+	var resp any
+	switch endpoint {
+	case "/users":
+		var users []User
+		for _, s := range findMockStreamers(fixture, params["login"], func(s MockStreamer) string { return s.Login }) {
+			users = append(users, User{
+				ID:              s.userID(),
+				Login:           strings.ToLower(s.Login),
+				DisplayName:     s.displayName(),
+				ProfileImageURL: s.ProfileImageURL,
+			})
+		}
+		resp = apiResponse[User]{Data: users}
+	case "/streams":
+		var streams []Stream
+		for _, s := range findMockStreamers(fixture, params["user_login"], func(s MockStreamer) string { return s.Login }) {
+			if s.Live {
+				streams = append(streams, mockStream(s))
+			}
+		}
+		resp = apiResponse[Stream]{Data: streams}
+	case "/channels":
+		var channels []Channel
+		for _, s := range findMockStreamers(fixture, params["broadcaster_id"], MockStreamer.userID) {
+			channels = append(channels, Channel{
+				BroadcasterID:    s.userID(),
+				BroadcasterLogin: strings.ToLower(s.Login),
+				BroadcasterName:  s.displayName(),
+				GameID:           s.GameID,
+				GameName:         s.GameName,
+				Title:            s.Title,
+			})
+		}
+		resp = apiResponse[Channel]{Data: channels}
+	case "/videos":
+		var videos []Video
+		for _, s := range findMockStreamers(fixture, params["user_id"], MockStreamer.userID) {
+			if s.Vod != nil {
+				videos = append(videos, *s.Vod)
+			}
+		}
+		resp = apiResponse[Video]{Data: videos}
+	case "/channels/followers":
+		var followers followersResponse
+		for _, s := range findMockStreamers(fixture, params["broadcaster_id"], MockStreamer.userID) {
+			followers.Total = s.Followers
+		}
+		resp = followers
+	case "/schedule":
+		found := findMockStreamers(fixture, params["broadcaster_id"], MockStreamer.userID)
+		if len(found) == 0 || len(found[0].Schedule) == 0 {
+			return newTwitchError(endpoint, http.StatusNotFound, []byte(`{"error":"Not Found","message":"schedule not found"}`))
+		}
+		var schedule scheduleResponse
+		schedule.Data.Segments = found[0].Schedule
+		resp = schedule
+	default:
+		return newTwitchError(endpoint, http.StatusNotFound, []byte(`{"error":"Not Found","message":"not supported in mock mode"}`))
+	}
+
+	// 実際のレスポンスと同じ経路でデコードするため、一度JSONに変換する
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("APIレスポンスの解析に失敗: %w", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("APIレスポンスの解析に失敗: %w", err)
+	}
+	return nil
+}
+
+// findMockStreamers はkeyの値がvaluesのいずれかと一致する配信者を返す。大文字小文字は区別しない。
+func findMockStreamers(fixture *MockFixture, values []string, key func(MockStreamer) string) []MockStreamer {
+	var found []MockStreamer
+	for _, s := range fixture.Streamers {
+		if slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(v, key(s)) }) {
+			found = append(found, s)
+		}
+	}
+	return found
+}
+
+// mockStream は配信中の配信者の配信情報を作る。
+func mockStream(s MockStreamer) Stream {
+	streamID := s.StreamID
+	if streamID == "" {
+		id := s.StartedAt
+		if t, err := time.Parse(time.RFC3339, s.StartedAt); err == nil {
+			id = strconv.FormatInt(t.Unix(), 10)
+		}
+		streamID = "mock-" + strings.ToLower(s.Login) + "-" + id
+	}
+	login := strings.ToLower(s.Login)
+	return Stream{
+		ID:           streamID,
+		UserID:       s.userID(),
+		UserLogin:    login,
+		UserName:     s.displayName(),
+		GameID:       s.GameID,
+		GameName:     s.GameName,
+		Title:        s.Title,
+		ViewerCount:  s.ViewerCount,
+		StartedAt:    s.StartedAt,
+		ThumbnailURL: "https://static-cdn.jtvnw.net/previews-ttv/live_user_" + login + "-{width}x{height}.jpg",
+		Language:     s.Language,
+	}
+}
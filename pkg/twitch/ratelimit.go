@@ -0,0 +1,65 @@
+package twitch
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultRequestsPerMinute はTwitch Helix APIのアプリアクセストークンのレート制限(800ポイント/分)。
+const DefaultRequestsPerMinute = 800
+
+// rateLimiter は全APIリクエストで共有するトークンバケット。
+// 429を受けてから待つのではなく、送信前に待機して上限を超えないようにする。
+type rateLimiter struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	// interval はトークン1つが補充されるまでの時間。
+	interval time.Duration
+	last     time.Time
+}
+
+func newRateLimiter(perMinute int) *rateLimiter {
+	return &rateLimiter{
+		capacity: float64(perMinute),
+		tokens:   float64(perMinute),
+		interval: time.Minute / time.Duration(perMinute),
+		last:     time.Now(),
+	}
+}
+
+// wait はトークンを1つ取得できるまで待つ。待機中にctxがキャンセルされたらそのエラーを返す。
+func (l *rateLimiter) wait(ctx context.Context) error {
+	for {
+		delay := l.reserve(time.Now())
+		if delay <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve は経過時間分のトークンを補充し、取得できればトークンを消費して0を、
+// できなければ次のトークンが補充されるまでの時間を返す。
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens = min(l.capacity, l.tokens+float64(elapsed)/float64(l.interval))
+		l.last = now
+	}
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) * float64(l.interval))
+}
@@ -3,7 +3,13 @@ package twitch
 
 // apiResponse はTwitch APIの共通レスポンス構造。
 type apiResponse[T any] struct {
-	Data []T `json:"data"`
+	Data       []T        `json:"data"`
+	Pagination pagination `json:"pagination"`
+}
+
+// pagination は次ページ取得用のカーソル。最終ページでは空になる。
+type pagination struct {
+	Cursor string `json:"cursor"`
 }
 
 // User はTwitchユーザー情報。
@@ -26,6 +32,8 @@ type Stream struct {
 	ViewerCount  int    `json:"viewer_count"`
 	StartedAt    string `json:"started_at"`
 	ThumbnailURL string `json:"thumbnail_url"`
+	// Language は配信言語(ISO 639-1。例: "ja")。
+	Language string `json:"language"`
 }
 
 // Channel はTwitchチャンネル情報(オフライン時のタイトル/ゲーム取得用)。
@@ -50,6 +58,36 @@ type Video struct {
 	ThumbnailURL string `json:"thumbnail_url"`
 }
 
+// ScheduleCategory は配信スケジュールのカテゴリ。
+type ScheduleCategory struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// ScheduleSegment は配信スケジュールの1枠。
+type ScheduleSegment struct {
+	ID        string            `json:"id"`
+	StartTime string            `json:"start_time"`
+	EndTime   string            `json:"end_time"`
+	Title     string            `json:"title"`
+	Category  *ScheduleCategory `json:"category"`
+	// CanceledUntil はキャンセルされた枠で設定される。
+	CanceledUntil *string `json:"canceled_until"`
+	IsRecurring   bool    `json:"is_recurring"`
+}
+
+// scheduleResponse は/scheduleのレスポンス構造(dataが配列ではなくオブジェクト)。
+type scheduleResponse struct {
+	Data struct {
+		Segments []ScheduleSegment `json:"segments"`
+	} `json:"data"`
+}
+
+// followersResponse は/channels/followersのレスポンス構造。フォロワー数はtotalのみ使う。
+type followersResponse struct {
+	Total int `json:"total"`
+}
+
 // tokenResponse はOAuth2トークンレスポンス。
 type tokenResponse struct {
 	AccessToken string `json:"access_token"`
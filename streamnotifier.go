@@ -0,0 +1,91 @@
+// Package streamnotifier は配信監視を自前のGoプログラムに組み込むためのエントリーポイントを提供する。
+//
+// 設定・Twitch APIクライアント・監視・Discord通知の各機能はpkg/以下のパッケージとしても利用できる。
+package streamnotifier
+
+import (
+	"context"
+
+	"github.com/yuu1111/StreamNotifier/internal/httpclient"
+	"github.com/yuu1111/StreamNotifier/internal/notifier"
+	"github.com/yuu1111/StreamNotifier/pkg/config"
+	"github.com/yuu1111/StreamNotifier/pkg/discord"
+	"github.com/yuu1111/StreamNotifier/pkg/monitor"
+)
+
+// Monitor は設定の配信者をポーリングし、検出した変更をハンドラーに渡す。
+type Monitor struct {
+	poller     *monitor.Poller
+	dispatcher *notifier.Dispatcher
+	handler    monitor.ChangeHandler
+	// ctx はRunに渡されたcontext。標準ハンドラーでの送信に使う。
+	ctx context.Context
+}
+
+// New は設定を検証し、routesを解決してMonitorを作成する。routesの解決はコピーに対して行い、cfgは変更しない。
+// OnChangesでハンドラーを設定しない場合は、設定のWebhookへ通知を送信する。
+func New(cfg *config.Config) (*Monitor, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	cfg = cfg.WithRoutes()
+	httpclient.SetUserAgent(cfg.Network.UserAgent)
+	if err := httpclient.ConfigureTLS(cfg.Network.CAFile, cfg.Network.InsecureSkipVerify); err != nil {
+		return nil, err
+	}
+
+	api := monitor.NewTwitchAPI(cfg.Twitch)
+
+	discord.SetDeadWebhookThreshold(cfg.DeadWebhookFailures())
+	discord.SetSendConcurrency(cfg.SendConcurrency())
+	if cfg.DeadLetter.Enabled {
+		discord.SetDeadLetter(cfg.DeadLetter.DeadLetterPath(), cfg.DeadLetter.MaxDeadLetters())
+	}
+
+	m := &Monitor{
+		dispatcher: notifier.NewDispatcher(cfg, nil),
+		ctx:        context.Background(),
+	}
+	m.poller = monitor.NewPoller(api, cfg, m.handle)
+	return m, nil
+}
+
+// OnChanges は変更検出時に呼び出すハンドラーを設定する。Run前に呼ぶこと。
+func (m *Monitor) OnChanges(handler monitor.ChangeHandler) {
+	m.handler = handler
+}
+
+// OnResolved は見つからなかった配信者がユーザー情報の再取得(1時間ごと)で見つかったときに呼び出す関数を設定する。Run前に呼ぶこと。
+func (m *Monitor) OnResolved(fn monitor.ResolvedHandler) {
+	m.poller.SetResolvedHandler(fn)
+}
+
+// AddDetector は標準の検出器に加えて使う検出器を追加する。Run前に呼ぶこと。
+func (m *Monitor) AddDetector(d monitor.Detector) {
+	m.poller.AddDetector(d)
+}
+
+// Stats は現在の実行統計を返す。
+func (m *Monitor) Stats() monitor.StatsSnapshot {
+	return m.poller.Stats()
+}
+
+// TriggerPoll は次の定期ポーリングを待たずに全配信者をポーリングするよう要求する。Run中にどのgoroutineからでも呼べる。
+func (m *Monitor) TriggerPoll() {
+	m.poller.TriggerPoll()
+}
+
+// Run はctxがキャンセルされるまで監視する。
+func (m *Monitor) Run(ctx context.Context) error {
+	m.ctx = ctx
+	return m.poller.Run(ctx)
+}
+
+// handle は設定されたハンドラー、なければDispatcherに変更を渡す。
+func (m *Monitor) handle(changes []monitor.DetectedChange, sc config.StreamerConfig) {
+	if m.handler != nil {
+		m.handler(changes, sc)
+		return
+	}
+	m.dispatcher.Dispatch(m.ctx, changes, sc)
+}